@@ -8,6 +8,7 @@ package v1
 
 import (
 	_ "github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"
+	_ "github.com/yanking/app-skeleton/api/proto/gen/auth/v1"
 	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
@@ -27,11 +28,11 @@ var File_demo_v1_demo_proto protoreflect.FileDescriptor
 
 const file_demo_v1_demo_proto_rawDesc = "" +
 	"\n" +
-	"\x12demo/v1/demo.proto\x12\ademo.v1\x1a\x15demo/v1/healthz.proto\x1a\x12demo/v1/user.proto\x1a\x1cgoogle/api/annotations.proto\x1a\x1bgoogle/protobuf/empty.proto\x1a.protoc-gen-openapiv2/options/annotations.proto2\xe2\x01\n" +
-	"\vDemoService\x12M\n" +
-	"\x04Echo\x12\x14.demo.v1.EchoRequest\x1a\x15.demo.v1.EchoResponse\"\x18\x82\xd3\xe4\x93\x02\x12:\x01*\"\r/v1/demo/echo\x12\x83\x01\n" +
-	"\aHealthz\x12\x16.google.protobuf.Empty\x1a\x18.demo.v1.HealthzResponse\"F\x92A+\n" +
-	"\f服务治理\x12\x12服务健康检查*\aHealthz\x82\xd3\xe4\x93\x02\x12\x12\x10/v1/demo/healthzB\xd3\x01\x92A\x9b\x01\x12r\n" +
+	"\x12demo/v1/demo.proto\x12\ademo.v1\x1a\x12auth/v1/auth.proto\x1a\x15demo/v1/healthz.proto\x1a\x12demo/v1/user.proto\x1a\x1cgoogle/api/annotations.proto\x1a\x1bgoogle/protobuf/empty.proto\x1a.protoc-gen-openapiv2/options/annotations.proto2\xf2\x01\n" +
+	"\vDemoService\x12T\n" +
+	"\x04Echo\x12\x14.demo.v1.EchoRequest\x1a\x15.demo.v1.EchoResponse\"\x1f\xa2\xbb\x18\x00\x82\xd3\xe4\x93\x02\x12:\x01*\"\r/v1/demo/echo\x90\x02\x01\x12\x8c\x01\n" +
+	"\aHealthz\x12\x16.google.protobuf.Empty\x1a\x18.demo.v1.HealthzResponse\"O\x92A+\n" +
+	"\f服务治理\x12\x12服务健康检查*\aHealthz\xa2\xbb\x18\x02\b\x01\x82\xd3\xe4\x93\x02\x12\x12\x10/v1/demo/healthz\x90\x02\x01B\xd3\x01\x92A\x9b\x01\x12r\n" +
 	"\x10App-Skeleton API\"Y\n" +
 	"\x18小而美的博客项目\x12'https://github.com/yanking/app-skeleton\x1a\x14colin404@foxmail.com2\x031.0*\x01\x012\x10application/json:\x10application/jsonZ2github.com/yanking/app-skeleton/api/gen/demo/v1;v1b\x06proto3"
 
@@ -0,0 +1,139 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: demo/v1/error_reason.proto
+
+package v1
+
+import (
+	_ "github.com/yanking/app-skeleton/api/proto/gen/errors/v1"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ErrorReason demo 服务的错误原因，通过 protoc-gen-go-errors 生成 ErrorXxx 构造函数和 IsXxx 判断函数
+type ErrorReason int32
+
+const (
+	// 未指定，不生成构造函数
+	ErrorReason_ERROR_REASON_UNSPECIFIED ErrorReason = 0
+	// 用户不存在
+	ErrorReason_USER_NOT_FOUND ErrorReason = 1
+	// 创建用户的参数不合法
+	ErrorReason_INVALID_USER ErrorReason = 2
+	// 用户已存在
+	ErrorReason_USER_ALREADY_EXISTS ErrorReason = 3
+)
+
+// Enum value maps for ErrorReason.
+var (
+	ErrorReason_name = map[int32]string{
+		0: "ERROR_REASON_UNSPECIFIED",
+		1: "USER_NOT_FOUND",
+		2: "INVALID_USER",
+		3: "USER_ALREADY_EXISTS",
+	}
+	ErrorReason_value = map[string]int32{
+		"ERROR_REASON_UNSPECIFIED": 0,
+		"USER_NOT_FOUND":           1,
+		"INVALID_USER":             2,
+		"USER_ALREADY_EXISTS":      3,
+	}
+)
+
+func (x ErrorReason) Enum() *ErrorReason {
+	p := new(ErrorReason)
+	*p = x
+	return p
+}
+
+func (x ErrorReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ErrorReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_demo_v1_error_reason_proto_enumTypes[0].Descriptor()
+}
+
+func (ErrorReason) Type() protoreflect.EnumType {
+	return &file_demo_v1_error_reason_proto_enumTypes[0]
+}
+
+func (x ErrorReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ErrorReason.Descriptor instead.
+func (ErrorReason) EnumDescriptor() ([]byte, []int) {
+	return file_demo_v1_error_reason_proto_rawDescGZIP(), []int{0}
+}
+
+var File_demo_v1_error_reason_proto protoreflect.FileDescriptor
+
+const file_demo_v1_error_reason_proto_rawDesc = "" +
+	"\n" +
+	"\x1ademo/v1/error_reason.proto\x12\ademo.v1\x1a\x16errors/v1/errors.proto*\x82\x01\n" +
+	"\vErrorReason\x12\x1c\n" +
+	"\x18ERROR_REASON_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x0eUSER_NOT_FOUND\x10\x01\x1a\x04\xc8\xc1\x18\x05\x12\x16\n" +
+	"\fINVALID_USER\x10\x02\x1a\x04\xc8\xc1\x18\x03\x12\x1d\n" +
+	"\x13USER_ALREADY_EXISTS\x10\x03\x1a\x04\xc8\xc1\x18\x06\x1a\x04\xc0\xc1\x18\rB4Z2github.com/yanking/app-skeleton/api/gen/demo/v1;v1b\x06proto3"
+
+var (
+	file_demo_v1_error_reason_proto_rawDescOnce sync.Once
+	file_demo_v1_error_reason_proto_rawDescData []byte
+)
+
+func file_demo_v1_error_reason_proto_rawDescGZIP() []byte {
+	file_demo_v1_error_reason_proto_rawDescOnce.Do(func() {
+		file_demo_v1_error_reason_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_demo_v1_error_reason_proto_rawDesc), len(file_demo_v1_error_reason_proto_rawDesc)))
+	})
+	return file_demo_v1_error_reason_proto_rawDescData
+}
+
+var file_demo_v1_error_reason_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_demo_v1_error_reason_proto_goTypes = []any{
+	(ErrorReason)(0), // 0: demo.v1.ErrorReason
+}
+var file_demo_v1_error_reason_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_demo_v1_error_reason_proto_init() }
+func file_demo_v1_error_reason_proto_init() {
+	if File_demo_v1_error_reason_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_demo_v1_error_reason_proto_rawDesc), len(file_demo_v1_error_reason_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   0,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_demo_v1_error_reason_proto_goTypes,
+		DependencyIndexes: file_demo_v1_error_reason_proto_depIdxs,
+		EnumInfos:         file_demo_v1_error_reason_proto_enumTypes,
+	}.Build()
+	File_demo_v1_error_reason_proto = out.File
+	file_demo_v1_error_reason_proto_goTypes = nil
+	file_demo_v1_error_reason_proto_depIdxs = nil
+}
@@ -0,0 +1,44 @@
+// Code generated by protoc-gen-go-errors. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-errors v0.1.0
+// source: demo/v1/error_reason.proto
+
+package v1
+
+import (
+	errors "github.com/yanking/app-skeleton/pkg/errors"
+	codes "google.golang.org/grpc/codes"
+)
+
+// IsUserNotFound 错误原因是否为 USER_NOT_FOUND
+func IsUserNotFound(err error) bool {
+	return errors.Reason(err) == ErrorReason_USER_NOT_FOUND.String()
+}
+
+// ErrorUserNotFound 用户不存在
+// 状态码为 NotFound
+func ErrorUserNotFound(format string, args ...any) *errors.Error {
+	return errors.Newf(codes.NotFound, ErrorReason_USER_NOT_FOUND.String(), format, args...)
+}
+
+// IsInvalidUser 错误原因是否为 INVALID_USER
+func IsInvalidUser(err error) bool {
+	return errors.Reason(err) == ErrorReason_INVALID_USER.String()
+}
+
+// ErrorInvalidUser 创建用户的参数不合法
+// 状态码为 InvalidArgument
+func ErrorInvalidUser(format string, args ...any) *errors.Error {
+	return errors.Newf(codes.InvalidArgument, ErrorReason_INVALID_USER.String(), format, args...)
+}
+
+// IsUserAlreadyExists 错误原因是否为 USER_ALREADY_EXISTS
+func IsUserAlreadyExists(err error) bool {
+	return errors.Reason(err) == ErrorReason_USER_ALREADY_EXISTS.String()
+}
+
+// ErrorUserAlreadyExists 用户已存在
+// 状态码为 AlreadyExists
+func ErrorUserAlreadyExists(format string, args ...any) *errors.Error {
+	return errors.Newf(codes.AlreadyExists, ErrorReason_USER_ALREADY_EXISTS.String(), format, args...)
+}
@@ -0,0 +1,108 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: errors/v1/errors.proto
+
+package v1
+
+import (
+	code "google.golang.org/genproto/googleapis/rpc/code"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+var file_errors_v1_errors_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.EnumOptions)(nil),
+		ExtensionType: (*code.Code)(nil),
+		Field:         50200,
+		Name:          "errors.v1.default_code",
+		Tag:           "varint,50200,opt,name=default_code,enum=google.rpc.Code",
+		Filename:      "errors/v1/errors.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.EnumValueOptions)(nil),
+		ExtensionType: (*code.Code)(nil),
+		Field:         50201,
+		Name:          "errors.v1.code",
+		Tag:           "varint,50201,opt,name=code,enum=google.rpc.Code",
+		Filename:      "errors/v1/errors.proto",
+	},
+}
+
+// Extension fields to descriptorpb.EnumOptions.
+var (
+	// default_code 错误原因默认的 gRPC 状态码，声明了 default_code 的枚举由 protoc-gen-go-errors 生成错误构造函数.
+	// HTTP 状态码不单独声明，通过 gRPC-Gateway 返回时由 pkg/errors 根据 gRPC 状态码转换
+	//
+	// optional google.rpc.Code default_code = 50200;
+	E_DefaultCode = &file_errors_v1_errors_proto_extTypes[0]
+)
+
+// Extension fields to descriptorpb.EnumValueOptions.
+var (
+	// code 单个错误原因的 gRPC 状态码，未声明时使用枚举的 default_code
+	//
+	// optional google.rpc.Code code = 50201;
+	E_Code = &file_errors_v1_errors_proto_extTypes[1]
+)
+
+var File_errors_v1_errors_proto protoreflect.FileDescriptor
+
+const file_errors_v1_errors_proto_rawDesc = "" +
+	"\n" +
+	"\x16errors/v1/errors.proto\x12\terrors.v1\x1a google/protobuf/descriptor.proto\x1a\x15google/rpc/code.proto:S\n" +
+	"\fdefault_code\x12\x1c.google.protobuf.EnumOptions\x18\x98\x88\x03 \x01(\x0e2\x10.google.rpc.CodeR\vdefaultCode:I\n" +
+	"\x04code\x12!.google.protobuf.EnumValueOptions\x18\x99\x88\x03 \x01(\x0e2\x10.google.rpc.CodeR\x04codeB<Z:github.com/yanking/app-skeleton/api/proto/gen/errors/v1;v1b\x06proto3"
+
+var file_errors_v1_errors_proto_goTypes = []any{
+	(*descriptorpb.EnumOptions)(nil),      // 0: google.protobuf.EnumOptions
+	(*descriptorpb.EnumValueOptions)(nil), // 1: google.protobuf.EnumValueOptions
+	(code.Code)(0),                        // 2: google.rpc.Code
+}
+var file_errors_v1_errors_proto_depIdxs = []int32{
+	0, // 0: errors.v1.default_code:extendee -> google.protobuf.EnumOptions
+	1, // 1: errors.v1.code:extendee -> google.protobuf.EnumValueOptions
+	2, // 2: errors.v1.default_code:type_name -> google.rpc.Code
+	2, // 3: errors.v1.code:type_name -> google.rpc.Code
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	2, // [2:4] is the sub-list for extension type_name
+	0, // [0:2] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_errors_v1_errors_proto_init() }
+func file_errors_v1_errors_proto_init() {
+	if File_errors_v1_errors_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_errors_v1_errors_proto_rawDesc), len(file_errors_v1_errors_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   0,
+			NumExtensions: 2,
+			NumServices:   0,
+		},
+		GoTypes:           file_errors_v1_errors_proto_goTypes,
+		DependencyIndexes: file_errors_v1_errors_proto_depIdxs,
+		ExtensionInfos:    file_errors_v1_errors_proto_extTypes,
+	}.Build()
+	File_errors_v1_errors_proto = out.File
+	file_errors_v1_errors_proto_goTypes = nil
+	file_errors_v1_errors_proto_depIdxs = nil
+}
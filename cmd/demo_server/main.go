@@ -2,25 +2,39 @@
 package main
 
 import (
+	"compress/gzip"
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
+	"buf.build/go/protovalidate"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/redis/go-redis/v9"
 	"github.com/yanking/app-skeleton/api/proto/gen/demo/v1"
 	"github.com/yanking/app-skeleton/internal/config"
+	"github.com/yanking/app-skeleton/pkg/admin"
 	"github.com/yanking/app-skeleton/pkg/app"
+	"github.com/yanking/app-skeleton/pkg/auth"
+	"github.com/yanking/app-skeleton/pkg/errors"
 	pkgGrpc "github.com/yanking/app-skeleton/pkg/grpc"
+	httpmw "github.com/yanking/app-skeleton/pkg/grpc/httpmiddlewares"
+	srvintc "github.com/yanking/app-skeleton/pkg/grpc/serverinterceptors"
+	"github.com/yanking/app-skeleton/pkg/health"
 	"github.com/yanking/app-skeleton/pkg/log"
-	"github.com/yanking/app-skeleton/pkg/metric"
+	"github.com/yanking/app-skeleton/pkg/ratelimit"
+	"github.com/yanking/app-skeleton/pkg/registry"
+	"github.com/yanking/app-skeleton/pkg/tlsconfig"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 
 	demoHandler "github.com/yanking/app-skeleton/internal/demo_server/handler/grpc"
 )
 
+const configFile = "configs/demo_server.yaml"
+
 func main() {
 	// 初始化配置
-	if err := config.Init("configs/demo_server.yaml"); err != nil {
+	if err := config.Init(configFile); err != nil {
 		log.Fatalf("failed to initialize config: %v", err)
 	}
 	cfg := config.Get()
@@ -31,42 +45,226 @@ func main() {
 
 	var components []app.IComponent
 
+	// a 在组件处理请求时已经创建完成
+	var a *app.App
+
+	// 健康检查，App 的就绪状态作为关键检查项
+	checks := health.NewRegistry()
+	checks.MustRegister(health.Checker{
+		Name:     "app",
+		Kinds:    []health.Kind{health.Readiness},
+		Critical: true,
+		Check:    func(ctx context.Context) error { return a.CheckReady(ctx) },
+	})
+	checks.MustRegister(health.Checker{
+		Name:     "app-started",
+		Kinds:    []health.Kind{health.Startup},
+		Critical: true,
+		Check:    func(ctx context.Context) error { return a.CheckStarted(ctx) },
+	})
+	components = append(components, checks)
+
 	// 创建 gRPC 服务器（带 gRPC-Gateway）
 	grpcOptions := []pkgGrpc.ServerOption{
 		pkgGrpc.WithAddress(cfg.Grpc.Addr),
-		pkgGrpc.WithTimeout(time.Second * 5),
+		pkgGrpc.WithAdvertiseHost(cfg.Grpc.Advertise.Host),
+		pkgGrpc.WithAdvertiseInterface(cfg.Grpc.Advertise.Interface),
+		pkgGrpc.WithTimeout(cfg.Grpc.Timeout),
+		pkgGrpc.WithHealthChecks(checks),
+		pkgGrpc.WithLoadShedding(cfg.Grpc.Shedding),
 		pkgGrpc.WithGateway(cfg.Grpc.Gateway.Enabled, cfg.HTTP.Addr), // 根据配置启用 gRPC-Gateway
+		pkgGrpc.WithSinglePort(cfg.Grpc.Gateway.SinglePort),
+		pkgGrpc.WithGatewayTimeout(cfg.HTTP.Timeout),
+		pkgGrpc.WithErrorDomain(cfg.AppName),
+		// 将 HTTP 请求 ID 转发给 gRPC 服务，错误使用统一的 JSON 结构返回
+		pkgGrpc.WithGatewayOptions(runtime.WithMetadata(httpmw.RequestIDMetadata)),
+		pkgGrpc.WithGatewayOptions(errors.GatewayOptions()...),
+	}
+	trustedProxies, err := pkgGrpc.ParseTrustedProxies(cfg.Grpc.Gateway.TrustedProxies)
+	if err != nil {
+		log.Fatalf("invalid grpc.gateway.trusted-proxies: %v", err)
+	}
+	grpcOptions = append(grpcOptions, pkgGrpc.WithTrustedProxies(trustedProxies...))
+	// HTTP 中间件按顺序执行：panic 恢复、请求 ID、访问日志、指标、跨域、压缩
+	gatewayMiddlewares := []httpmw.Middleware{httpmw.Recovery, httpmw.RequestID}
+	if cfg.HTTP.AccessLog {
+		gatewayMiddlewares = append(gatewayMiddlewares, httpmw.AccessLog)
 	}
 	if cfg.EnableMetrics {
-		metric.Handler()
+		gatewayMiddlewares = append(gatewayMiddlewares, httpmw.Metrics)
+	}
+	if cfg.HTTP.CORS.Enabled {
+		gatewayMiddlewares = append(gatewayMiddlewares, httpmw.CORS(cfg.HTTP.CORS))
+	}
+	if cfg.HTTP.Gzip {
+		gatewayMiddlewares = append(gatewayMiddlewares, httpmw.Gzip(gzip.DefaultCompression))
+	}
+	grpcOptions = append(grpcOptions, pkgGrpc.WithGatewayMiddleware(gatewayMiddlewares...))
+	if cfg.EnableMetrics {
 		grpcOptions = append(grpcOptions, pkgGrpc.WithMetrics(true))
 	}
+	if cfg.Registry.Enabled {
+		grpcOptions = append(grpcOptions, pkgGrpc.WithRegistrar(registry.NewFile(cfg.Registry.File), &registry.ServiceInstance{
+			Name:     cfg.AppName,
+			Metadata: map[string]string{registry.MetadataWeight: strconv.Itoa(cfg.Registry.Weight)},
+		}))
+	}
+	if cfg.Grpc.TLS.Enabled {
+		tlsConfig, err := tlsconfig.NewServerConfig(&cfg.Grpc.TLS)
+		if err != nil {
+			log.Fatalf("failed to load grpc tls config: %v", err)
+		}
+		grpcOptions = append(grpcOptions, pkgGrpc.WithTLSConfig(tlsConfig))
+	}
+	if cfg.HTTP.TLS.Enabled {
+		tlsConfig, err := tlsconfig.NewServerConfig(&cfg.HTTP.TLS)
+		if err != nil {
+			log.Fatalf("failed to load http tls config: %v", err)
+		}
+		grpcOptions = append(grpcOptions, pkgGrpc.WithGatewayTLSConfig(tlsConfig))
+	}
+	// 拦截器按顺序执行：认证、授权、限流（按 subject 限流依赖认证结果）、请求校验
+	var unaryInts []grpc.UnaryServerInterceptor
+	var streamInts []grpc.StreamServerInterceptor
+	var authorizer *srvintc.Authorizer
+	if cfg.Auth.Enabled {
+		verifierOptions := []auth.VerifierOption{auth.WithExpectedIssuer(cfg.Auth.Issuer)}
+		if cfg.JwtKey != "" {
+			verifierOptions = append(verifierOptions, auth.WithHMACKey(cfg.JwtKey))
+		}
+		if cfg.Auth.JWKSFile != "" {
+			verifierOptions = append(verifierOptions, auth.WithJWKSFile(cfg.Auth.JWKSFile))
+		}
+		verifier, err := auth.NewVerifier(verifierOptions...)
+		if err != nil {
+			log.Fatalf("failed to create token verifier: %v", err)
+		}
+		// 访问策略声明在 proto 中，存在未声明策略的方法时启动失败
+		authorizer, err = srvintc.NewAuthorizer(v1.DemoService_ServiceDesc.ServiceName, v1.UserService_ServiceDesc.ServiceName)
+		if err != nil {
+			log.Fatalf("failed to create authorizer: %v", err)
+		}
+		// 配置中的 public-methods 必须在 proto 中声明为 public，否则会跳过认证后被授权拒绝
+		if err := authorizer.CheckPublic(cfg.Auth.PublicMethods...); err != nil {
+			log.Fatalf("invalid auth.public-methods: %v", err)
+		}
+		publicMethods := append(authorizer.PublicMethods(), cfg.Auth.PublicMethods...)
+		unaryInts = append(unaryInts, srvintc.UnaryAuthInterceptor(verifier, publicMethods...), authorizer.UnaryInterceptor)
+		streamInts = append(streamInts, srvintc.StreamAuthInterceptor(verifier, publicMethods...), authorizer.StreamInterceptor)
+	}
+	var limiter *ratelimit.Limiter
+	var redisClient redis.UniversalClient
+	if cfg.RateLimit != nil && cfg.RateLimit.Enabled {
+		var store ratelimit.Store = ratelimit.NewMemoryStore()
+		if cfg.RateLimit.Store == ratelimit.StoreRedis {
+			redisClient = newRedisClient(&cfg.Redis)
+			store = ratelimit.NewRedisStore(redisClient, cfg.AppName+":ratelimit")
+		}
+		limiter = ratelimit.NewLimiter(cfg.RateLimit, store)
+		unaryInts = append(unaryInts, srvintc.UnaryRateLimitInterceptor(limiter))
+		streamInts = append(streamInts, srvintc.StreamRateLimitInterceptor(limiter))
+	}
+	// 校验规则声明在 proto 中，启动时预先编译，规则不合法时启动失败
+	validator, err := protovalidate.New(protovalidate.WithMessages(
+		&v1.GetUserRequest{}, &v1.ListUsersRequest{}, &v1.CreateUserRequest{},
+	))
+	if err != nil {
+		log.Fatalf("failed to create request validator: %v", err)
+	}
+	unaryInts = append(unaryInts, srvintc.UnaryValidateInterceptor(validator))
+	streamInts = append(streamInts, srvintc.StreamValidateInterceptor(validator))
+	grpcOptions = append(grpcOptions,
+		pkgGrpc.WithUnaryInterceptor(unaryInts...),
+		pkgGrpc.WithStreamInterceptor(streamInts...),
+	)
 	rpcServer := pkgGrpc.NewServer(grpcOptions...)
-	
+
 	// 注册 gRPC 服务
-	v1.RegisterDemoServiceServer(rpcServer.Server, demoHandler.NewHandler())
+	v1.RegisterDemoServiceServer(rpcServer.Server, demoHandler.NewHandler(checks))
 	userHandler := demoHandler.NewUserHandler()
 	v1.RegisterUserServiceServer(rpcServer.Server, userHandler)
+	if authorizer != nil {
+		if err := authorizer.Covers(rpcServer.Services()...); err != nil {
+			log.Fatalf("failed to verify access policies: %v", err)
+		}
+	}
 
-	// 注册 gRPC-Gateway 处理程序（直接使用生成的函数）
+	// 注册 gRPC-Gateway 处理程序，通过进程内连接调用 gRPC 服务
 	if cfg.Grpc.Gateway.Enabled {
-		ctx := context.Background()
-		opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
-		
-		// 注册DemoService
-		if err := v1.RegisterDemoServiceHandlerFromEndpoint(ctx, rpcServer.GetGatewayMux(), cfg.Grpc.Addr, opts); err != nil {
-			log.Fatalf("failed to register demo service gateway: %v", err)
-		}
-		
-		// 注册UserService
-		if err := v1.RegisterUserServiceHandlerFromEndpoint(ctx, rpcServer.GetGatewayMux(), cfg.Grpc.Addr, opts); err != nil {
-			log.Fatalf("failed to register user service gateway: %v", err)
+		if err := rpcServer.RegisterGateway(context.Background(), v1.RegisterDemoServiceHandler, v1.RegisterUserServiceHandler); err != nil {
+			log.Fatalf("failed to register gateway handlers: %v", err)
 		}
 	}
 
 	components = append(components, rpcServer)
 
-	a, err := app.New(cfg.AppName, components)
+	if cfg.Admin.Enabled {
+		adminServer := admin.NewServer(
+			admin.WithAddress(cfg.Admin.Addr),
+			admin.WithConfig(func() any { return config.Get() }),
+			admin.WithStatus(func() any {
+				return map[string]any{"ready": a.IsReady(), "components": a.Status()}
+			}),
+			admin.WithHandler("/livez", checks.Handler(health.Liveness)),
+			admin.WithHandler("/readyz", checks.Handler(health.Readiness)),
+			admin.WithHandler("/startupz", checks.Handler(health.Startup)),
+		)
+		components = append(components, adminServer)
+	}
+
+	a, err = app.New(cfg.AppName, components,
+		app.WithStartTimeout(cfg.Lifecycle.StartTimeout),
+		app.WithShutdownTimeout(cfg.Lifecycle.ShutdownTimeout),
+		app.WithStopTimeout(cfg.Lifecycle.StopTimeout),
+		app.WithPreStopDelay(cfg.Lifecycle.PreStopDelay),
+		app.WithParallelStop(cfg.Lifecycle.ParallelStop),
+		// 所有组件就绪后立即同步健康状态，不必等待下一次周期检查
+		app.WithHooks(app.AfterStart, app.Hook{Name: "health-refresh", Fn: func(ctx context.Context) error {
+			checks.Refresh(ctx)
+			return nil
+		}}),
+		app.WithHooks(app.AfterStop, app.Hook{Name: "redis-close", Fn: func(ctx context.Context) error {
+			if redisClient == nil {
+				return nil
+			}
+			return redisClient.Close()
+		}}),
+		// 所有组件停止后刷新日志缓冲
+		app.WithHooks(app.AfterStop, app.Hook{Name: "log-sync", Fn: func(ctx context.Context) error {
+			log.Sync()
+			return nil
+		}}),
+		// 收到 SIGHUP 时重新加载配置，任一参与者拒绝时回滚
+		app.WithConfig(cfg, func() (any, error) { return config.Load(configFile) }),
+		app.WithReloader("config", func(ctx context.Context, c any) error {
+			config.Set(c.(*config.Config))
+			return nil
+		}),
+		app.WithReloader("log-level", func(ctx context.Context, c any) error {
+			log.SetLevel(c.(*config.Config).Log.Level)
+			return nil
+		}),
+		app.WithReloader("grpc-timeout", func(ctx context.Context, c any) error {
+			rpcServer.SetTimeout(c.(*config.Config).Grpc.Timeout)
+			return nil
+		}),
+		// 限流规则可以重载，启用/停用限流和切换存储后端需要重启
+		app.WithReloader("ratelimit", func(ctx context.Context, c any) error {
+			opts := c.(*config.Config).RateLimit
+			enabled := opts != nil && opts.Enabled
+			if enabled != (limiter != nil) {
+				return fmt.Errorf("ratelimit can not be enabled or disabled without a restart")
+			}
+			if limiter == nil {
+				return nil
+			}
+			if opts.Store != cfg.RateLimit.Store {
+				return fmt.Errorf("ratelimit store can not be changed without a restart")
+			}
+			limiter.Update(opts)
+			return nil
+		}),
+	)
 	if err != nil {
 		panic(err)
 	}
@@ -75,4 +273,20 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
-}
\ No newline at end of file
+}
+
+// newRedisClient 根据配置创建 Redis 客户端，配置了 addrs 时使用集群模式
+func newRedisClient(c *config.RedisConfig) redis.UniversalClient {
+	addrs := c.Addrs
+	if len(addrs) == 0 {
+		addrs = []string{c.Addr}
+	}
+	return redis.NewUniversalClient(&redis.UniversalOptions{
+		Addrs:        addrs,
+		Password:     c.Password,
+		DB:           c.Db,
+		DialTimeout:  time.Duration(c.DialTimeout) * time.Second,
+		ReadTimeout:  time.Duration(c.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(c.WriteTimeout) * time.Second,
+	})
+}
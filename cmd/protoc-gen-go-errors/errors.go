@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+
+	errorsv1 "github.com/yanking/app-skeleton/api/proto/gen/errors/v1"
+)
+
+const (
+	errorsPackage = protogen.GoImportPath("github.com/yanking/app-skeleton/pkg/errors")
+	codesPackage  = protogen.GoImportPath("google.golang.org/grpc/codes")
+)
+
+// errorValue 需要生成构造函数的枚举值
+type errorValue struct {
+	value *protogen.EnumValue
+	code  codes.Code
+	name  string
+}
+
+// generateFile 生成 xxx_errors.pb.go，文件中没有声明了 default_code 的枚举时不生成
+func generateFile(gen *protogen.Plugin, file *protogen.File) error {
+	var values []errorValue
+	for _, enum := range file.Enums {
+		vs, err := errorValues(enum)
+		if err != nil {
+			return err
+		}
+		values = append(values, vs...)
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	g := gen.NewGeneratedFile(file.GeneratedFilenamePrefix+"_errors.pb.go", file.GoImportPath)
+	g.P("// Code generated by protoc-gen-go-errors. DO NOT EDIT.")
+	g.P("// versions:")
+	g.P("// - protoc-gen-go-errors v", version)
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	g.P("package ", file.GoPackageName)
+	g.P()
+	for _, v := range values {
+		generateValue(g, v)
+	}
+	return nil
+}
+
+// errorValues 返回枚举中需要生成的值，枚举没有声明 default_code 时返回 nil
+func errorValues(enum *protogen.Enum) ([]errorValue, error) {
+	opts := enum.Desc.Options()
+	if opts == nil || !proto.HasExtension(opts, errorsv1.E_DefaultCode) {
+		return nil, nil
+	}
+	defaultCode := proto.GetExtension(opts, errorsv1.E_DefaultCode).(code.Code)
+
+	var values []errorValue
+	for _, v := range enum.Values {
+		if v.Desc.Number() == 0 && strings.HasSuffix(string(v.Desc.Name()), "_UNSPECIFIED") {
+			continue
+		}
+		c := defaultCode
+		if vo := v.Desc.Options(); vo != nil && proto.HasExtension(vo, errorsv1.E_Code) {
+			c = proto.GetExtension(vo, errorsv1.E_Code).(code.Code)
+		}
+		if c == code.Code_OK {
+			return nil, fmt.Errorf("%s: error code must not be OK, set (errors.v1.code) or (errors.v1.default_code)", v.Desc.FullName())
+		}
+		values = append(values, errorValue{value: v, code: codes.Code(c), name: camelCase(string(v.Desc.Name()))})
+	}
+	return values, nil
+}
+
+func generateValue(g *protogen.GeneratedFile, v errorValue) {
+	reason := g.QualifiedGoIdent(v.value.GoIdent) + ".String()"
+	codeIdent := g.QualifiedGoIdent(codesPackage.Ident(v.code.String()))
+	errorType := g.QualifiedGoIdent(errorsPackage.Ident("Error"))
+
+	g.P("// Is", v.name, " 错误原因是否为 ", v.value.Desc.Name())
+	g.P("func Is", v.name, "(err error) bool {")
+	g.P("return ", errorsPackage.Ident("Reason"), "(err) == ", reason)
+	g.P("}")
+	g.P()
+
+	if comments := strings.TrimSpace(string(v.value.Comments.Leading)); comments != "" {
+		for i, line := range strings.Split(comments, "\n") {
+			if i == 0 {
+				g.P("// Error", v.name, " ", strings.TrimSpace(line))
+				continue
+			}
+			g.P("// ", strings.TrimSpace(line))
+		}
+	} else {
+		g.P("// Error", v.name, " 创建原因为 ", v.value.Desc.Name(), " 的错误")
+	}
+	g.P("// 状态码为 ", v.code)
+	g.P("func Error", v.name, "(format string, args ...any) *", errorType, " {")
+	g.P("return ", errorsPackage.Ident("Newf"), "(", codeIdent, ", ", reason, ", format, args...)")
+	g.P("}")
+	g.P()
+}
+
+// camelCase 将 USER_NOT_FOUND 转换为 UserNotFound
+func camelCase(s string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(s, "_") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]))
+		b.WriteString(strings.ToLower(part[1:]))
+	}
+	return b.String()
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+
+	v1 "github.com/yanking/app-skeleton/api/proto/gen/demo/v1"
+	errorsv1 "github.com/yanking/app-skeleton/api/proto/gen/errors/v1"
+)
+
+// generate 对 file 运行插件，返回生成的文件名和内容
+func generate(t *testing.T, file *descriptorpb.FileDescriptorProto) (map[string]string, error) {
+	t.Helper()
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{file.GetName()},
+		Parameter:      proto.String("paths=source_relative"),
+		ProtoFile: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(descriptorpb.File_google_protobuf_descriptor_proto),
+			protodesc.ToFileDescriptorProto(code.File_google_rpc_code_proto),
+			protodesc.ToFileDescriptorProto(errorsv1.File_errors_v1_errors_proto),
+			file,
+		},
+	}
+	gen, err := protogen.Options{}.New(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range gen.Files {
+		if !f.Generate {
+			continue
+		}
+		if err := generateFile(gen, f); err != nil {
+			return nil, err
+		}
+	}
+	res := gen.Response()
+	if res.Error != nil {
+		t.Fatal(res.GetError())
+	}
+	files := map[string]string{}
+	for _, f := range res.GetFile() {
+		files[f.GetName()] = f.GetContent()
+	}
+	return files, nil
+}
+
+// TestGenerateGolden 生成的代码与仓库中 demo/v1/error_reason.proto 生成的文件一致
+func TestGenerateGolden(t *testing.T) {
+	file := protodesc.ToFileDescriptorProto(v1.File_demo_v1_error_reason_proto)
+	// 编译进 Go 代码的描述符不包含注释，这里补上 error_reason.proto 中枚举值的注释
+	file.SourceCodeInfo = &descriptorpb.SourceCodeInfo{}
+	for i, comment := range []string{"", " 用户不存在\n", " 创建用户的参数不合法\n", " 用户已存在\n"} {
+		if comment == "" {
+			continue
+		}
+		file.SourceCodeInfo.Location = append(file.SourceCodeInfo.Location, &descriptorpb.SourceCodeInfo_Location{
+			// FileDescriptorProto.enum_type = 5, EnumDescriptorProto.value = 2
+			Path:            []int32{5, 0, 2, int32(i)},
+			Span:            []int32{0, 0, 0},
+			LeadingComments: proto.String(comment),
+		})
+	}
+
+	files, err := generate(t, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("../../api/proto/gen/demo/v1/error_reason_errors.pb.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := files["demo/v1/error_reason_errors.pb.go"]; got != string(want) {
+		t.Fatalf("generated code differs from api/proto/gen/demo/v1/error_reason_errors.pb.go, run make protoc:\n%s", got)
+	}
+}
+
+// reasonFile 返回包含一个 Reason 枚举的文件，defaultCode 为 nil 时不声明 default_code
+func reasonFile(defaultCode *code.Code, values ...*descriptorpb.EnumValueDescriptorProto) *descriptorpb.FileDescriptorProto {
+	opts := &descriptorpb.EnumOptions{}
+	if defaultCode != nil {
+		proto.SetExtension(opts, errorsv1.E_DefaultCode, *defaultCode)
+	}
+	return &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("test/v1/reason.proto"),
+		Package:    proto.String("test.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"errors/v1/errors.proto"},
+		Options:    &descriptorpb.FileOptions{GoPackage: proto.String("example.com/test/v1;testv1")},
+		EnumType:   []*descriptorpb.EnumDescriptorProto{{Name: proto.String("Reason"), Value: values, Options: opts}},
+	}
+}
+
+// enumValue 返回枚举值，c 不为 nil 时声明 (errors.v1.code)
+func enumValue(name string, number int32, c *code.Code) *descriptorpb.EnumValueDescriptorProto {
+	opts := &descriptorpb.EnumValueOptions{}
+	if c != nil {
+		proto.SetExtension(opts, errorsv1.E_Code, *c)
+	}
+	return &descriptorpb.EnumValueDescriptorProto{Name: proto.String(name), Number: proto.Int32(number), Options: opts}
+}
+
+func TestGenerate(t *testing.T) {
+	internal, notFound, ok := code.Code_INTERNAL, code.Code_NOT_FOUND, code.Code_OK
+	tests := []struct {
+		name string
+		file *descriptorpb.FileDescriptorProto
+		// want 生成的代码中应包含的内容，notWant 不应包含的内容，wantErr 错误中应包含的内容
+		want    []string
+		notWant []string
+		wantErr string
+	}{
+		{
+			name: "no default code",
+			file: reasonFile(nil, enumValue("REASON_UNSPECIFIED", 0, nil), enumValue("NOT_FOUND", 1, &notFound)),
+		},
+		{
+			name: "default and value codes",
+			file: reasonFile(&internal,
+				enumValue("REASON_UNSPECIFIED", 0, nil), enumValue("QUOTA_BROKEN", 1, nil), enumValue("ORDER_NOT_FOUND", 2, &notFound)),
+			want: []string{
+				"// ErrorQuotaBroken 创建原因为 QUOTA_BROKEN 的错误\n// 状态码为 Internal\n",
+				"return errors.Newf(codes.Internal, Reason_QUOTA_BROKEN.String(), format, args...)",
+				"return errors.Newf(codes.NotFound, Reason_ORDER_NOT_FOUND.String(), format, args...)",
+				"func IsOrderNotFound(err error) bool {\n\treturn errors.Reason(err) == Reason_ORDER_NOT_FOUND.String()",
+			},
+			notWant: []string{"ReasonUnspecified", "HTTP"},
+		},
+		{
+			name: "zero value without the unspecified suffix",
+			file: reasonFile(&internal, enumValue("UNKNOWN", 0, nil)),
+			want: []string{"func ErrorUnknown(format string, args ...any) *errors.Error"},
+		},
+		{
+			name:    "ok code",
+			file:    reasonFile(&internal, enumValue("REASON_UNSPECIFIED", 0, nil), enumValue("DONE", 1, &ok)),
+			wantErr: "test.v1.DONE: error code must not be OK",
+		},
+		{
+			name:    "ok default code",
+			file:    reasonFile(&ok, enumValue("REASON_UNSPECIFIED", 0, nil), enumValue("DONE", 1, nil)),
+			wantErr: "error code must not be OK",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := generate(t, tt.file)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, generated := files["test/v1/reason_errors.pb.go"]
+			if generated != (len(tt.want) > 0) {
+				t.Fatalf("generated files = %v", files)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Fatalf("generated code does not contain %q:\n%s", want, got)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Fatalf("generated code contains %q:\n%s", notWant, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCamelCase(t *testing.T) {
+	tests := map[string]string{
+		"USER_NOT_FOUND": "UserNotFound",
+		"already_exists": "AlreadyExists",
+		"A__B_":          "AB",
+		"V2_API":         "V2Api",
+	}
+	for in, want := range tests {
+		if got := camelCase(in); got != want {
+			t.Errorf("camelCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
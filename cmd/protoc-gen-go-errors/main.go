@@ -0,0 +1,51 @@
+// Package main 实现 protoc-gen-go-errors 插件，为声明了 (errors.v1.default_code) 的枚举生成错误构造函数和判断函数.
+//
+// 插件通过 buf.gen.yaml 调用，生成的文件为 xxx_errors.pb.go，与 protoc-gen-go 生成的枚举位于同一个包:
+//
+//	enum ErrorReason {
+//	  option (errors.v1.default_code) = INTERNAL;
+//	  ERROR_REASON_UNSPECIFIED = 0;
+//	  USER_NOT_FOUND = 1 [(errors.v1.code) = NOT_FOUND];
+//	}
+//
+// 对每个枚举值生成:
+//
+//	func ErrorUserNotFound(format string, args ...any) *errors.Error
+//	func IsUserNotFound(err error) bool
+//
+// 错误的原因为枚举值的名称，状态码为枚举值的 (errors.v1.code)，未声明时使用枚举的 default_code.
+// 生成的代码只包含 gRPC 状态码，通过 gRPC-Gateway 返回时由 pkg/errors 的 GatewayOptions 转换为 HTTP 状态码.
+// 以 _UNSPECIFIED 结尾的零值不生成.
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+const version = "0.1.0"
+
+func main() {
+	showVersion := flag.Bool("version", false, "print the version and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Printf("protoc-gen-go-errors %v\n", version)
+		return
+	}
+
+	protogen.Options{}.Run(func(gen *protogen.Plugin) error {
+		gen.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+		for _, f := range gen.Files {
+			if !f.Generate {
+				continue
+			}
+			if err := generateFile(gen, f); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"slices"
+
+	"buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// constraints 一个字段在 OpenAPI 中的约束，指针为 nil 表示没有约束
+type constraints struct {
+	minimum          *float64
+	exclusiveMinimum bool
+	maximum          *float64
+	exclusiveMaximum bool
+	minLength        *uint64
+	maxLength        *uint64
+	pattern          string
+	format           string
+	enum             []string
+	minItems         *uint64
+	maxItems         *uint64
+	uniqueItems      bool
+	required         bool
+}
+
+func (c constraints) empty() bool {
+	return c.minimum == nil && c.maximum == nil && c.minLength == nil && c.maxLength == nil && c.pattern == "" &&
+		c.format == "" && len(c.enum) == 0 && c.minItems == nil && c.maxItems == nil && !c.uniqueItems && !c.required
+}
+
+// fieldConstraints 返回字段的约束：先由校验规则得到，再以显式声明的 (openapiv2_field) 和 field_behavior 为准
+func fieldConstraints(opts *descriptorpb.FieldOptions) constraints {
+	c := rulesConstraints(opts)
+	if schema, ok := proto.GetExtension(opts, options.E_Openapiv2Field).(*options.JSONSchema); ok && schema != nil {
+		c.override(schema)
+	}
+	if behaviors, ok := proto.GetExtension(opts, annotations.E_FieldBehavior).([]annotations.FieldBehavior); ok &&
+		slices.Contains(behaviors, annotations.FieldBehavior_REQUIRED) {
+		c.required = true
+	}
+	return c
+}
+
+// rulesConstraints 将字段的 (buf.validate.field) 转换为约束
+func rulesConstraints(opts *descriptorpb.FieldOptions) constraints {
+	var c constraints
+	rules, ok := proto.GetExtension(opts, validate.E_Field).(*validate.FieldRules)
+	if !ok || rules == nil {
+		return c
+	}
+	c.required = rules.GetRequired()
+
+	m := rules.ProtoReflect()
+	fd := m.WhichOneof(m.Descriptor().Oneofs().ByName("type"))
+	switch {
+	case fd == nil:
+	case rules.HasString():
+		s := rules.GetString()
+		if s.HasLen() {
+			c.minLength, c.maxLength = proto.Uint64(s.GetLen()), proto.Uint64(s.GetLen())
+		}
+		if s.HasMinLen() {
+			c.minLength = proto.Uint64(s.GetMinLen())
+		}
+		if s.HasMaxLen() {
+			c.maxLength = proto.Uint64(s.GetMaxLen())
+		}
+		c.pattern = s.GetPattern()
+		c.enum = s.GetIn()
+		c.format = stringFormat(s)
+	case rules.HasRepeated():
+		r := rules.GetRepeated()
+		if r.HasMinItems() {
+			c.minItems = proto.Uint64(r.GetMinItems())
+		}
+		if r.HasMaxItems() {
+			c.maxItems = proto.Uint64(r.GetMaxItems())
+		}
+		c.uniqueItems = r.GetUnique()
+	case fd.Message() != nil:
+		// 各种数值类型的规则都使用 gt/gte 和 lt/lte 表示范围
+		numericBounds(m.Get(fd).Message(), &c)
+	}
+	return c
+}
+
+func stringFormat(s *validate.StringRules) string {
+	switch {
+	case s.GetEmail():
+		return "email"
+	case s.GetHostname():
+		return "hostname"
+	case s.GetIpv4():
+		return "ipv4"
+	case s.GetIpv6():
+		return "ipv6"
+	case s.GetUri():
+		return "uri"
+	case s.GetUuid():
+		return "uuid"
+	}
+	return ""
+}
+
+// numericBounds 读取数值规则中的范围，时间和时长等非数值的范围不转换
+func numericBounds(m protoreflect.Message, c *constraints) {
+	bound := func(name protoreflect.Name) (*float64, bool) {
+		fd := m.Descriptor().Fields().ByName(name)
+		if fd == nil || !m.Has(fd) {
+			return nil, false
+		}
+		v := m.Get(fd)
+		switch fd.Kind() {
+		case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+			protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+			return proto.Float64(float64(v.Int())), true
+		case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+			return proto.Float64(float64(v.Uint())), true
+		case protoreflect.FloatKind, protoreflect.DoubleKind:
+			return proto.Float64(v.Float()), true
+		}
+		return nil, false
+	}
+
+	if v, ok := bound("gte"); ok {
+		c.minimum = v
+	} else if v, ok := bound("gt"); ok {
+		c.minimum, c.exclusiveMinimum = v, true
+	}
+	if v, ok := bound("lte"); ok {
+		c.maximum = v
+	} else if v, ok := bound("lt"); ok {
+		c.maximum, c.exclusiveMaximum = v, true
+	}
+}
+
+// override 使用显式声明的 (openapiv2_field) 覆盖约束，零值视为没有声明
+func (c *constraints) override(s *options.JSONSchema) {
+	if s.GetMinimum() != 0 {
+		c.minimum, c.exclusiveMinimum = proto.Float64(s.GetMinimum()), s.GetExclusiveMinimum()
+	}
+	if s.GetMaximum() != 0 {
+		c.maximum, c.exclusiveMaximum = proto.Float64(s.GetMaximum()), s.GetExclusiveMaximum()
+	}
+	if s.GetMinLength() != 0 {
+		c.minLength = proto.Uint64(s.GetMinLength())
+	}
+	if s.GetMaxLength() != 0 {
+		c.maxLength = proto.Uint64(s.GetMaxLength())
+	}
+	if s.GetPattern() != "" {
+		c.pattern = s.GetPattern()
+	}
+	if s.GetFormat() != "" {
+		c.format = s.GetFormat()
+	}
+	if len(s.GetEnum()) > 0 {
+		c.enum = s.GetEnum()
+	}
+	if s.GetMinItems() != 0 {
+		c.minItems = proto.Uint64(s.GetMinItems())
+	}
+	if s.GetMaxItems() != 0 {
+		c.maxItems = proto.Uint64(s.GetMaxItems())
+	}
+	if s.GetUniqueItems() {
+		c.uniqueItems = true
+	}
+}
+
+// injectFile 将文件中所有字段的校验规则写入 (openapiv2_field) 和 field_behavior，供 protoc-gen-openapiv2 生成 definitions
+func injectFile(f *descriptorpb.FileDescriptorProto) {
+	var walk func(msgs []*descriptorpb.DescriptorProto)
+	walk = func(msgs []*descriptorpb.DescriptorProto) {
+		for _, msg := range msgs {
+			for _, field := range msg.GetField() {
+				if field.GetOptions() != nil {
+					inject(field.GetOptions())
+				}
+			}
+			walk(msg.GetNestedType())
+		}
+	}
+	walk(f.GetMessageType())
+}
+
+// inject 只补充 (openapiv2_field) 中没有显式声明的约束
+func inject(opts *descriptorpb.FieldOptions) {
+	c := rulesConstraints(opts)
+	if c.empty() {
+		return
+	}
+
+	if c.required {
+		behaviors, _ := proto.GetExtension(opts, annotations.E_FieldBehavior).([]annotations.FieldBehavior)
+		if !slices.Contains(behaviors, annotations.FieldBehavior_REQUIRED) {
+			proto.SetExtension(opts, annotations.E_FieldBehavior, append(behaviors, annotations.FieldBehavior_REQUIRED))
+		}
+	}
+
+	// 只有 required 时不需要声明 (openapiv2_field)
+	if c.required = false; c.empty() {
+		return
+	}
+	schema, _ := proto.GetExtension(opts, options.E_Openapiv2Field).(*options.JSONSchema)
+	if schema == nil {
+		schema = &options.JSONSchema{}
+	}
+	// protoc-gen-openapiv2 不输出为 0 的 minimum 和 maximum，query 和 path 参数中的约束不受影响
+	if c.minimum != nil && schema.GetMinimum() == 0 {
+		schema.Minimum, schema.ExclusiveMinimum = *c.minimum, c.exclusiveMinimum
+	}
+	if c.maximum != nil && schema.GetMaximum() == 0 {
+		schema.Maximum, schema.ExclusiveMaximum = *c.maximum, c.exclusiveMaximum
+	}
+	if c.minLength != nil && schema.GetMinLength() == 0 {
+		schema.MinLength = *c.minLength
+	}
+	if c.maxLength != nil && schema.GetMaxLength() == 0 {
+		schema.MaxLength = *c.maxLength
+	}
+	if schema.GetPattern() == "" {
+		schema.Pattern = c.pattern
+	}
+	if schema.GetFormat() == "" {
+		schema.Format = c.format
+	}
+	if len(schema.GetEnum()) == 0 {
+		schema.Enum = c.enum
+	}
+	if c.minItems != nil && schema.GetMinItems() == 0 {
+		schema.MinItems = *c.minItems
+	}
+	if c.maxItems != nil && schema.GetMaxItems() == 0 {
+		schema.MaxItems = *c.maxItems
+	}
+	schema.UniqueItems = schema.GetUniqueItems() || c.uniqueItems
+	proto.SetExtension(opts, options.E_Openapiv2Field, schema)
+}
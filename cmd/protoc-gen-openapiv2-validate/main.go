@@ -0,0 +1,112 @@
+// Package main 实现 protoc-gen-openapiv2-validate 插件，根据 (buf.validate.field) 校验规则为 protoc-gen-openapiv2
+// 生成的 OpenAPI 文档补充约束，使文档与服务端的校验拦截器保持一致，proto 中不需要再重复声明 (openapiv2_field).
+//
+// 插件包装了 protoc-gen-openapiv2，通过 buf.gen.yaml 代替它调用，参数原样传递:
+//   - 调用前将校验规则转换为字段上的 (openapiv2_field) 和 field_behavior = REQUIRED，用于请求体等 definitions
+//   - protoc-gen-openapiv2 不为 query 和 path 参数输出长度、范围等约束，调用后按同样的规则补充到 JSON 文档的参数中
+//
+// 字段上显式声明的 (openapiv2_field) 优先于校验规则. 支持的规则:
+//
+//	string: min_len、max_len、len、pattern、in 以及 email、hostname、ipv4、ipv6、uri、uuid
+//	数值: gt、gte、lt、lte
+//	repeated: min_items、max_items、unique
+//	required
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+const version = "0.1.0"
+
+// openapiv2Plugin 实际生成 OpenAPI 文档的插件，需要在 PATH 中
+const openapiv2Plugin = "protoc-gen-openapiv2"
+
+func main() {
+	showVersion := flag.Bool("version", false, "print the version and exit")
+	flag.Parse()
+	if *showVersion {
+		fmt.Printf("protoc-gen-openapiv2-validate %v\n", version)
+		return
+	}
+
+	if err := run(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "protoc-gen-openapiv2-validate: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(in io.Reader, out io.Writer) error {
+	b, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	req := &pluginpb.CodeGeneratorRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		return fmt.Errorf("parse request: %w", err)
+	}
+
+	for _, f := range req.GetProtoFile() {
+		injectFile(f)
+	}
+	files, err := protodesc.NewFiles(&descriptorpb.FileDescriptorSet{File: req.GetProtoFile()})
+	if err != nil {
+		return err
+	}
+
+	resp, err := generate(req)
+	if err != nil {
+		return err
+	}
+	if resp.GetError() == "" {
+		ops := operations(files, req.GetFileToGenerate(), strings.Contains(req.GetParameter(), "simple_operation_ids=true"))
+		for _, f := range resp.GetFile() {
+			if !strings.HasSuffix(f.GetName(), ".json") {
+				continue
+			}
+			content, err := addParameterConstraints([]byte(f.GetContent()), ops)
+			if err != nil {
+				return fmt.Errorf("%s: %w", f.GetName(), err)
+			}
+			f.Content = proto.String(string(content))
+		}
+	}
+
+	b, err = proto.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(b)
+	return err
+}
+
+// generate 使用补充了约束的请求调用 protoc-gen-openapiv2
+func generate(req *pluginpb.CodeGeneratorRequest) (*pluginpb.CodeGeneratorResponse, error) {
+	b, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(openapiv2Plugin)
+	cmd.Stdin = bytes.NewReader(b)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("run %s: %w", openapiv2Plugin, err)
+	}
+	resp := &pluginpb.CodeGeneratorResponse{}
+	if err := proto.Unmarshal(out, resp); err != nil {
+		return nil, fmt.Errorf("parse %s response: %w", openapiv2Plugin, err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// operations 返回 OpenAPI 文档中 operationId 到 gRPC 方法的映射，规则与 protoc-gen-openapiv2 相同:
+// 默认为 Service_Method，额外的 HTTP 绑定依次加上 2、3 等后缀，(openapiv2_operation) 中的 operation_id 优先
+func operations(files *protoregistry.Files, generate []string, simple bool) map[string]protoreflect.MethodDescriptor {
+	ops := make(map[string]protoreflect.MethodDescriptor)
+	for _, path := range generate {
+		fd, err := files.FindFileByPath(path)
+		if err != nil {
+			continue
+		}
+		for i := 0; i < fd.Services().Len(); i++ {
+			sd := fd.Services().Get(i)
+			for j := 0; j < sd.Methods().Len(); j++ {
+				md := sd.Methods().Get(j)
+				opts, _ := md.Options().(*descriptorpb.MethodOptions)
+				if op, ok := proto.GetExtension(opts, options.E_Openapiv2Operation).(*options.Operation); ok && op.GetOperationId() != "" {
+					ops[op.GetOperationId()] = md
+					continue
+				}
+
+				id := string(sd.Name()) + "_" + string(md.Name())
+				if simple {
+					id = string(md.Name())
+				}
+				bindings := 1
+				if rule, ok := proto.GetExtension(opts, annotations.E_Http).(*annotations.HttpRule); ok && rule != nil {
+					bindings += len(rule.GetAdditionalBindings())
+				}
+				for b := 0; b < bindings; b++ {
+					if b == 0 {
+						ops[id] = md
+						continue
+					}
+					ops[id+strconv.Itoa(b+1)] = md
+				}
+			}
+		}
+	}
+	return ops
+}
+
+// addParameterConstraints 为文档中 query 和 path 参数补充约束，没有需要补充的约束时原样返回
+func addParameterConstraints(content []byte, ops map[string]protoreflect.MethodDescriptor) ([]byte, error) {
+	var doc object
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	raw, ok := doc.values["paths"]
+	if !ok {
+		return content, nil
+	}
+	var paths object
+	if err := json.Unmarshal(raw, &paths); err != nil {
+		return nil, err
+	}
+
+	changed := false
+	for _, path := range paths.keys {
+		var item object
+		if err := json.Unmarshal(paths.values[path], &item); err != nil {
+			return nil, err
+		}
+		for _, verb := range item.keys {
+			var op struct {
+				OperationID string   `json:"operationId"`
+				Parameters  []object `json:"parameters"`
+			}
+			// 路径级别的 parameters 等不是操作
+			if err := json.Unmarshal(item.values[verb], &op); err != nil {
+				continue
+			}
+			md, ok := ops[op.OperationID]
+			if !ok {
+				continue
+			}
+
+			opChanged := false
+			for i := range op.Parameters {
+				if applyParameter(&op.Parameters[i], md.Input()) {
+					opChanged = true
+				}
+			}
+			if !opChanged {
+				continue
+			}
+			var opObj object
+			if err := json.Unmarshal(item.values[verb], &opObj); err != nil {
+				return nil, err
+			}
+			if err := opObj.set("parameters", op.Parameters); err != nil {
+				return nil, err
+			}
+			if err := item.set(verb, opObj); err != nil {
+				return nil, err
+			}
+			changed = true
+		}
+		if err := paths.set(path, item); err != nil {
+			return nil, err
+		}
+	}
+	if !changed {
+		return content, nil
+	}
+	if err := doc.set("paths", paths); err != nil {
+		return nil, err
+	}
+
+	// 与 protoc-gen-openapiv2 的输出格式一致
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, b, "", "  "); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// applyParameter 为参数补充对应字段的约束，已有的属性保持不变
+func applyParameter(param *object, input protoreflect.MessageDescriptor) bool {
+	var in, name string
+	_ = json.Unmarshal(param.values["in"], &in)
+	_ = json.Unmarshal(param.values["name"], &name)
+	if in != "query" && in != "path" {
+		return false
+	}
+	fd := findField(input, name)
+	if fd == nil {
+		return false
+	}
+	opts, _ := fd.Options().(*descriptorpb.FieldOptions)
+	c := fieldConstraints(opts)
+
+	changed := false
+	add := func(key string, v any) {
+		if _, ok := param.values[key]; ok {
+			return
+		}
+		if err := param.set(key, v); err == nil {
+			changed = true
+		}
+	}
+	var required bool
+	_ = json.Unmarshal(param.values["required"], &required)
+	if c.required && !required {
+		_ = param.set("required", true)
+		changed = true
+	}
+	if c.format != "" {
+		add("format", c.format)
+	}
+	if c.pattern != "" {
+		add("pattern", c.pattern)
+	}
+	if c.minimum != nil {
+		add("minimum", *c.minimum)
+		if c.exclusiveMinimum {
+			add("exclusiveMinimum", true)
+		}
+	}
+	if c.maximum != nil {
+		add("maximum", *c.maximum)
+		if c.exclusiveMaximum {
+			add("exclusiveMaximum", true)
+		}
+	}
+	if c.minLength != nil {
+		add("minLength", *c.minLength)
+	}
+	if c.maxLength != nil {
+		add("maxLength", *c.maxLength)
+	}
+	if len(c.enum) > 0 {
+		add("enum", c.enum)
+	}
+	if c.minItems != nil {
+		add("minItems", *c.minItems)
+	}
+	if c.maxItems != nil {
+		add("maxItems", *c.maxItems)
+	}
+	if c.uniqueItems {
+		add("uniqueItems", true)
+	}
+	return changed
+}
+
+// findField 按参数名称（如 pageSize 或 user.id）查找请求中的字段，每一段可以是 JSON 名称或 proto 名称
+func findField(md protoreflect.MessageDescriptor, name string) protoreflect.FieldDescriptor {
+	var fd protoreflect.FieldDescriptor
+	for i, part := range strings.Split(name, ".") {
+		if i > 0 {
+			if md = fd.Message(); md == nil {
+				return nil
+			}
+		}
+		if fd = md.Fields().ByJSONName(part); fd == nil {
+			fd = md.Fields().ByName(protoreflect.Name(part))
+		}
+		if fd == nil {
+			return nil
+		}
+	}
+	return fd
+}
+
+// object 保持键顺序的 JSON 对象，修改后的文档与 protoc-gen-openapiv2 的输出只有补充的部分不同
+type object struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+func (o *object) set(key string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = b
+	return nil
+}
+
+func (o *object) UnmarshalJSON(b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if t, err := dec.Token(); err != nil {
+		return err
+	} else if t != json.Delim('{') {
+		return fmt.Errorf("expected a JSON object, got %v", t)
+	}
+	o.keys, o.values = nil, make(map[string]json.RawMessage)
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := t.(string)
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+		o.keys = append(o.keys, key)
+		o.values[key] = v
+	}
+	_, err := dec.Token()
+	return err
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(o.values[key])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
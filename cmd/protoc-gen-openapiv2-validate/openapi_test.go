@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2/options"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	v1 "github.com/yanking/app-skeleton/api/proto/gen/demo/v1"
+)
+
+// fieldOptions 返回 demo.v1 中字段的选项副本
+func fieldOptions(t *testing.T, msg proto.Message, field string) *descriptorpb.FieldOptions {
+	t.Helper()
+	fd := msg.ProtoReflect().Descriptor().Fields().ByName(protoreflect.Name(field))
+	if fd == nil {
+		t.Fatalf("field %s not found", field)
+	}
+	return proto.Clone(protodesc.ToFieldDescriptorProto(fd).GetOptions()).(*descriptorpb.FieldOptions)
+}
+
+func TestInject(t *testing.T) {
+	tests := []struct {
+		name         string
+		msg          proto.Message
+		field        string
+		want         *options.JSONSchema
+		wantRequired bool
+	}{
+		{
+			name:  "string length and pattern",
+			msg:   &v1.CreateUserRequest{},
+			field: "name",
+			want:  &options.JSONSchema{MinLength: 1, MaxLength: 64, Pattern: "^[A-Za-z0-9_-]+$"},
+			// (buf.validate.field).required 转换为 field_behavior = REQUIRED
+			wantRequired: true,
+		},
+		{
+			name:         "email format",
+			msg:          &v1.CreateUserRequest{},
+			field:        "email",
+			want:         &options.JSONSchema{Format: "email"},
+			wantRequired: true,
+		},
+		{
+			name:  "numeric range",
+			msg:   &v1.ListUsersRequest{},
+			field: "page_size",
+			want:  &options.JSONSchema{Maximum: 100},
+		},
+		{
+			name:  "no rules",
+			msg:   &v1.CreateUserResponse{},
+			field: "id",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := fieldOptions(t, tt.msg, tt.field)
+			inject(opts)
+
+			got, _ := proto.GetExtension(opts, options.E_Openapiv2Field).(*options.JSONSchema)
+			if tt.want == nil && got != nil || tt.want != nil && !proto.Equal(got, tt.want) {
+				t.Errorf("openapiv2_field = %v, want %v", got, tt.want)
+			}
+			behaviors, _ := proto.GetExtension(opts, annotations.E_FieldBehavior).([]annotations.FieldBehavior)
+			if required := slices.Contains(behaviors, annotations.FieldBehavior_REQUIRED); required != tt.wantRequired {
+				t.Errorf("required = %t, want %t", required, tt.wantRequired)
+			}
+		})
+	}
+}
+
+func TestAddParameterConstraints(t *testing.T) {
+	ops := operations(protoregistry.GlobalFiles, []string{"demo/v1/user.proto"}, false)
+	content := []byte(`{
+  "swagger": "2.0",
+  "paths": {
+    "/v1/users": {
+      "get": {
+        "operationId": "UserService_ListUsers",
+        "parameters": [
+          {"name": "pageSize", "in": "query", "required": false, "type": "integer", "format": "int32"},
+          {"name": "pageToken", "in": "query", "required": false, "type": "string"}
+        ]
+      }
+    },
+    "/v1/users/{id}": {
+      "get": {
+        "operationId": "UserService_GetUser",
+        "parameters": [
+          {"name": "id", "in": "path", "required": true, "type": "string"}
+        ]
+      }
+    }
+  }
+}
+`)
+	out, err := addParameterConstraints(content, ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Parameters []map[string]any `json:"parameters"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatal(err)
+	}
+	params := func(path string) []map[string]any {
+		return doc.Paths[path]["get"].Parameters
+	}
+	tests := []struct {
+		param map[string]any
+		key   string
+		want  any
+	}{
+		// 为 0 的 minimum 同样输出
+		{params("/v1/users")[0], "minimum", 0.0},
+		{params("/v1/users")[0], "maximum", 100.0},
+		{params("/v1/users")[1], "maxLength", 256.0},
+		{params("/v1/users/{id}")[0], "minLength", 1.0},
+		{params("/v1/users/{id}")[0], "maxLength", 64.0},
+	}
+	for _, tt := range tests {
+		if got := tt.param[tt.key]; got != tt.want {
+			t.Errorf("%s.%s = %v, want %v", tt.param["name"], tt.key, got, tt.want)
+		}
+	}
+
+	// 没有需要补充的约束时原样返回
+	unchanged := []byte(`{"paths": {"/v1/demo/echo": {"post": {"operationId": "DemoService_Echo"}}}}`)
+	if out, err := addParameterConstraints(unchanged, ops); err != nil || string(out) != string(unchanged) {
+		t.Fatalf("addParameterConstraints() = %s, %v, want the input unchanged", out, err)
+	}
+}
+
+func TestObjectKeepsKeyOrder(t *testing.T) {
+	var o object
+	if err := json.Unmarshal([]byte(`{"name":"id","in":"path","type":"string"}`), &o); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.set("in", "query"); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.set("maxLength", 64); err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"name":"id","in":"query","type":"string","maxLength":64}`; string(b) != want {
+		t.Fatalf("json = %s, want %s", b, want)
+	}
+}
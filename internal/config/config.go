@@ -1,59 +1,227 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/yanking/app-skeleton/pkg/auth"
 	"github.com/yanking/app-skeleton/pkg/conf"
+	pkggrpc "github.com/yanking/app-skeleton/pkg/grpc"
+	"github.com/yanking/app-skeleton/pkg/grpc/httpmiddlewares"
 	"github.com/yanking/app-skeleton/pkg/log"
+	"github.com/yanking/app-skeleton/pkg/ratelimit"
+	"github.com/yanking/app-skeleton/pkg/tlsconfig"
 )
 
-var config *Config
+// config 当前生效的配置，配置重载时整体替换，admin 的 /config 等处会并发读取
+var config atomic.Pointer[Config]
 
 func Init(configFile string, fs ...func()) error {
-	config = &Config{}
-	return conf.Parse(configFile, config, fs...)
+	c := &Config{}
+	if err := conf.Parse(configFile, c, fs...); err != nil {
+		return err
+	}
+	config.Store(c)
+	return c.Validate()
+}
+
+// Load 重新读取并校验配置文件，返回新的配置，不影响当前生效的配置
+func Load(configFile string) (*Config, error) {
+	c := &Config{}
+	if err := conf.Parse(configFile, c); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
 }
 
 func Get() *Config {
-	if config == nil {
+	c := config.Load()
+	if c == nil {
 		panic("configs is nil, please call configs.Init() first")
 	}
-	return config
+	return c
 }
 
+// Set 替换当前生效的配置，可以与 Get 并发调用
 func Set(conf *Config) {
-	config = conf
+	config.Store(conf)
 }
 
 type Config struct {
-	AppName       string       `mapstructure:"app-name" yaml:"app-name" json:"app-name"`
-	EnableMetrics bool         `mapstructure:"enable-metrics" yaml:"enable-metrics" json:"enable-metrics"`
-	ServerMode    string       `mapstructure:"server-mode" yaml:"server-mode" json:"server-mode"`
-	JwtKey        string       `mapstructure:"jwt-key" yaml:"jwt-key" json:"jwt-key"`
-	Expiration    string       `mapstructure:"expiration" yaml:"expiration" json:"expiration"`
-	HTTP          HTTPConfig   `mapstructure:"http" yaml:"http" json:"http"`
-	Grpc          GrpcConfig   `mapstructure:"grpc" yaml:"grpc" json:"grpc"`
-	Log           *log.Options `mapstructure:"log" yaml:"log" json:"log"`
-	Mysql         MysqlConfig  `mapstructure:"mysql" yaml:"mysql" json:"mysql"`
-	Redis         RedisConfig  `mapstructure:"redis" yaml:"redis" json:"redis"`
-	Jaeger        JaegerConfig `mapstructure:"jaeger" yaml:"jaeger" json:"jaeger"`
+	AppName       string             `mapstructure:"app-name" yaml:"app-name" json:"app-name"`
+	EnableMetrics bool               `mapstructure:"enable-metrics" yaml:"enable-metrics" json:"enable-metrics"`
+	ServerMode    string             `mapstructure:"server-mode" yaml:"server-mode" json:"server-mode"`
+	JwtKey        string             `mapstructure:"jwt-key" yaml:"jwt-key" json:"jwt-key"`
+	Expiration    string             `mapstructure:"expiration" yaml:"expiration" json:"expiration"`
+	Auth          AuthConfig         `mapstructure:"auth" yaml:"auth" json:"auth"`
+	RateLimit     *ratelimit.Options `mapstructure:"ratelimit" yaml:"ratelimit" json:"ratelimit"`
+	Lifecycle     LifecycleConfig    `mapstructure:"lifecycle" yaml:"lifecycle" json:"lifecycle"`
+	Admin         AdminConfig        `mapstructure:"admin" yaml:"admin" json:"admin"`
+	Registry      RegistryConfig     `mapstructure:"registry" yaml:"registry" json:"registry"`
+	HTTP          HTTPConfig         `mapstructure:"http" yaml:"http" json:"http"`
+	Grpc          GrpcConfig         `mapstructure:"grpc" yaml:"grpc" json:"grpc"`
+	Log           *log.Options       `mapstructure:"log" yaml:"log" json:"log"`
+	Mysql         MysqlConfig        `mapstructure:"mysql" yaml:"mysql" json:"mysql"`
+	Redis         RedisConfig        `mapstructure:"redis" yaml:"redis" json:"redis"`
+	Jaeger        JaegerConfig       `mapstructure:"jaeger" yaml:"jaeger" json:"jaeger"`
+}
+
+// Validate 校验配置是否合法
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Log != nil {
+		errs = append(errs, c.Log.Validate()...)
+	}
+	if c.Expiration != "" {
+		if d, err := time.ParseDuration(c.Expiration); err != nil || d <= 0 {
+			errs = append(errs, fmt.Errorf("invalid expiration %q, must be a positive duration", c.Expiration))
+		}
+	}
+	if c.Auth.Enabled && c.JwtKey == "" && c.Auth.JWKSFile == "" {
+		errs = append(errs, fmt.Errorf("auth requires jwt-key or auth.jwks-file"))
+	}
+	errs = append(errs, c.RateLimit.Validate()...)
+	if c.RateLimit != nil && c.RateLimit.Enabled && c.RateLimit.Store == ratelimit.StoreRedis && c.Redis.Addr == "" && len(c.Redis.Addrs) == 0 {
+		errs = append(errs, fmt.Errorf("ratelimit store redis requires redis.addr or redis.addrs"))
+	}
+	errs = append(errs, c.Grpc.TLS.Validate(true)...)
+	errs = append(errs, c.Grpc.Shedding.Validate()...)
+	errs = append(errs, c.HTTP.TLS.Validate(true)...)
+	if c.Grpc.Gateway.SinglePort && c.HTTP.TLS.Enabled {
+		errs = append(errs, fmt.Errorf("http.tls must not be enabled when grpc.gateway.single-port is enabled, use grpc.tls instead"))
+	}
+	if _, err := pkggrpc.ParseTrustedProxies(c.Grpc.Gateway.TrustedProxies); err != nil {
+		errs = append(errs, err)
+	}
+	if c.HTTP.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("http.timeout must not be negative"))
+	}
+	if c.HTTP.CORS.Enabled && len(c.HTTP.CORS.AllowedOrigins) == 0 {
+		errs = append(errs, fmt.Errorf("http.cors.allowed-origins is required when cors is enabled"))
+	}
+	if c.Grpc.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("grpc.timeout must not be negative"))
+	}
+	if c.Registry.Enabled && c.Registry.File == "" {
+		errs = append(errs, fmt.Errorf("registry.file is required when registry is enabled"))
+	}
+	if c.Lifecycle.ShutdownTimeout < 0 || c.Lifecycle.StopTimeout < 0 || c.Lifecycle.PreStopDelay < 0 {
+		errs = append(errs, fmt.Errorf("lifecycle timeouts must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// NewIssuer 使用 jwt-key、expiration 和 auth.issuer 创建 HS256 token 签发器
+func (c *Config) NewIssuer(opts ...auth.IssuerOption) (*auth.Issuer, error) {
+	if c.JwtKey == "" || c.Expiration == "" {
+		return nil, fmt.Errorf("issuing tokens requires jwt-key and expiration")
+	}
+	expiration, err := time.ParseDuration(c.Expiration)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expiration %q: %w", c.Expiration, err)
+	}
+	if c.Auth.Issuer != "" {
+		opts = append([]auth.IssuerOption{auth.WithIssuerName(c.Auth.Issuer)}, opts...)
+	}
+	return auth.NewIssuer(c.JwtKey, expiration, opts...)
+}
+
+// AuthConfig 对应认证相关配置，HS256 的密钥和 token 有效期分别使用 jwt-key 和 expiration
+type AuthConfig struct {
+	// Enabled 是否对 gRPC 请求进行 JWT 认证
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// JWKSFile 校验 RS256/ES256 token 的公钥文件（JWKS 格式）
+	JWKSFile string `mapstructure:"jwks-file" yaml:"jwks-file" json:"jwks-file"`
+	// Issuer 签发和校验 token 时使用的 iss，为空时不校验
+	Issuer string `mapstructure:"issuer" yaml:"issuer" json:"issuer"`
+	// PublicMethods 不需要认证的方法，如 /demo.v1.DemoService/Healthz，以 /* 结尾表示整个服务.
+	// 访问策略以 proto 中的 (auth.v1.policy) 为准，列出的方法必须声明为 public，否则启动失败
+	PublicMethods []string `mapstructure:"public-methods" yaml:"public-methods" json:"public-methods"`
+}
+
+// RegistryConfig 对应服务注册相关配置
+type RegistryConfig struct {
+	// Enabled 是否在启动时将 gRPC 服务注册到注册中心
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// File 基于文件的注册中心路径，同一台机器上的进程共享
+	File string `mapstructure:"file" yaml:"file" json:"file"`
+	// Weight 实例的权重，供加权负载均衡使用
+	Weight int `mapstructure:"weight" yaml:"weight" json:"weight"`
+}
+
+// LifecycleConfig 对应应用生命周期相关配置
+type LifecycleConfig struct {
+	// StartTimeout 所有组件就绪的期限，为 0 时不限制
+	StartTimeout time.Duration `mapstructure:"start-timeout" yaml:"start-timeout" json:"start-timeout"`
+	// ShutdownTimeout 整个停止流程的总预算，应小于 Kubernetes 的 terminationGracePeriodSeconds
+	ShutdownTimeout time.Duration `mapstructure:"shutdown-timeout" yaml:"shutdown-timeout" json:"shutdown-timeout"`
+	// StopTimeout 单个组件 Stop 的默认预算，为 0 时只受总预算约束
+	StopTimeout time.Duration `mapstructure:"stop-timeout" yaml:"stop-timeout" json:"stop-timeout"`
+	// PreStopDelay 标记 NOT_SERVING 之后等待多久再停止组件
+	PreStopDelay time.Duration `mapstructure:"pre-stop-delay" yaml:"pre-stop-delay" json:"pre-stop-delay"`
+	// ParallelStop 同一依赖层内的组件是否并发停止
+	ParallelStop bool `mapstructure:"parallel-stop" yaml:"parallel-stop" json:"parallel-stop"`
+}
+
+// AdminConfig 对应 admin 服务相关配置（metrics、pprof、运行时信息）
+type AdminConfig struct {
+	// Enabled 控制是否启动 admin 服务
+	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// Addr admin 服务监听地址，不应暴露到公网
+	Addr string `mapstructure:"addr" yaml:"addr" json:"addr"`
 }
 
 // HTTPConfig 对应 HTTP 相关配置 (主要用于 gRPC-Gateway)
 type HTTPConfig struct {
-	Addr    string `mapstructure:"addr" yaml:"addr" json:"addr"`
-	Timeout string `mapstructure:"timeout" yaml:"timeout" json:"timeout"`
+	Addr string `mapstructure:"addr" yaml:"addr" json:"addr"`
+	// Timeout 读取请求和写入响应的超时时间，空闲连接在两倍的超时时间后关闭，为 0 时不限制
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout" json:"timeout"`
+	// AccessLog 是否记录访问日志
+	AccessLog bool `mapstructure:"access-log" yaml:"access-log" json:"access-log"`
+	// Gzip 是否压缩响应
+	Gzip bool `mapstructure:"gzip" yaml:"gzip" json:"gzip"`
+	// CORS 跨域资源共享配置
+	CORS httpmiddlewares.CORSOptions `mapstructure:"cors" yaml:"cors" json:"cors"`
+	// TLS HTTP 服务的 TLS 配置
+	TLS tlsconfig.Options `mapstructure:"tls" yaml:"tls" json:"tls"`
 }
 
 // GrpcConfig 对应 gRPC 相关配置
 type GrpcConfig struct {
 	Addr string `mapstructure:"addr" yaml:"addr" json:"addr"`
+	// Advertise 对外公布的地址，用于注册中心和日志
+	Advertise AdvertiseConfig `mapstructure:"advertise" yaml:"advertise" json:"advertise"`
+	// Timeout 单个 unary 请求的超时时间，支持通过 SIGHUP 重新加载
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout" json:"timeout"`
+	// TLS gRPC 服务的 TLS 配置
+	TLS tlsconfig.Options `mapstructure:"tls" yaml:"tls" json:"tls"`
+	// Shedding 自适应限流（过载保护）配置
+	Shedding pkggrpc.SheddingOptions `mapstructure:"shedding" yaml:"shedding" json:"shedding"`
 	// Gateway 用于配置 gRPC-Gateway 相关选项
 	Gateway GatewayConfig `mapstructure:"gateway" yaml:"gateway" json:"gateway"`
 }
 
+// AdvertiseConfig 对应对外公布地址的配置，两者都为空时从第一个已启用的非回环网卡选择 IP
+type AdvertiseConfig struct {
+	// Host 对外公布的主机（IP 或域名），优先级最高
+	Host string `mapstructure:"host" yaml:"host" json:"host"`
+	// Interface 从指定的网卡选择 IP，如 eth0
+	Interface string `mapstructure:"interface" yaml:"interface" json:"interface"`
+}
+
 // GatewayConfig 对应 gRPC-Gateway 相关配置
 type GatewayConfig struct {
 	// Enabled 控制是否启用 gRPC-Gateway
 	Enabled bool `mapstructure:"enabled" yaml:"enabled" json:"enabled"`
+	// SinglePort 与 gRPC 共用 grpc.addr 和 grpc.tls，此时忽略 http.addr 和 http.tls
+	SinglePort bool `mapstructure:"single-port" yaml:"single-port" json:"single-port"`
+	// TrustedProxies 可信代理（如负载均衡器）的 IP 或 CIDR，只采用这些代理转发的 X-Forwarded-For 作为客户端地址
+	TrustedProxies []string `mapstructure:"trusted-proxies" yaml:"trusted-proxies" json:"trusted-proxies"`
 }
 
 // MysqlConfig 对应 MySQL 数据库相关配置
@@ -83,4 +251,4 @@ type RedisConfig struct {
 type JaegerConfig struct {
 	AgentHost string `mapstructure:"agentHost" yaml:"agentHost" json:"agentHost"`
 	AgentPort int    `mapstructure:"agentPort" yaml:"agentPort" json:"agentPort"`
-}
\ No newline at end of file
+}
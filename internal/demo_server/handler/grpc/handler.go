@@ -1,11 +1,17 @@
 package grpc
 
-import "github.com/yanking/app-skeleton/api/proto/gen/demo/v1"
+import (
+	"github.com/yanking/app-skeleton/api/proto/gen/demo/v1"
+	"github.com/yanking/app-skeleton/pkg/health"
+)
 
 type Handler struct {
 	v1.UnimplementedDemoServiceServer
+
+	// checks 为 nil 时 Healthz 总是返回 HEALTHY
+	checks *health.Registry
 }
 
-func NewHandler() *Handler {
-	return &Handler{}
+func NewHandler(checks *health.Registry) *Handler {
+	return &Handler{checks: checks}
 }
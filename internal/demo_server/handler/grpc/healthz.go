@@ -2,16 +2,34 @@ package grpc
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang/protobuf/ptypes/empty"
 	v1 "github.com/yanking/app-skeleton/api/proto/gen/demo/v1"
+	"github.com/yanking/app-skeleton/pkg/health"
 )
 
+// Healthz 返回 readiness 检查的结果，与 /readyz 和 gRPC health 服务保持一致
 func (h *Handler) Healthz(ctx context.Context, req *empty.Empty) (*v1.HealthzResponse, error) {
-	return &v1.HealthzResponse{
+	resp := &v1.HealthzResponse{
 		Status:    v1.ServiceStatus_HEALTHY,
 		Timestamp: time.Now().Format(time.RFC3339),
 		Message:   "",
-	}, nil
+	}
+	if h.checks == nil {
+		return resp, nil
+	}
+
+	report := h.checks.Check(ctx, health.Readiness)
+	if !report.Healthy() {
+		resp.Status = v1.ServiceStatus_UNHEALTHY
+	}
+	var msgs []string
+	for _, c := range report.Failed() {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", c.Name, c.Error))
+	}
+	resp.Message = strings.Join(msgs, "; ")
+	return resp, nil
 }
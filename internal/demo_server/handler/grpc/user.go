@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"strconv"
 
 	v1 "github.com/yanking/app-skeleton/api/proto/gen/demo/v1"
 )
@@ -15,7 +16,10 @@ func NewUserHandler() *UserHandler {
 }
 
 func (h *UserHandler) GetUser(ctx context.Context, req *v1.GetUserRequest) (*v1.GetUserResponse, error) {
-	// 模拟获取用户信息
+	// 模拟获取用户信息，只有正整数 ID 的用户存在
+	if id, err := strconv.Atoi(req.Id); err != nil || id <= 0 {
+		return nil, v1.ErrorUserNotFound("user not found").WithMetadata(map[string]string{"id": req.Id})
+	}
 	return &v1.GetUserResponse{
 		Id:    req.Id,
 		Name:  "User " + req.Id,
@@ -45,6 +49,9 @@ func (h *UserHandler) ListUsers(ctx context.Context, req *v1.ListUsersRequest) (
 
 func (h *UserHandler) CreateUser(ctx context.Context, req *v1.CreateUserRequest) (*v1.CreateUserResponse, error) {
 	// 模拟创建用户
+	if req.Name == "" {
+		return nil, v1.ErrorInvalidUser("invalid user").WithFieldViolation("name", "must not be empty")
+	}
 	return &v1.CreateUserResponse{
 		Id:    "3",
 		Name:  req.Name,
@@ -0,0 +1,172 @@
+package admin
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/yanking/app-skeleton/pkg/log"
+	"github.com/yanking/app-skeleton/pkg/version"
+)
+
+const redacted = "******"
+
+func defaultRedactKeys() []string {
+	return []string{"password", "secret", "token", "jwt-key", "private-key", "api-key", "credential"}
+}
+
+func (s *Server) registerRoutes() {
+	s.mux.HandleFunc("/", s.index)
+	s.mux.Handle("/metrics", promhttp.Handler())
+
+	s.mux.HandleFunc("/debug/pprof/", pprof.Index)
+	s.mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	s.mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	s.mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	s.mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	s.mux.Handle("/debug/vars", expvar.Handler())
+
+	s.mux.HandleFunc("/version", s.version)
+	s.mux.HandleFunc("/config", s.config)
+	s.mux.HandleFunc("/loglevel", s.logLevel)
+	s.mux.HandleFunc("/components", s.components)
+
+	for _, r := range s.handlers {
+		s.mux.Handle(r.pattern, r.handler)
+	}
+}
+
+// index 列出所有可用的接口
+func (s *Server) index(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	paths := []string{"/metrics", "/debug/pprof/", "/debug/vars", "/version", "/config", "/loglevel", "/components"}
+	for _, h := range s.handlers {
+		paths = append(paths, h.pattern)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, p := range paths {
+		fmt.Fprintln(w, p)
+	}
+}
+
+func (s *Server) version(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, version.Get())
+}
+
+// config 输出当前生效的配置，敏感字段会被脱敏
+func (s *Server) config(w http.ResponseWriter, r *http.Request) {
+	if s.configFn == nil {
+		http.Error(w, "config is not available", http.StatusNotFound)
+		return
+	}
+
+	// 先序列化为 JSON 再反序列化为通用结构，保证字段名与配置文件一致
+	raw, err := json.Marshal(s.configFn())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.redact(v))
+}
+
+// redact 递归地把敏感字段替换为固定字符串
+func (s *Server) redact(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			if s.sensitive(k) {
+				if item != nil && item != "" {
+					val[k] = redacted
+				}
+				continue
+			}
+			val[k] = s.redact(item)
+		}
+		return val
+	case []any:
+		for i, item := range val {
+			val[i] = s.redact(item)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+func (s *Server) sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range s.redactKeys {
+		if strings.Contains(key, strings.ToLower(k)) {
+			return true
+		}
+	}
+	return false
+}
+
+type logLevel struct {
+	Level string `json:"level"`
+}
+
+// logLevel GET 返回当前日志级别，PUT 修改日志级别，请求体为 {"level": "debug"}
+func (s *Server) logLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, logLevel{Level: log.Level()})
+	case http.MethodPut:
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1024))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var req logLevel
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		if err := log.ValidateLevel(req.Level); err != nil || req.Level == "" {
+			http.Error(w, fmt.Sprintf("invalid log level %q", req.Level), http.StatusBadRequest)
+			return
+		}
+
+		previous := log.Level()
+		log.SetLevel(req.Level)
+		log.Infof("[admin] log level changed from %s to %s", previous, log.Level())
+		writeJSON(w, http.StatusOK, logLevel{Level: log.Level()})
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) components(w http.ResponseWriter, r *http.Request) {
+	if s.statusFn == nil {
+		http.Error(w, "component status is not available", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.statusFn())
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
@@ -0,0 +1,142 @@
+// Package admin 提供运维管理用的 HTTP 服务，包含 metrics、pprof、expvar、版本信息、
+// 当前配置、日志级别和组件状态等接口，作为 app.IComponent 随应用一起启动和停止.
+package admin
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/yanking/app-skeleton/pkg/log"
+)
+
+type Option func(s *Server)
+
+type Server struct {
+	address string
+	lis     net.Listener
+	mux     *http.ServeMux
+	server  *http.Server
+
+	// configFn 返回当前生效的配置，输出前会脱敏
+	configFn func() any
+	// statusFn 返回组件状态
+	statusFn func() any
+	// redactKeys 配置中需要脱敏的字段，按字段名包含关系匹配（不区分大小写）
+	redactKeys []string
+	// handlers 用户额外注册的路由
+	handlers []route
+
+	ready     chan struct{}
+	readyOnce sync.Once
+}
+
+type route struct {
+	pattern string
+	handler http.Handler
+}
+
+// WithAddress 设置监听地址，默认 :9091
+func WithAddress(address string) Option {
+	return func(s *Server) {
+		s.address = address
+	}
+}
+
+// WithListener 使用已经创建好的监听器
+func WithListener(lis net.Listener) Option {
+	return func(s *Server) {
+		s.lis = lis
+	}
+}
+
+// WithConfig 设置获取当前生效配置的方法，用于 /config 接口
+func WithConfig(fn func() any) Option {
+	return func(s *Server) {
+		s.configFn = fn
+	}
+}
+
+// WithStatus 设置获取组件状态的方法，用于 /components 接口
+func WithStatus(fn func() any) Option {
+	return func(s *Server) {
+		s.statusFn = fn
+	}
+}
+
+// WithRedactKeys 追加配置中需要脱敏的字段名
+func WithRedactKeys(keys ...string) Option {
+	return func(s *Server) {
+		s.redactKeys = append(s.redactKeys, keys...)
+	}
+}
+
+// WithHandler 注册额外的路由
+func WithHandler(pattern string, handler http.Handler) Option {
+	return func(s *Server) {
+		s.handlers = append(s.handlers, route{pattern: pattern, handler: handler})
+	}
+}
+
+// NewServer 创建 admin 服务
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		address:    ":9091",
+		mux:        http.NewServeMux(),
+		redactKeys: defaultRedactKeys(),
+		ready:      make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.registerRoutes()
+	s.server = &http.Server{
+		Addr:    s.address,
+		Handler: s.mux,
+	}
+	return s
+}
+
+func (s *Server) Name() string {
+	return "adminServer"
+}
+
+// Ready 返回一个在服务开始监听后关闭的 channel，实现 app.IReadiness
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Handle 在服务启动前注册额外的路由
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Start 启动 admin 服务，阻塞直到服务关闭
+func (s *Server) Start(ctx context.Context) error {
+	if s.lis == nil {
+		lis, err := net.Listen("tcp", s.address)
+		if err != nil {
+			return err
+		}
+		s.lis = lis
+	}
+
+	log.Infof("[admin] server listening on: %s", s.lis.Addr().String())
+	s.readyOnce.Do(func() { close(s.ready) })
+
+	if err := s.server.Serve(s.lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Stop 优雅关闭 admin 服务
+func (s *Server) Stop(ctx context.Context) error {
+	err := s.server.Shutdown(ctx)
+	log.Infof("[admin] server stopped")
+	return err
+}
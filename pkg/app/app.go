@@ -5,6 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"os/signal"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -24,15 +27,96 @@ type IComponent interface {
 type App struct {
 	name       string
 	components []IComponent
+
+	// deps 通过 WithDependencies 声明的额外依赖
+	deps map[string][]string
+	// layers 按依赖关系拓扑排序后的组件分层，启动时从前往后，停止时从后往前
+	layers [][]IComponent
+
+	// startTimeout 所有组件就绪的期限，为 0 时不限制
+	startTimeout time.Duration
+	status       *statusBoard
+	ready        chan struct{}
+	stopping     atomic.Bool
+	// stopCh 在进入停止流程时关闭
+	stopCh chan struct{}
+
+	// 停止策略，见 WithShutdownTimeout 等选项
+	shutdownTimeout       time.Duration
+	stopTimeout           time.Duration
+	componentStopTimeouts map[string]time.Duration
+	preStopDelay          time.Duration
+	parallelStop          bool
+
+	reportMu sync.Mutex
+	report   *ShutdownReport
+
+	// hooks App 级生命周期钩子，componentHooks 为按组件名称注册的钩子
+	hooks          hookSet
+	componentHooks map[string]hookSet
+
+	// restartPolicies 按组件名称配置的重启策略，未配置的组件不会被重启
+	restartPolicies map[string]RestartPolicy
+
+	// 配置重载，见 WithConfig
+	reloadMu      sync.Mutex
+	config        any
+	configLoader  ConfigLoader
+	reloaders     []reloader
+	reloadTimeout time.Duration
 }
 
 // New create an app
-func New(name string, components []IComponent) (*App, error) {
-	log.Infof("app: all components initialized and dependencies injected.")
-	return &App{
+func New(name string, components []IComponent, opts ...Option) (*App, error) {
+	a := &App{
 		name:       name,
 		components: components,
-	}, nil
+		deps:       make(map[string][]string),
+
+		shutdownTimeout:       defaultShutdownTimeout,
+		componentStopTimeouts: make(map[string]time.Duration),
+
+		hooks:          make(hookSet),
+		componentHooks: make(map[string]hookSet),
+
+		restartPolicies: make(map[string]RestartPolicy),
+
+		reloadTimeout: defaultReloadTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	layers, err := buildLayers(components, a.deps)
+	if err != nil {
+		return nil, err
+	}
+	for name := range a.componentHooks {
+		if !hasComponent(components, name) {
+			return nil, fmt.Errorf("app: hooks registered for unknown component %q", name)
+		}
+	}
+	for name := range a.restartPolicies {
+		if !hasComponent(components, name) {
+			return nil, fmt.Errorf("app: restart policy configured for unknown component %q", name)
+		}
+	}
+	for name := range a.componentStopTimeouts {
+		if !hasComponent(components, name) {
+			return nil, fmt.Errorf("app: stop timeout configured for unknown component %q", name)
+		}
+	}
+	a.layers = layers
+	a.status = newStatusBoard(layers)
+	a.ready = make(chan struct{})
+	a.stopCh = make(chan struct{})
+
+	for i, layer := range a.layers {
+		log.Infof("app: dependency layer %d: [%s]", i, componentNames(layer))
+	}
+	log.Infof("app: all components initialized and dependencies injected.")
+	return a, nil
 }
 
 // Run starts the application and manages the lifecycle of all components
@@ -42,33 +126,66 @@ func (a *App) Run() error {
 	appCtx, appStop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer appStop()
 
-	// 使用 errgroup 并发启动所有组件
-	g, ctx := errgroup.WithContext(appCtx)
+	// 使用 errgroup 管理所有组件的 Start，任一组件启动失败都会取消 ctx.
+	// 组件 Start 使用的 ctx 不随退出信号取消，组件由停止流程中的 Stop 负责关闭，
+	// 这样 pre-stop delay 期间组件仍能正常处理请求.
+	runCtx, cancelRun := context.WithCancel(context.Background())
+	defer cancelRun()
+	g, ctx := errgroup.WithContext(runCtx)
 
-	for _, component := range a.components {
-		// 在闭包中使用局部变量捕获 component
-		c := component
-		g.Go(func() error {
-			log.Infof("app: starting %s", c.Name())
-			if err := c.Start(ctx); err != nil {
-				log.Errorf("app: failed to start %s: %v", c.Name(), err)
-				return err
-			}
-			return nil
-		})
-	}
-	log.Infof("app: waiting for initial component start goroutines to complete...")
-	// 等待所有组件启动完成或任一组件启动失败
-	if err := g.Wait(); err != nil {
-		log.Errorf("app: failed to start application: %v", err)
-		return err
+	// shutdownCtx 在收到退出信号或任一组件失败时结束，是进入停止流程的触发条件
+	shutdownCtx, cancelShutdown := context.WithCancelCause(appCtx)
+	defer cancelShutdown(nil)
+	stopPropagation := context.AfterFunc(ctx, func() { cancelShutdown(context.Cause(ctx)) })
+	defer stopPropagation()
+
+	// SIGHUP 触发配置重载
+	a.watchReload(shutdownCtx)
+
+	// 启动期限只约束等待就绪的过程，不影响组件 Start 使用的 ctx
+	var deadline context.Context
+	var cancelDeadline context.CancelFunc
+	if a.startTimeout > 0 {
+		deadline, cancelDeadline = context.WithTimeout(context.Background(), a.startTimeout)
+	} else {
+		deadline, cancelDeadline = context.WithCancel(context.Background())
 	}
+	defer cancelDeadline()
 
-	log.Infof("app: all components started successfully")
+	// 按依赖层依次启动，同层组件并发启动，上一层全部就绪后才启动下一层
+	startTime := time.Now()
+	var started [][]IComponent
+	startErr := runHooks(shutdownCtx, BeforeStart, "", a.hooks[BeforeStart])
+	for i, layer := range a.layers {
+		if startErr != nil {
+			break
+		}
+		log.Infof("app: starting dependency layer %d: [%s]", i, componentNames(layer))
+		started = append(started, layer)
+		startErr = a.startLayer(ctx, shutdownCtx, deadline, g, layer)
+	}
+	cancelDeadline()
+	if startErr == nil {
+		startErr = runHooks(shutdownCtx, AfterStart, "", a.hooks[AfterStart])
+	}
 
-	<-appCtx.Done()
+	switch {
+	case startErr == nil:
+		close(a.ready)
+		log.Infof("app: all components started successfully in %s, application %s is ready", time.Since(startTime), a.name)
+		<-shutdownCtx.Done()
+	case appCtx.Err() != nil:
+		// 启动过程中收到了退出信号
+		log.Infof("app: startup interrupted by shutdown signal")
+	default:
+		log.Errorf("app: failed to start application: %v", startErr)
+	}
 
-	errCause := context.Cause(appCtx)
+	errCause := context.Cause(shutdownCtx)
+	if errCause == nil {
+		// 启动超时时 ctx 并未被取消
+		errCause = startErr
+	}
 	if errCause != nil && !errors.Is(errCause, context.Canceled) && !errors.Is(errCause, context.DeadlineExceeded) { // context.Canceled from signal is normal
 		log.Infof("app: shutdown initiated due to: %v", errCause)
 	} else {
@@ -77,26 +194,49 @@ func (a *App) Run() error {
 
 	// --- Graceful Shutdown Procedure ---
 	log.Infof("app: initiating graceful stop of application %s...", a.name)
+	a.stopping.Store(true)
+	close(a.stopCh)
 
 	// Create a new context for the shutdown procedure itself, with a timeout.
-	// This timeout is for the *entire* shutdown sequence of all components.
-	shutdownOverallTimeout := 20 * time.Second // Configurable
-	stopCtx, cancelStopCtx := context.WithTimeout(context.Background(), shutdownOverallTimeout)
+	// This timeout is for the *entire* shutdown sequence of all components,
+	// including the pre-stop delay.
+	report := &ShutdownReport{StartedAt: time.Now()}
+	if errCause != nil {
+		report.Cause = errCause.Error()
+	}
+	stopCtx, cancelStopCtx := context.WithTimeout(context.Background(), a.shutdownTimeout)
 	defer cancelStopCtx()
 
-	// Stop components in reverse order of registration (LIFO).
-	// This assumes a simple dependency order; more complex apps might need a dependency graph.
-	for i := len(a.components) - 1; i >= 0; i-- {
-		comp := a.components[i]
-		log.Infof("app: attempting to stop component: %s", comp.Name())
-
-		// We pass stopCtx to each component's Stop method.
-		// The component's Stop method should respect this context's deadline.
-		if err := comp.Stop(stopCtx); err != nil {
-			log.Errorf("app: error stopping component %s: %v", comp.Name(), err)
-		} else {
-			log.Infof("app: component %s stopped successfully.", comp.Name())
-		}
+	_ = runHooks(stopCtx, BeforeStop, "", a.hooks[BeforeStop])
+	a.preStop(stopCtx, started)
+
+	// Stop components in reverse topological order: dependents are stopped
+	// before the components they depend on.
+	report.Components = a.stopLayers(stopCtx, started)
+	cancelRun()
+
+	// 等待所有 Start 返回，避免遗留 goroutine；超过停止预算后不再等待
+	waitCh := make(chan error, 1)
+	go func() {
+		waitCh <- g.Wait()
+	}()
+	select {
+	case <-waitCh:
+	case <-stopCtx.Done():
+		report.Abandoned = true
+		log.Warnf("app: timed out waiting for components to exit")
+	}
+	_ = runHooks(stopCtx, AfterStop, "", a.hooks[AfterStop])
+	report.Duration = time.Since(report.StartedAt)
+
+	a.reportMu.Lock()
+	a.report = report
+	a.reportMu.Unlock()
+
+	if len(report.Failed()) > 0 || report.Abandoned {
+		log.Warnf("app: %s", report)
+	} else {
+		log.Infof("app: %s", report)
 	}
 
 	log.Infof("app: application %s stopped gracefully.", a.name)
@@ -107,3 +247,63 @@ func (a *App) Run() error {
 	return nil // Graceful shutdown completed
 
 }
+
+func hasComponent(components []IComponent, name string) bool {
+	return slices.ContainsFunc(components, func(c IComponent) bool { return c.Name() == name })
+}
+
+// startAttempt 记录一次组件启动的过程
+type startAttempt struct {
+	// ready 为 nil 表示组件未实现 IReadiness 且没有重启策略
+	ready <-chan struct{}
+	// started 对配置了重启策略、但未实现 IReadiness 的组件，在第一次调用 Start 时关闭并作为就绪标志
+	started chan struct{}
+	exited  chan struct{}
+	// err 为 Start 的返回值，exited 关闭后可读
+	err error
+}
+
+// startLayer 并发启动同一层的组件，并等待它们全部就绪.
+// 实现了 IReadiness 的组件以 Ready() 关闭作为就绪标志，其余组件以 Start 返回作为就绪标志.
+// ctx 传递给组件的 Start，waitCtx 结束时放弃等待.
+func (a *App) startLayer(ctx, waitCtx, deadline context.Context, g *errgroup.Group, layer []IComponent) error {
+	attempts := make([]*startAttempt, 0, len(layer))
+	for _, component := range layer {
+		// 在闭包中使用局部变量捕获 component
+		c := component
+		att := &startAttempt{exited: make(chan struct{})}
+		if r, ok := c.(IReadiness); ok {
+			att.ready = r.Ready()
+		} else if a.supervised(c.Name()) {
+			// 受监管的组件 Start 通常会一直阻塞，以开始运行作为就绪标志
+			att.started = make(chan struct{})
+			att.ready = att.started
+		}
+		attempts = append(attempts, att)
+
+		a.status.set(c.Name(), StateStarting, nil)
+		g.Go(func() error {
+			err := runHooks(waitCtx, BeforeStart, c.Name(), a.componentHooks[c.Name()][BeforeStart])
+			if err != nil {
+				a.status.finish(c.Name(), StateFailed, err)
+			} else {
+				err = a.supervise(ctx, c, att)
+			}
+			att.err = err
+			close(att.exited)
+			return err
+		})
+	}
+
+	for i, c := range layer {
+		if err := a.awaitReady(waitCtx, deadline, c, attempts[i]); err != nil {
+			return err
+		}
+	}
+	for _, c := range layer {
+		if err := runHooks(waitCtx, AfterStart, c.Name(), a.componentHooks[c.Name()][AfterStart]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
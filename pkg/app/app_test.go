@@ -0,0 +1,307 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// recorder 按发生顺序记录组件的生命周期事件
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.events)
+}
+
+// fakeComponent Start 立即返回的组件，如初始化连接池
+type fakeComponent struct {
+	name     string
+	deps     []string
+	rec      *recorder
+	startErr error
+	stopErr  error
+	// stopDelay Stop 返回前的等待时间，期间不响应 ctx
+	stopDelay time.Duration
+}
+
+func (c *fakeComponent) Name() string           { return c.name }
+func (c *fakeComponent) Dependencies() []string { return c.deps }
+
+func (c *fakeComponent) Start(ctx context.Context) error {
+	c.rec.add("start " + c.name)
+	return c.startErr
+}
+
+func (c *fakeComponent) Stop(ctx context.Context) error {
+	c.rec.add("stop " + c.name)
+	time.Sleep(c.stopDelay)
+	return c.stopErr
+}
+
+// serverComponent Start 一直阻塞到 Stop 的组件，如 gRPC server，通过 IReadiness 报告就绪
+type serverComponent struct {
+	fakeComponent
+	// pending 为 true 时由测试调用 markReady 标记就绪，否则 Start 后立即就绪
+	pending  bool
+	ready    chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+func newServer(name string, rec *recorder, deps ...string) *serverComponent {
+	return &serverComponent{
+		fakeComponent: fakeComponent{name: name, deps: deps, rec: rec},
+		ready:         make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+}
+
+// newPendingServer 返回需要调用 markReady 才会就绪的组件
+func newPendingServer(name string, rec *recorder, deps ...string) *serverComponent {
+	c := newServer(name, rec, deps...)
+	c.pending = true
+	return c
+}
+
+func (c *serverComponent) Ready() <-chan struct{} {
+	return c.ready
+}
+
+func (c *serverComponent) Start(ctx context.Context) error {
+	if err := c.fakeComponent.Start(ctx); err != nil {
+		return err
+	}
+	if !c.pending {
+		c.markReady()
+	}
+	select {
+	case <-c.stopped:
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+func (c *serverComponent) Stop(ctx context.Context) error {
+	c.stopOnce.Do(func() { close(c.stopped) })
+	return c.fakeComponent.Stop(ctx)
+}
+
+// markReady 将组件标记为就绪
+func (c *serverComponent) markReady() {
+	close(c.ready)
+}
+
+// run 在后台运行 App，返回 Run 的结果
+func run(a *App) <-chan error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- a.Run()
+	}()
+	return errCh
+}
+
+// shutdown 等待 App 就绪后发送 SIGTERM，并返回 Run 的结果
+func shutdown(t *testing.T, a *App, errCh <-chan error) error {
+	t.Helper()
+	select {
+	case <-a.Ready():
+	case err := <-errCh:
+		t.Fatalf("Run() returned before the app became ready: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("app did not become ready")
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	return wait(t, errCh)
+}
+
+// wait 等待 Run 返回
+func wait(t *testing.T, errCh <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-errCh:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return")
+		return nil
+	}
+}
+
+// waitFor 轮询直到 cond 成立
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// states 返回组件名称到状态的映射
+func states(a *App) map[string]State {
+	out := make(map[string]State)
+	for _, s := range a.Status() {
+		out[s.Name] = s.State
+	}
+	return out
+}
+
+func assertEvents(t *testing.T, got, want []string) {
+	t.Helper()
+	if !slices.Equal(got, want) {
+		t.Fatalf("events = %q, want %q", got, want)
+	}
+}
+
+func TestRunStartsInDependencyOrderAndStopsInReverse(t *testing.T) {
+	rec := &recorder{}
+	components := []IComponent{
+		newServer("grpc", rec, "db", "cache"),
+		&fakeComponent{name: "cache", rec: rec, deps: []string{"config"}},
+		&fakeComponent{name: "db", rec: rec, deps: []string{"config"}},
+		&fakeComponent{name: "config", rec: rec},
+	}
+	a, err := New("test", components)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := shutdown(t, a, run(a)); err != nil {
+		t.Fatalf("Run() = %v, want nil", err)
+	}
+	// 同层组件并发启动，顺序不确定；停止时按注册顺序的逆序依次停止
+	got := rec.get()
+	slices.Sort(got[1:3])
+	assertEvents(t, got, []string{
+		"start config", "start cache", "start db", "start grpc",
+		"stop grpc", "stop db", "stop cache", "stop config",
+	})
+
+	report := a.ShutdownReport()
+	if report == nil {
+		t.Fatal("ShutdownReport() = nil after Run returned")
+	}
+	var order []string
+	for _, c := range report.Components {
+		order = append(order, c.Name)
+	}
+	assertEvents(t, order, []string{"grpc", "cache", "db", "config"})
+	for name, state := range states(a) {
+		if state != StateStopped {
+			t.Errorf("%s is %s after shutdown, want %s", name, state, StateStopped)
+		}
+	}
+}
+
+func TestRunStatusTransitions(t *testing.T) {
+	rec := &recorder{}
+	db := newPendingServer("db", rec)
+	api := newPendingServer("api", rec, "db")
+	a, err := New("test", []IComponent{api, db})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := states(a); got["db"] != StateCreated || got["api"] != StateCreated {
+		t.Fatalf("states before Run = %v, want all created", got)
+	}
+
+	errCh := run(a)
+	// db 就绪前不会启动 api
+	waitFor(t, "db to start", func() bool { return states(a)["db"] == StateStarting })
+	if got := states(a)["api"]; got != StateCreated {
+		t.Fatalf("api is %s before db is ready, want %s", got, StateCreated)
+	}
+	if a.IsReady() || a.CheckStarted(context.Background()) == nil {
+		t.Fatal("app reports ready before its components are ready")
+	}
+
+	db.markReady()
+	waitFor(t, "api to start", func() bool { return states(a)["api"] == StateStarting })
+	if got := states(a)["db"]; got != StateRunning {
+		t.Fatalf("db is %s after becoming ready, want %s", got, StateRunning)
+	}
+
+	api.markReady()
+	<-a.Ready()
+	if !a.IsReady() {
+		t.Fatalf("IsReady() = false with states %v", states(a))
+	}
+	if err := a.CheckReady(context.Background()); err != nil {
+		t.Fatalf("CheckReady() = %v", err)
+	}
+
+	if err := shutdown(t, a, errCh); err != nil {
+		t.Fatalf("Run() = %v, want nil", err)
+	}
+	if a.IsReady() || a.CheckReady(context.Background()) == nil {
+		t.Fatal("app reports ready after shutdown")
+	}
+}
+
+func TestRunStartTimeout(t *testing.T) {
+	rec := &recorder{}
+	db := newPendingServer("db", rec)
+	api := newServer("api", rec, "db")
+	a, err := New("test", []IComponent{db, api}, WithStartTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// db 一直没有就绪
+	err = wait(t, run(a))
+	if !errors.Is(err, ErrStartTimeout) {
+		t.Fatalf("Run() = %v, want %v", err, ErrStartTimeout)
+	}
+	// 已启动的组件被停止，后续的依赖层不会启动
+	assertEvents(t, rec.get(), []string{"start db", "stop db"})
+	if got := states(a)["api"]; got != StateCreated {
+		t.Fatalf("api is %s, want %s", got, StateCreated)
+	}
+	select {
+	case <-a.Ready():
+		t.Fatal("Ready() closed after a start timeout")
+	default:
+	}
+}
+
+func TestRunStartFailure(t *testing.T) {
+	rec := &recorder{}
+	errBoom := errors.New("boom")
+	components := []IComponent{
+		&fakeComponent{name: "config", rec: rec},
+		&fakeComponent{name: "db", rec: rec, deps: []string{"config"}, startErr: errBoom},
+		&fakeComponent{name: "api", rec: rec, deps: []string{"db"}},
+	}
+	a, err := New("test", components)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = wait(t, run(a))
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("Run() = %v, want %v", err, errBoom)
+	}
+	assertEvents(t, rec.get(), []string{"start config", "start db", "stop db", "stop config"})
+	if got := states(a)["api"]; got != StateCreated {
+		t.Fatalf("api is %s, want %s", got, StateCreated)
+	}
+}
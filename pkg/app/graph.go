@@ -0,0 +1,156 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// IDependent 是组件可选实现的接口，用于声明该组件依赖的其他组件（按 Name() 匹配）
+type IDependent interface {
+	Dependencies() []string
+}
+
+// buildLayers 根据组件之间的依赖关系做拓扑排序，返回按层分组的组件.
+// 同一层内的组件互不依赖，可以并发启动；第 N 层只依赖前 N-1 层的组件.
+// 层内组件保持注册时的顺序，保证启动/停止顺序是确定的.
+func buildLayers(components []IComponent, extraDeps map[string][]string) ([][]IComponent, error) {
+	index := make(map[string]int, len(components))
+	for i, c := range components {
+		name := c.Name()
+		if _, ok := index[name]; ok {
+			return nil, fmt.Errorf("app: duplicate component name %q", name)
+		}
+		index[name] = i
+	}
+
+	for name := range extraDeps {
+		if _, ok := index[name]; !ok {
+			return nil, fmt.Errorf("app: dependencies declared for unknown component %q", name)
+		}
+	}
+
+	// deps[i] 为第 i 个组件依赖的组件下标，dependents[i] 为依赖第 i 个组件的组件下标
+	deps := make([][]int, len(components))
+	dependents := make([][]int, len(components))
+	for i, c := range components {
+		var names []string
+		if d, ok := c.(IDependent); ok {
+			names = append(names, d.Dependencies()...)
+		}
+		names = append(names, extraDeps[c.Name()]...)
+
+		seen := make(map[int]struct{}, len(names))
+		for _, dep := range names {
+			j, ok := index[dep]
+			if !ok {
+				return nil, fmt.Errorf("app: component %q depends on unknown component %q", c.Name(), dep)
+			}
+			if j == i {
+				return nil, fmt.Errorf("app: component %q depends on itself", c.Name())
+			}
+			if _, ok := seen[j]; ok {
+				continue
+			}
+			seen[j] = struct{}{}
+			deps[i] = append(deps[i], j)
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+
+	// Kahn 算法，每一轮入度为 0 的组件构成一层
+	inDegree := make([]int, len(components))
+	for i := range components {
+		inDegree[i] = len(deps[i])
+	}
+
+	var layers [][]IComponent
+	resolved := 0
+	current := make([]int, 0, len(components))
+	for i := range components {
+		if inDegree[i] == 0 {
+			current = append(current, i)
+		}
+	}
+	for len(current) > 0 {
+		layer := make([]IComponent, 0, len(current))
+		var next []int
+		for _, i := range current {
+			layer = append(layer, components[i])
+			for _, j := range dependents[i] {
+				inDegree[j]--
+				if inDegree[j] == 0 {
+					next = append(next, j)
+				}
+			}
+		}
+		resolved += len(current)
+		layers = append(layers, layer)
+		// 保证同层组件按注册顺序排列
+		slices.Sort(next)
+		current = next
+	}
+
+	if resolved != len(components) {
+		return nil, fmt.Errorf("app: dependency cycle detected: %s", findCycle(components, deps, inDegree))
+	}
+
+	return layers, nil
+}
+
+// findCycle 在未能完成拓扑排序的组件中找出一个环，用于生成可读的错误信息
+func findCycle(components []IComponent, deps [][]int, inDegree []int) string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(components))
+	var stack []int
+
+	var visit func(i int) []int
+	visit = func(i int) []int {
+		state[i] = visiting
+		stack = append(stack, i)
+		for _, j := range deps[i] {
+			switch state[j] {
+			case visiting:
+				for k, v := range stack {
+					if v == j {
+						return append(append([]int{}, stack[k:]...), j)
+					}
+				}
+			case unvisited:
+				if cycle := visit(j); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[i] = visited
+		return nil
+	}
+
+	for i := range components {
+		if inDegree[i] == 0 || state[i] != unvisited {
+			continue
+		}
+		if cycle := visit(i); cycle != nil {
+			names := make([]string, 0, len(cycle))
+			for _, k := range cycle {
+				names = append(names, components[k].Name())
+			}
+			return strings.Join(names, " -> ")
+		}
+	}
+	return "unknown"
+}
+
+// componentNames 返回组件名称列表，便于日志输出
+func componentNames(components []IComponent) string {
+	names := make([]string, 0, len(components))
+	for _, c := range components {
+		names = append(names, c.Name())
+	}
+	return strings.Join(names, ", ")
+}
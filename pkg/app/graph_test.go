@@ -0,0 +1,137 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// graphComponent 只用于拓扑排序的组件
+type graphComponent struct {
+	name string
+	deps []string
+}
+
+func (c *graphComponent) Start(ctx context.Context) error { return nil }
+func (c *graphComponent) Stop(ctx context.Context) error  { return nil }
+func (c *graphComponent) Name() string                    { return c.name }
+func (c *graphComponent) Dependencies() []string          { return c.deps }
+
+// layerNames 将分层结果转换为组件名称
+func layerNames(layers [][]IComponent) [][]string {
+	out := make([][]string, 0, len(layers))
+	for _, layer := range layers {
+		names := make([]string, 0, len(layer))
+		for _, c := range layer {
+			names = append(names, c.Name())
+		}
+		out = append(out, names)
+	}
+	return out
+}
+
+func TestBuildLayers(t *testing.T) {
+	tests := []struct {
+		name       string
+		components []IComponent
+		extraDeps  map[string][]string
+		want       [][]string
+		// wantErr 不为空时期望返回包含该内容的错误
+		wantErr string
+	}{
+		{
+			name: "no dependencies keep registration order",
+			components: []IComponent{
+				&graphComponent{name: "b"},
+				&graphComponent{name: "a"},
+			},
+			want: [][]string{{"b", "a"}},
+		},
+		{
+			name: "chain",
+			components: []IComponent{
+				&graphComponent{name: "grpc", deps: []string{"db"}},
+				&graphComponent{name: "db", deps: []string{"config"}},
+				&graphComponent{name: "config"},
+			},
+			want: [][]string{{"config"}, {"db"}, {"grpc"}},
+		},
+		{
+			name: "diamond",
+			components: []IComponent{
+				&graphComponent{name: "api", deps: []string{"cache", "db"}},
+				&graphComponent{name: "db", deps: []string{"config"}},
+				&graphComponent{name: "cache", deps: []string{"config"}},
+				&graphComponent{name: "config"},
+			},
+			// 同层组件按注册顺序排列
+			want: [][]string{{"config"}, {"db", "cache"}, {"api"}},
+		},
+		{
+			name: "extra dependencies are merged",
+			components: []IComponent{
+				&graphComponent{name: "grpc"},
+				&graphComponent{name: "db"},
+			},
+			extraDeps: map[string][]string{"grpc": {"db", "db"}},
+			want:      [][]string{{"db"}, {"grpc"}},
+		},
+		{
+			name: "cycle",
+			components: []IComponent{
+				&graphComponent{name: "standalone"},
+				&graphComponent{name: "a", deps: []string{"b"}},
+				&graphComponent{name: "b", deps: []string{"c"}},
+				&graphComponent{name: "c", deps: []string{"a"}},
+			},
+			wantErr: "dependency cycle detected: a -> b -> c -> a",
+		},
+		{
+			name:       "self dependency",
+			components: []IComponent{&graphComponent{name: "a", deps: []string{"a"}}},
+			wantErr:    `component "a" depends on itself`,
+		},
+		{
+			name:       "unknown dependency",
+			components: []IComponent{&graphComponent{name: "a", deps: []string{"missing"}}},
+			wantErr:    `component "a" depends on unknown component "missing"`,
+		},
+		{
+			name:       "extra dependencies for unknown component",
+			components: []IComponent{&graphComponent{name: "a"}},
+			extraDeps:  map[string][]string{"missing": {"a"}},
+			wantErr:    `dependencies declared for unknown component "missing"`,
+		},
+		{
+			name: "duplicate name",
+			components: []IComponent{
+				&graphComponent{name: "a"},
+				&graphComponent{name: "a"},
+			},
+			wantErr: `duplicate component name "a"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layers, err := buildLayers(tt.components, tt.extraDeps)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("buildLayers() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := layerNames(layers)
+			if len(got) != len(tt.want) {
+				t.Fatalf("layers = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if strings.Join(got[i], ",") != strings.Join(tt.want[i], ",") {
+					t.Fatalf("layers = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
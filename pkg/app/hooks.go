@@ -0,0 +1,94 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/yanking/app-skeleton/pkg/log"
+)
+
+// Stage 生命周期阶段
+type Stage string
+
+const (
+	// BeforeStart App 级钩子在任何组件启动前执行；组件级钩子在该组件 Start 前执行
+	BeforeStart Stage = "before-start"
+	// AfterStart App 级钩子在所有组件就绪后执行；组件级钩子在该组件就绪后执行
+	AfterStart Stage = "after-start"
+	// BeforeStop App 级钩子在停止流程开始时执行；组件级钩子在该组件 Stop 前执行
+	BeforeStop Stage = "before-stop"
+	// AfterStop App 级钩子在所有组件停止后执行；组件级钩子在该组件 Stop 返回后执行
+	AfterStop Stage = "after-stop"
+)
+
+// Hook 生命周期钩子. 同一阶段的钩子按注册顺序依次执行.
+//
+// 启动阶段（BeforeStart/AfterStart）的钩子返回错误时默认中断启动，App 进入停止流程；
+// 停止阶段（BeforeStop/AfterStop）的钩子返回错误时只记录日志，不影响后续组件的停止.
+type Hook struct {
+	Name string
+	Fn   func(ctx context.Context) error
+	// Timeout 单个钩子的执行期限，为 0 时只受所在流程的 ctx 约束
+	Timeout time.Duration
+	// ContinueOnError 为 true 时启动阶段的钩子失败只记录日志，不中断启动
+	ContinueOnError bool
+}
+
+// hookSet 各阶段的钩子
+type hookSet map[Stage][]Hook
+
+// isStartStage 启动阶段的钩子失败会中断启动
+func isStartStage(stage Stage) bool {
+	return stage == BeforeStart || stage == AfterStart
+}
+
+// runHooks 依次执行钩子. owner 为组件名称，App 级钩子为空.
+// 启动阶段遇到不可忽略的错误时立即返回，停止阶段执行完所有钩子.
+func runHooks(ctx context.Context, stage Stage, owner string, hooks []Hook) error {
+	for _, h := range hooks {
+		scope := "app"
+		if owner != "" {
+			scope = owner
+		}
+
+		err := runHook(ctx, h)
+		if err == nil {
+			continue
+		}
+
+		err = fmt.Errorf("%s hook %q of %s: %w", stage, h.Name, scope, err)
+		if isStartStage(stage) && !h.ContinueOnError {
+			log.Errorf("app: %v", err)
+			return err
+		}
+		log.Warnf("app: %v", err)
+	}
+	return nil
+}
+
+func runHook(ctx context.Context, h Hook) (err error) {
+	if h.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.Timeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+			}
+		}()
+		done <- h.Fn(ctx)
+	}()
+
+	select {
+	case err = <-done:
+		return err
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
@@ -0,0 +1,198 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// hook 返回一个记录执行顺序的钩子
+func hook(rec *recorder, name string, err error) Hook {
+	return Hook{Name: name, Fn: func(ctx context.Context) error {
+		rec.add(name)
+		return err
+	}}
+}
+
+func TestRunHooks(t *testing.T) {
+	errHook := errors.New("hook failed")
+	tests := []struct {
+		name  string
+		stage Stage
+		// hooks 的参数为执行记录
+		hooks   func(rec *recorder) []Hook
+		want    []string
+		wantErr string
+	}{
+		{
+			name:  "hooks run in registration order",
+			stage: BeforeStart,
+			hooks: func(rec *recorder) []Hook {
+				return []Hook{hook(rec, "first", nil), hook(rec, "second", nil), hook(rec, "third", nil)}
+			},
+			want: []string{"first", "second", "third"},
+		},
+		{
+			name:  "start stage stops at the first error",
+			stage: BeforeStart,
+			hooks: func(rec *recorder) []Hook {
+				return []Hook{hook(rec, "migrate", errHook), hook(rec, "warmup", nil)}
+			},
+			want:    []string{"migrate"},
+			wantErr: `before-start hook "migrate" of db: hook failed`,
+		},
+		{
+			name:  "start stage continues on error when allowed",
+			stage: AfterStart,
+			hooks: func(rec *recorder) []Hook {
+				h := hook(rec, "notify", errHook)
+				h.ContinueOnError = true
+				return []Hook{h, hook(rec, "warmup", nil)}
+			},
+			want: []string{"notify", "warmup"},
+		},
+		{
+			name:  "stop stage runs every hook",
+			stage: BeforeStop,
+			hooks: func(rec *recorder) []Hook {
+				return []Hook{hook(rec, "flush", errHook), hook(rec, "deregister", nil)}
+			},
+			want: []string{"flush", "deregister"},
+		},
+		{
+			name:  "timeout",
+			stage: AfterStart,
+			hooks: func(rec *recorder) []Hook {
+				return []Hook{{
+					Name:    "slow",
+					Timeout: 10 * time.Millisecond,
+					Fn: func(ctx context.Context) error {
+						rec.add("slow")
+						time.Sleep(time.Second)
+						return nil
+					},
+				}, hook(rec, "next", nil)}
+			},
+			want:    []string{"slow"},
+			wantErr: "context deadline exceeded",
+		},
+		{
+			name:  "panic",
+			stage: BeforeStart,
+			hooks: func(rec *recorder) []Hook {
+				return []Hook{{Name: "crash", Fn: func(ctx context.Context) error { panic("oops") }}}
+			},
+			wantErr: "panic: oops",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{}
+			err := runHooks(context.Background(), tt.stage, "db", tt.hooks(rec))
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("runHooks() error = %v, want %q", err, tt.wantErr)
+			}
+			assertEvents(t, rec.get(), tt.want)
+		})
+	}
+}
+
+func TestHooksAroundComponents(t *testing.T) {
+	rec := &recorder{}
+	api := newServer("api", rec, "db")
+	components := []IComponent{&fakeComponent{name: "db", rec: rec}, api}
+	a, err := New("test", components,
+		WithHooks(BeforeStart, hook(rec, "app before-start", nil)),
+		WithHooks(AfterStart, hook(rec, "app after-start", nil)),
+		WithHooks(BeforeStop, hook(rec, "app before-stop", nil)),
+		WithHooks(AfterStop, hook(rec, "app after-stop", nil)),
+		WithComponentHooks("db", BeforeStart, hook(rec, "db before-start", nil)),
+		WithComponentHooks("db", AfterStart, hook(rec, "db after-start", nil)),
+		WithComponentHooks("api", BeforeStop, hook(rec, "api before-stop", nil)),
+		WithComponentHooks("api", AfterStop, hook(rec, "api after-stop", nil)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := shutdown(t, a, run(a)); err != nil {
+		t.Fatalf("Run() = %v, want nil", err)
+	}
+	assertEvents(t, rec.get(), []string{
+		"app before-start",
+		"db before-start", "start db", "db after-start",
+		"start api",
+		"app after-start",
+		"app before-stop",
+		"api before-stop", "stop api", "api after-stop",
+		"stop db",
+		"app after-stop",
+	})
+}
+
+func TestHookFailures(t *testing.T) {
+	errHook := errors.New("hook failed")
+	tests := []struct {
+		name string
+		opts []Option
+		// rec 中的钩子和组件事件
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "app before-start failure starts no component",
+			opts:    []Option{WithHooks(BeforeStart, Hook{Name: "check", Fn: func(context.Context) error { return errHook }})},
+			wantErr: true,
+		},
+		{
+			name:    "component before-start failure skips its start and stops started layers",
+			opts:    []Option{WithComponentHooks("api", BeforeStart, Hook{Name: "migrate", Fn: func(context.Context) error { return errHook }})},
+			want:    []string{"start db", "stop api", "stop db"},
+			wantErr: true,
+		},
+		{
+			name:    "component after-start failure aborts the start",
+			opts:    []Option{WithComponentHooks("db", AfterStart, Hook{Name: "warmup", Fn: func(context.Context) error { return errHook }})},
+			want:    []string{"start db", "stop db"},
+			wantErr: true,
+		},
+		{
+			name: "stop hook failure does not block shutdown",
+			opts: []Option{
+				WithComponentHooks("api", BeforeStop, Hook{Name: "drain", Fn: func(context.Context) error { return errHook }}),
+				WithHooks(BeforeStop, Hook{Name: "deregister", Fn: func(context.Context) error { return errHook }}),
+			},
+			want: []string{"start db", "start api", "stop api", "stop db"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{}
+			api := newServer("api", rec, "db")
+			a, err := New("test", []IComponent{&fakeComponent{name: "db", rec: rec}, api}, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			errCh := run(a)
+			if tt.wantErr {
+				if err := wait(t, errCh); !errors.Is(err, errHook) {
+					t.Fatalf("Run() = %v, want %v", err, errHook)
+				}
+			} else if err := shutdown(t, a, errCh); err != nil {
+				t.Fatalf("Run() = %v, want nil", err)
+			}
+			assertEvents(t, rec.get(), tt.want)
+		})
+	}
+}
+
+func TestNewRejectsHooksForUnknownComponent(t *testing.T) {
+	components := []IComponent{&fakeComponent{name: "db", rec: &recorder{}}}
+	_, err := New("test", components, WithComponentHooks("dbb", BeforeStart, Hook{Name: "migrate"}))
+	if err == nil || !strings.Contains(err.Error(), `unknown component "dbb"`) {
+		t.Fatalf("New() error = %v, want unknown component", err)
+	}
+}
@@ -0,0 +1,109 @@
+package app
+
+import "time"
+
+// Option 用于配置 App
+type Option func(a *App)
+
+// WithDependencies 在 App 层面为组件声明依赖，适用于无法修改组件实现（未实现 IDependent）的场景.
+// 可以多次调用，依赖会被合并.
+func WithDependencies(name string, deps ...string) Option {
+	return func(a *App) {
+		a.deps[name] = append(a.deps[name], deps...)
+	}
+}
+
+// WithStartTimeout 设置所有组件就绪的期限，超过期限仍有组件未就绪时 App 会直接进入停止流程并返回错误.
+// 为 0 时不限制.
+func WithStartTimeout(timeout time.Duration) Option {
+	return func(a *App) {
+		a.startTimeout = timeout
+	}
+}
+
+// WithShutdownTimeout 设置整个停止流程（包含 pre-stop delay）的总预算，默认 20s.
+// 在 Kubernetes 中应小于 terminationGracePeriodSeconds.
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(a *App) {
+		if timeout > 0 {
+			a.shutdownTimeout = timeout
+		}
+	}
+}
+
+// WithStopTimeout 设置每个组件 Stop 的默认预算，为 0 时只受总预算约束
+func WithStopTimeout(timeout time.Duration) Option {
+	return func(a *App) {
+		a.stopTimeout = timeout
+	}
+}
+
+// WithComponentStopTimeout 为指定组件单独设置 Stop 的预算，优先于 WithStopTimeout，组件名称不存在时 New 返回错误
+func WithComponentStopTimeout(name string, timeout time.Duration) Option {
+	return func(a *App) {
+		a.componentStopTimeouts[name] = timeout
+	}
+}
+
+// WithPreStopDelay 设置通知组件 PreStop 之后、真正停止组件之前的等待时间，
+// 让负载均衡器有时间观察到 NOT_SERVING 并摘除流量
+func WithPreStopDelay(delay time.Duration) Option {
+	return func(a *App) {
+		a.preStopDelay = delay
+	}
+}
+
+// WithParallelStop 设置同一依赖层内的组件是否并发停止，默认按注册顺序的逆序依次停止
+func WithParallelStop(parallel bool) Option {
+	return func(a *App) {
+		a.parallelStop = parallel
+	}
+}
+
+// WithHooks 注册 App 级生命周期钩子，可以多次调用，同一阶段的钩子按注册顺序执行
+func WithHooks(stage Stage, hooks ...Hook) Option {
+	return func(a *App) {
+		a.hooks[stage] = append(a.hooks[stage], hooks...)
+	}
+}
+
+// WithComponentHooks 为指定组件注册生命周期钩子，组件名称不存在时 New 返回错误
+func WithComponentHooks(name string, stage Stage, hooks ...Hook) Option {
+	return func(a *App) {
+		if a.componentHooks[name] == nil {
+			a.componentHooks[name] = make(hookSet)
+		}
+		a.componentHooks[name][stage] = append(a.componentHooks[name][stage], hooks...)
+	}
+}
+
+// WithRestartPolicy 为指定组件配置重启策略，组件名称不存在时 New 返回错误
+func WithRestartPolicy(name string, policy RestartPolicy) Option {
+	return func(a *App) {
+		a.restartPolicies[name] = policy
+	}
+}
+
+// WithConfig 设置初始配置快照和重新读取配置的方法. 配置后 App 会把 SIGHUP 作为配置重载的信号.
+func WithConfig(initial any, loader ConfigLoader) Option {
+	return func(a *App) {
+		a.config = initial
+		a.configLoader = loader
+	}
+}
+
+// WithReloader 注册一个不是组件的配置重载参与者，按注册顺序在组件之前调用
+func WithReloader(name string, fn ReloadFunc) Option {
+	return func(a *App) {
+		a.reloaders = append(a.reloaders, reloader{name: name, fn: fn})
+	}
+}
+
+// WithReloadTimeout 设置一次配置重载（包含回滚）的期限，默认 30s
+func WithReloadTimeout(timeout time.Duration) Option {
+	return func(a *App) {
+		if timeout > 0 {
+			a.reloadTimeout = timeout
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/yanking/app-skeleton/pkg/log"
+)
+
+const defaultReloadTimeout = 30 * time.Second
+
+// ErrReloadNotConfigured 没有通过 WithConfig 配置 ConfigLoader
+var ErrReloadNotConfigured = errors.New("app: config reload is not configured")
+
+// IReloader 是组件可选实现的接口，配置重新加载时按依赖顺序调用.
+// 组件返回错误表示拒绝新配置，此时已经应用新配置的组件会以旧配置再次调用 Reload 进行回滚.
+type IReloader interface {
+	Reload(ctx context.Context, cfg any) error
+}
+
+// ReloadFunc 用于注册不是组件的配置重载参与者，如日志级别
+type ReloadFunc func(ctx context.Context, cfg any) error
+
+// ConfigLoader 重新读取并校验配置，返回新的配置快照
+type ConfigLoader func() (any, error)
+
+// reloader 一个配置重载的参与者
+type reloader struct {
+	name string
+	fn   ReloadFunc
+}
+
+// Config 返回当前生效的配置快照
+func (a *App) Config() any {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+	return a.config
+}
+
+// Reload 重新读取配置并分发给所有参与者. 任一参与者拒绝新配置时，
+// 已应用的参与者按相反顺序回滚到旧配置，并返回错误.
+func (a *App) Reload(ctx context.Context) error {
+	if a.configLoader == nil {
+		return ErrReloadNotConfigured
+	}
+
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+
+	log.Infof("app: reloading configuration")
+	cfg, err := a.configLoader()
+	if err != nil {
+		log.Errorf("app: failed to load configuration, keeping the current one: %v", err)
+		return fmt.Errorf("app: load config: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.reloadTimeout)
+	defer cancel()
+
+	participants := a.reloadParticipants()
+	for i, r := range participants {
+		if err := r.fn(ctx, cfg); err != nil {
+			log.Errorf("app: %s rejected the new configuration: %v", r.name, err)
+			// 参与者可能因为 ctx 超时而失败，回滚使用新的 ctx，保证已应用的参与者有足够的时间恢复
+			rollbackCtx, cancelRollback := context.WithTimeout(context.WithoutCancel(ctx), a.reloadTimeout)
+			a.rollback(rollbackCtx, participants[:i])
+			cancelRollback()
+			return fmt.Errorf("app: reload rejected by %s: %w", r.name, err)
+		}
+	}
+
+	a.config = cfg
+	log.Infof("app: configuration reloaded, %d participant(s) applied", len(participants))
+	return nil
+}
+
+// rollback 将已应用新配置的参与者按相反顺序恢复到旧配置
+func (a *App) rollback(ctx context.Context, applied []reloader) {
+	for i := len(applied) - 1; i >= 0; i-- {
+		r := applied[i]
+		if err := r.fn(ctx, a.config); err != nil {
+			log.Errorf("app: failed to roll back %s to the previous configuration: %v", r.name, err)
+			continue
+		}
+		log.Infof("app: %s rolled back to the previous configuration", r.name)
+	}
+}
+
+// reloadParticipants 返回所有参与配置重载的对象：先是通过 WithReloader 注册的，再按依赖顺序是实现了 IReloader 的组件
+func (a *App) reloadParticipants() []reloader {
+	participants := append([]reloader{}, a.reloaders...)
+	for _, layer := range a.layers {
+		for _, c := range layer {
+			if r, ok := c.(IReloader); ok {
+				participants = append(participants, reloader{name: c.Name(), fn: r.Reload})
+			}
+		}
+	}
+	return participants
+}
+
+// watchReload 监听 SIGHUP，在 App 就绪后触发配置重载，直到 ctx 结束.
+// 就绪前收到的 SIGHUP 会在就绪后处理.
+func (a *App) watchReload(ctx context.Context) {
+	if a.configLoader == nil {
+		return
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hup)
+
+		select {
+		case <-a.ready:
+		case <-ctx.Done():
+			return
+		}
+
+		for {
+			select {
+			case <-hup:
+				log.Infof("app: SIGHUP received")
+				_ = a.Reload(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// reloadComponent 记录 Reload 调用的组件，reject 中的配置会被拒绝
+type reloadComponent struct {
+	*serverComponent
+	reject any
+}
+
+func (c *reloadComponent) Reload(ctx context.Context, cfg any) error {
+	c.rec.add(fmt.Sprintf("reload %s %v", c.name, cfg))
+	if cfg == c.reject {
+		return errors.New("invalid config")
+	}
+	return nil
+}
+
+// recordReloader 返回记录调用的 ReloadFunc
+func recordReloader(rec *recorder, name string) ReloadFunc {
+	return func(ctx context.Context, cfg any) error {
+		rec.add(fmt.Sprintf("reload %s %v", name, cfg))
+		return nil
+	}
+}
+
+func TestReload(t *testing.T) {
+	tests := []struct {
+		name string
+		// rejectBy 拒绝新配置 v2 的组件，为空时所有参与者都接受
+		rejectBy   string
+		want       []string
+		wantConfig string
+		wantErr    bool
+	}{
+		{
+			name: "participants apply in order",
+			want: []string{
+				"reload log v2",
+				"reload db v2", "reload api v2",
+			},
+			wantConfig: "v2",
+		},
+		{
+			name:     "applied participants roll back in reverse order",
+			rejectBy: "api",
+			want: []string{
+				"reload log v2",
+				"reload db v2", "reload api v2",
+				// api 拒绝后 db 和 log 回滚到 v1
+				"reload db v1", "reload log v1",
+			},
+			wantConfig: "v1",
+			wantErr:    true,
+		},
+		{
+			name:     "first component rejects",
+			rejectBy: "db",
+			want: []string{
+				"reload log v2",
+				"reload db v2",
+				"reload log v1",
+			},
+			wantConfig: "v1",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{}
+			db := &reloadComponent{serverComponent: newServer("db", rec)}
+			api := &reloadComponent{serverComponent: newServer("api", rec, "db")}
+			for _, c := range []*reloadComponent{db, api} {
+				if c.name == tt.rejectBy {
+					c.reject = "v2"
+				}
+			}
+			// 组件按依赖顺序参与重载，与注册顺序无关
+			a, err := New("test", []IComponent{api, db},
+				WithConfig("v1", func() (any, error) { return "v2", nil }),
+				WithReloader("log", recordReloader(rec, "log")),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = a.Reload(context.Background())
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Reload() error = %v, want error %t", err, tt.wantErr)
+			}
+			assertEvents(t, rec.get(), tt.want)
+			if got := a.Config(); got != tt.wantConfig {
+				t.Fatalf("Config() = %v, want %v", got, tt.wantConfig)
+			}
+		})
+	}
+}
+
+func TestReloadLoaderFailure(t *testing.T) {
+	rec := &recorder{}
+	errLoad := errors.New("parse error")
+	a, err := New("test", []IComponent{&fakeComponent{name: "db", rec: rec}},
+		WithConfig("v1", func() (any, error) { return nil, errLoad }),
+		WithReloader("log", recordReloader(rec, "log")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 新配置读取失败时不通知任何参与者
+	if err := a.Reload(context.Background()); !errors.Is(err, errLoad) {
+		t.Fatalf("Reload() = %v, want %v", err, errLoad)
+	}
+	assertEvents(t, rec.get(), nil)
+	if got := a.Config(); got != "v1" {
+		t.Fatalf("Config() = %v, want v1", got)
+	}
+}
+
+func TestReloadNotConfigured(t *testing.T) {
+	a, err := New("test", []IComponent{&fakeComponent{name: "db", rec: &recorder{}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Reload(context.Background()); !errors.Is(err, ErrReloadNotConfigured) {
+		t.Fatalf("Reload() = %v, want %v", err, ErrReloadNotConfigured)
+	}
+}
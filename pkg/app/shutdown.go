@@ -0,0 +1,185 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yanking/app-skeleton/pkg/log"
+)
+
+const defaultShutdownTimeout = 20 * time.Second
+
+// IPreStopper 是组件可选实现的接口.
+// 进入停止流程后、等待 pre-stop delay 之前调用，组件应在此将自身标记为不可用（如 gRPC health 置为 NOT_SERVING），
+// 让负载均衡器在连接被真正关闭前摘除流量.
+type IPreStopper interface {
+	PreStop(ctx context.Context) error
+}
+
+// ComponentStopResult 单个组件的停止结果
+type ComponentStopResult struct {
+	Name     string        `json:"name"`
+	Layer    int           `json:"layer"`
+	Duration time.Duration `json:"duration"`
+	TimedOut bool          `json:"timed_out"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Failed 组件是否未能在预算内正常停止
+func (r ComponentStopResult) Failed() bool {
+	return r.TimedOut || r.Error != ""
+}
+
+// ShutdownReport 记录一次停止流程的结果
+type ShutdownReport struct {
+	Cause      string                `json:"cause,omitempty"`
+	StartedAt  time.Time             `json:"started_at"`
+	Duration   time.Duration         `json:"duration"`
+	Components []ComponentStopResult `json:"components"`
+	// Abandoned 为 true 表示超出总预算后仍有组件的 Start 没有返回
+	Abandoned bool `json:"abandoned"`
+}
+
+// Failed 返回超时或停止出错的组件
+func (r *ShutdownReport) Failed() []ComponentStopResult {
+	var failed []ComponentStopResult
+	for _, c := range r.Components {
+		if c.Failed() {
+			failed = append(failed, c)
+		}
+	}
+	return failed
+}
+
+// String 返回便于日志输出的摘要
+func (r *ShutdownReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "shutdown took %s, %d component(s) stopped", r.Duration, len(r.Components))
+	for _, c := range r.Failed() {
+		if c.TimedOut {
+			fmt.Fprintf(&b, "; %s timed out after %s", c.Name, c.Duration)
+		} else {
+			fmt.Fprintf(&b, "; %s failed: %s", c.Name, c.Error)
+		}
+	}
+	if r.Abandoned {
+		b.WriteString("; some components did not exit before the shutdown deadline")
+	}
+	return b.String()
+}
+
+// ShutdownReport 返回最近一次停止流程的结果，Run 返回前为 nil
+func (a *App) ShutdownReport() *ShutdownReport {
+	a.reportMu.Lock()
+	defer a.reportMu.Unlock()
+	return a.report
+}
+
+// preStop 通知组件即将停止，并等待 pre-stop delay，期间组件仍在正常处理请求
+func (a *App) preStop(ctx context.Context, started [][]IComponent) {
+	for i := len(started) - 1; i >= 0; i-- {
+		for _, c := range started[i] {
+			p, ok := c.(IPreStopper)
+			if !ok {
+				continue
+			}
+			if err := p.PreStop(ctx); err != nil {
+				log.Errorf("app: pre-stop of component %s failed: %v", c.Name(), err)
+			}
+		}
+	}
+
+	if a.preStopDelay <= 0 {
+		return
+	}
+	log.Infof("app: waiting %s before stopping components", a.preStopDelay)
+	select {
+	case <-time.After(a.preStopDelay):
+	case <-ctx.Done():
+	}
+}
+
+// stopLayers 按依赖的逆序停止已启动的组件. 同层组件可以并发停止，每个组件受各自的停止预算约束.
+func (a *App) stopLayers(ctx context.Context, started [][]IComponent) []ComponentStopResult {
+	var results []ComponentStopResult
+	for i := len(started) - 1; i >= 0; i-- {
+		layer := started[i]
+		layerResults := make([]ComponentStopResult, len(layer))
+		if a.parallelStop {
+			var wg sync.WaitGroup
+			for j, c := range layer {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					layerResults[j] = a.stopComponent(ctx, c, i)
+				}()
+			}
+			wg.Wait()
+		} else {
+			// 同层组件按注册顺序的逆序依次停止
+			for j := len(layer) - 1; j >= 0; j-- {
+				layerResults[j] = a.stopComponent(ctx, layer[j], i)
+			}
+		}
+		results = append(results, layerResults...)
+	}
+	return results
+}
+
+// stopComponent 停止单个组件. Stop 没有在预算内返回时不再等待，记为超时.
+func (a *App) stopComponent(ctx context.Context, c IComponent, layer int) ComponentStopResult {
+	name := c.Name()
+	// 组件超时后 AfterStop 钩子仍可以使用总预算中剩余的时间
+	parent := ctx
+	timeout := a.stopTimeout
+	if t, ok := a.componentStopTimeouts[name]; ok {
+		timeout = t
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	log.Infof("app: attempting to stop component: %s", name)
+	a.status.set(name, StateStopping, nil)
+
+	start := time.Now()
+	hooks := a.componentHooks[name]
+	_ = runHooks(ctx, BeforeStop, name, hooks[BeforeStop])
+
+	done := make(chan error, 1)
+	go func() {
+		// The component's Stop method should respect this context's deadline.
+		done <- c.Stop(ctx)
+	}()
+
+	result := ComponentStopResult{Name: name, Layer: layer}
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	_ = runHooks(parent, AfterStop, name, hooks[AfterStop])
+	result.Duration = time.Since(start)
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		result.TimedOut = true
+		a.status.set(name, StateStopped, err)
+		log.Errorf("app: component %s did not stop within its budget (%s)", name, result.Duration)
+	case err != nil:
+		result.Error = err.Error()
+		a.status.set(name, StateStopped, err)
+		log.Errorf("app: error stopping component %s: %v", name, err)
+	default:
+		a.status.set(name, StateStopped, nil)
+		log.Infof("app: component %s stopped successfully.", name)
+	}
+	return result
+}
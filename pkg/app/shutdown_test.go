@@ -0,0 +1,112 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// preStopComponent 记录 PreStop 调用时间的组件
+type preStopComponent struct {
+	*serverComponent
+	preStopAt time.Time
+	stopAt    time.Time
+}
+
+func (c *preStopComponent) PreStop(ctx context.Context) error {
+	c.rec.add("prestop " + c.name)
+	c.preStopAt = time.Now()
+	return nil
+}
+
+func (c *preStopComponent) Stop(ctx context.Context) error {
+	c.stopAt = time.Now()
+	return c.serverComponent.Stop(ctx)
+}
+
+func TestNewRejectsStopTimeoutForUnknownComponent(t *testing.T) {
+	components := []IComponent{&fakeComponent{name: "db", rec: &recorder{}}}
+	_, err := New("test", components, WithComponentStopTimeout("dbb", time.Second))
+	if err == nil || !strings.Contains(err.Error(), `unknown component "dbb"`) {
+		t.Fatalf("New() error = %v, want unknown component", err)
+	}
+}
+
+func TestShutdownReport(t *testing.T) {
+	rec := &recorder{}
+	errStop := errors.New("close failed")
+	api := newServer("api", rec, "cache", "db")
+	components := []IComponent{
+		&fakeComponent{name: "db", rec: rec, stopDelay: time.Second},
+		&fakeComponent{name: "cache", rec: rec, stopErr: errStop},
+		api,
+	}
+	// db 的 Stop 不响应 ctx，只能在自己的预算到期后被放弃，其余组件只受默认预算约束
+	a, err := New("test", components,
+		WithStopTimeout(time.Second),
+		WithComponentStopTimeout("db", 50*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if err := shutdown(t, a, run(a)); err != nil {
+		t.Fatalf("Run() = %v, want nil", err)
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Fatalf("shutdown took %s, want the db stop abandoned after its own budget", elapsed)
+	}
+
+	report := a.ShutdownReport()
+	if report == nil {
+		t.Fatal("ShutdownReport() = nil")
+	}
+	results := make(map[string]ComponentStopResult)
+	for _, r := range report.Components {
+		results[r.Name] = r
+	}
+	if r := results["api"]; r.Failed() || r.Layer != 1 {
+		t.Errorf("api result = %+v, want a clean stop in layer 1", r)
+	}
+	if r := results["cache"]; r.TimedOut || r.Error != errStop.Error() {
+		t.Errorf("cache result = %+v, want error %q", r, errStop)
+	}
+	if r := results["db"]; !r.TimedOut || r.Duration >= time.Second {
+		t.Errorf("db result = %+v, want timed out after 50ms", r)
+	}
+	if failed := report.Failed(); len(failed) != 2 {
+		t.Errorf("Failed() = %+v, want cache and db", failed)
+	}
+	if s := report.String(); !strings.Contains(s, "db timed out") || !strings.Contains(s, "cache failed: close failed") {
+		t.Errorf("String() = %q", s)
+	}
+	if report.Abandoned {
+		t.Error("Abandoned = true, want false")
+	}
+}
+
+func TestPreStopDelay(t *testing.T) {
+	rec := &recorder{}
+	delay := 100 * time.Millisecond
+	api := &preStopComponent{serverComponent: newServer("api", rec, "db")}
+	components := []IComponent{
+		&fakeComponent{name: "db", rec: rec},
+		api,
+	}
+	a, err := New("test", components, WithPreStopDelay(delay))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := shutdown(t, a, run(a)); err != nil {
+		t.Fatalf("Run() = %v, want nil", err)
+	}
+	// 先通知 PreStop，等待 delay 后才开始停止组件
+	assertEvents(t, rec.get(), []string{"start db", "start api", "prestop api", "stop api", "stop db"})
+	if waited := api.stopAt.Sub(api.preStopAt); waited < delay {
+		t.Fatalf("api stopped %s after pre-stop, want at least %s", waited, delay)
+	}
+}
@@ -0,0 +1,215 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yanking/app-skeleton/pkg/log"
+)
+
+// ErrStartTimeout 组件未能在启动期限内就绪
+var ErrStartTimeout = errors.New("app: component not ready before start deadline")
+
+// IReadiness 是组件可选实现的接口.
+// 对于 Start 会一直阻塞直到退出的组件（如 gRPC server），App 通过 Ready 返回的 channel
+// 判断组件是否已经可以对外提供服务；未实现该接口的组件以 Start 返回作为启动完成的标志.
+type IReadiness interface {
+	Ready() <-chan struct{}
+}
+
+// State 组件的生命周期状态
+type State string
+
+const (
+	// StateCreated 组件尚未启动
+	StateCreated State = "created"
+	// StateStarting 组件正在启动，还未就绪
+	StateStarting State = "starting"
+	// StateRunning 组件已就绪并正在运行
+	StateRunning State = "running"
+	// StateExited 组件的 Start 在未被停止的情况下正常返回
+	StateExited State = "exited"
+	// StateFailed 组件的 Start 返回了错误
+	StateFailed State = "failed"
+	// StateRestarting 组件已退出，正在按重启策略等待重启
+	StateRestarting State = "restarting"
+	// StateStopping 组件正在停止
+	StateStopping State = "stopping"
+	// StateStopped 组件已停止
+	StateStopped State = "stopped"
+)
+
+// ComponentStatus 组件状态快照
+type ComponentStatus struct {
+	Name  string    `json:"name"`
+	Layer int       `json:"layer"`
+	State State     `json:"state"`
+	Error string    `json:"error,omitempty"`
+	Since time.Time `json:"since"`
+}
+
+// Ready 组件是否处于就绪状态
+func (s ComponentStatus) Ready() bool {
+	return s.State == StateRunning
+}
+
+// statusBoard 记录所有组件的状态，供 App 的就绪判断和外部查询使用
+type statusBoard struct {
+	mu       sync.RWMutex
+	order    []string
+	statuses map[string]*ComponentStatus
+}
+
+func newStatusBoard(layers [][]IComponent) *statusBoard {
+	b := &statusBoard{statuses: make(map[string]*ComponentStatus)}
+	now := time.Now()
+	for i, layer := range layers {
+		for _, c := range layer {
+			b.order = append(b.order, c.Name())
+			b.statuses[c.Name()] = &ComponentStatus{Name: c.Name(), Layer: i, State: StateCreated, Since: now}
+		}
+	}
+	return b
+}
+
+func (b *statusBoard) set(name string, state State, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.setLocked(name, state, err)
+}
+
+// transition 仅当组件处于 from 状态时才切换到 to 状态
+func (b *statusBoard) transition(name string, from, to State) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s, ok := b.statuses[name]
+	if !ok || s.State != from {
+		return false
+	}
+	b.setLocked(name, to, nil)
+	return true
+}
+
+// finish 记录组件 Start 返回后的状态，已进入停止流程的组件保持原状态
+func (b *statusBoard) finish(name string, state State, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s, ok := b.statuses[name]
+	if !ok || s.State == StateStopping || s.State == StateStopped {
+		return
+	}
+	b.setLocked(name, state, err)
+}
+
+func (b *statusBoard) setLocked(name string, state State, err error) {
+	s, ok := b.statuses[name]
+	if !ok {
+		return
+	}
+	s.State = state
+	s.Since = time.Now()
+	s.Error = ""
+	if err != nil {
+		s.Error = err.Error()
+	}
+}
+
+func (b *statusBoard) snapshot() []ComponentStatus {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	out := make([]ComponentStatus, 0, len(b.order))
+	for _, name := range b.order {
+		out = append(out, *b.statuses[name])
+	}
+	return out
+}
+
+func (b *statusBoard) allReady() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, s := range b.statuses {
+		if !s.Ready() {
+			return false
+		}
+	}
+	return true
+}
+
+// Status 返回所有组件的状态快照，按启动顺序排列
+func (a *App) Status() []ComponentStatus {
+	return a.status.snapshot()
+}
+
+// Ready 返回一个在所有组件都就绪后关闭的 channel
+func (a *App) Ready() <-chan struct{} {
+	return a.ready
+}
+
+// IsReady 判断 App 当前是否可以对外提供服务：所有组件都已就绪且没有进入停止流程
+func (a *App) IsReady() bool {
+	if a.stopping.Load() {
+		return false
+	}
+	return a.status.allReady()
+}
+
+// CheckReady 以 error 的形式返回 App 的就绪状态，可以作为 readiness 检查项注册
+func (a *App) CheckReady(ctx context.Context) error {
+	if a.stopping.Load() {
+		return errors.New("application is stopping")
+	}
+	var notReady []string
+	for _, s := range a.status.snapshot() {
+		if !s.Ready() {
+			notReady = append(notReady, fmt.Sprintf("%s is %s", s.Name, s.State))
+		}
+	}
+	if len(notReady) > 0 {
+		return errors.New(strings.Join(notReady, ", "))
+	}
+	return nil
+}
+
+// CheckStarted 在所有组件首次就绪之前返回错误，可以作为 startup 检查项注册
+func (a *App) CheckStarted(ctx context.Context) error {
+	select {
+	case <-a.ready:
+		return nil
+	default:
+		return errors.New("application is starting")
+	}
+}
+
+// awaitReady 等待组件就绪，未实现 IReadiness 的组件以 Start 返回作为就绪标志
+func (a *App) awaitReady(ctx, deadline context.Context, c IComponent, att *startAttempt) error {
+	select {
+	case <-att.ready:
+		if a.status.transition(c.Name(), StateStarting, StateRunning) {
+			log.Infof("app: component %s is ready", c.Name())
+		}
+		return nil
+	case <-att.exited:
+		if att.err != nil {
+			return att.err
+		}
+		if att.ready == nil {
+			// Start 返回时已被标记为 running
+			log.Infof("app: component %s is ready", c.Name())
+			return nil
+		}
+		select {
+		case <-att.ready:
+		default:
+			log.Warnf("app: component %s exited before becoming ready", c.Name())
+		}
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	case <-deadline.Done():
+		return fmt.Errorf("%w: %s did not become ready within %s", ErrStartTimeout, c.Name(), a.startTimeout)
+	}
+}
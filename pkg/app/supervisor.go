@@ -0,0 +1,185 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yanking/app-skeleton/pkg/log"
+	"github.com/yanking/app-skeleton/pkg/metric"
+)
+
+// RestartMode 组件的 Start 返回后的处理方式
+type RestartMode string
+
+const (
+	// RestartNever 不重启，Start 返回错误时停止整个 App（默认）
+	RestartNever RestartMode = "never"
+	// RestartOnFailure Start 返回错误时重启，正常返回时不再重启
+	RestartOnFailure RestartMode = "on-failure"
+	// RestartAlways 无论 Start 如何返回都重启
+	RestartAlways RestartMode = "always"
+)
+
+// ErrRestartBudgetExhausted 组件的重启次数超过了重启策略的限制
+var ErrRestartBudgetExhausted = errors.New("app: restart budget exhausted")
+
+const (
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = 30 * time.Second
+	defaultMultiplier     = 2.0
+)
+
+var (
+	metricComponentCrashes = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: "app",
+		Subsystem: "component",
+		Name:      "crashes_total",
+		Help:      "app component crash count.",
+		Labels:    []string{"component"},
+	})
+
+	metricComponentRestarts = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: "app",
+		Subsystem: "component",
+		Name:      "restarts_total",
+		Help:      "app component restart count.",
+		Labels:    []string{"component"},
+	})
+)
+
+// RestartPolicy 组件的重启策略.
+// 重启之间按指数退避等待；连续重启次数超过 MaxRetries 后放弃重启，并以最后一次的错误停止整个 App.
+type RestartPolicy struct {
+	Mode RestartMode
+	// MaxRetries 连续重启的最大次数，为 0 时不限制
+	MaxRetries int
+	// InitialBackoff 第一次重启前的等待时间，默认 1s
+	InitialBackoff time.Duration
+	// MaxBackoff 退避等待的上限，默认 30s
+	MaxBackoff time.Duration
+	// Multiplier 每次重启后退避时间的倍数，默认 2
+	Multiplier float64
+	// ResetAfter 组件持续运行超过该时间后视为恢复健康，重置重启计数和退避时间；为 0 时不重置
+	ResetAfter time.Duration
+}
+
+func (p RestartPolicy) withDefaults() RestartPolicy {
+	if p.Mode == "" {
+		p.Mode = RestartNever
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = defaultInitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaultMaxBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = defaultMultiplier
+	}
+	return p
+}
+
+// supervised 组件是否配置了重启策略
+func (a *App) supervised(name string) bool {
+	p, ok := a.restartPolicies[name]
+	return ok && p.Mode != RestartNever
+}
+
+// supervise 运行组件的 Start，并按重启策略在其退出后重启. 返回值为组件最终退出的原因.
+func (a *App) supervise(ctx context.Context, c IComponent, att *startAttempt) error {
+	name := c.Name()
+	policy := a.restartPolicies[name].withDefaults()
+	backoff := policy.InitialBackoff
+	retries := 0
+
+	for restarted := false; ; restarted = true {
+		log.Infof("app: starting %s", name)
+		began := time.Now()
+		runDone := make(chan struct{})
+		if !restarted && att.started != nil {
+			close(att.started)
+		}
+		if restarted {
+			// 重启后重新等待组件就绪，组件可以在 Ready 中返回新的 channel
+			ready := att.ready
+			if r, ok := c.(IReadiness); ok {
+				ready = r.Ready()
+			}
+			go func() {
+				select {
+				case <-ready:
+					if a.status.transition(name, StateStarting, StateRunning) {
+						log.Infof("app: component %s is ready again", name)
+					}
+				case <-runDone:
+				}
+			}()
+		}
+
+		err := c.Start(ctx)
+		close(runDone)
+		if err != nil {
+			err = fmt.Errorf("start %s: %w", name, err)
+		}
+
+		// 进入停止流程后组件的退出都是预期内的，不再重启
+		if a.stopping.Load() || ctx.Err() != nil {
+			a.status.finish(name, StateExited, err)
+			return err
+		}
+
+		if err != nil {
+			metricComponentCrashes.Inc(name)
+		}
+
+		if policy.Mode == RestartNever || (policy.Mode == RestartOnFailure && err == nil) {
+			switch {
+			case err != nil:
+				a.status.finish(name, StateFailed, err)
+				log.Errorf("app: failed to start %s: %v", name, err)
+			case att.ready == nil:
+				// 未实现 IReadiness 的组件，Start 返回即视为启动完成
+				a.status.finish(name, StateRunning, nil)
+			default:
+				a.status.finish(name, StateExited, nil)
+			}
+			return err
+		}
+
+		if err == nil {
+			err = fmt.Errorf("component %s exited", name)
+		}
+
+		if policy.ResetAfter > 0 && time.Since(began) >= policy.ResetAfter {
+			retries = 0
+			backoff = policy.InitialBackoff
+		}
+		if policy.MaxRetries > 0 && retries >= policy.MaxRetries {
+			err = fmt.Errorf("%w: %s gave up after %d restart(s): %w", ErrRestartBudgetExhausted, name, retries, err)
+			a.status.finish(name, StateFailed, err)
+			log.Errorf("app: %v", err)
+			return err
+		}
+
+		a.status.finish(name, StateRestarting, err)
+		log.Warnf("app: component %s exited (%v), restarting in %s (retry %d)", name, err, backoff, retries+1)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-a.stopCh:
+			timer.Stop()
+			return nil
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		}
+
+		retries++
+		backoff = min(time.Duration(float64(backoff)*policy.Multiplier), policy.MaxBackoff)
+		metricComponentRestarts.Inc(name)
+		a.status.transition(name, StateRestarting, StateStarting)
+	}
+}
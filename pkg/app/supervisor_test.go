@@ -0,0 +1,213 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errCrash = errors.New("crash")
+
+// crashComponent 前 failures 次 Start 返回 exit，之后一直运行到 Stop
+type crashComponent struct {
+	name     string
+	failures int
+	// exit 为 Start 提前返回时的结果，为 nil 表示正常退出
+	exit error
+
+	mu      sync.Mutex
+	starts  []time.Time
+	stopped chan struct{}
+	once    sync.Once
+}
+
+func newCrashComponent(name string, failures int, exit error) *crashComponent {
+	return &crashComponent{name: name, failures: failures, exit: exit, stopped: make(chan struct{})}
+}
+
+func (c *crashComponent) Name() string { return c.name }
+
+func (c *crashComponent) Start(ctx context.Context) error {
+	c.mu.Lock()
+	c.starts = append(c.starts, time.Now())
+	n := len(c.starts)
+	c.mu.Unlock()
+	if n <= c.failures {
+		return c.exit
+	}
+	select {
+	case <-c.stopped:
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+func (c *crashComponent) Stop(ctx context.Context) error {
+	c.once.Do(func() { close(c.stopped) })
+	return nil
+}
+
+// startTimes 返回每次调用 Start 的时间
+func (c *crashComponent) startTimes() []time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Time{}, c.starts...)
+}
+
+func TestRestartPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RestartPolicy
+		// failures 和 exit 为组件提前退出的次数和结果
+		failures   int
+		exit       error
+		wantStarts int
+	}{
+		{
+			name:       "on-failure restarts after errors",
+			policy:     RestartPolicy{Mode: RestartOnFailure, InitialBackoff: time.Millisecond},
+			failures:   2,
+			exit:       errCrash,
+			wantStarts: 3,
+		},
+		{
+			name:       "on-failure does not restart after a clean exit",
+			policy:     RestartPolicy{Mode: RestartOnFailure, InitialBackoff: time.Millisecond},
+			failures:   1,
+			wantStarts: 1,
+		},
+		{
+			name:       "always restarts after a clean exit",
+			policy:     RestartPolicy{Mode: RestartAlways, InitialBackoff: time.Millisecond},
+			failures:   2,
+			wantStarts: 3,
+		},
+		{
+			name:       "retries within the budget",
+			policy:     RestartPolicy{Mode: RestartAlways, MaxRetries: 2, InitialBackoff: time.Millisecond},
+			failures:   2,
+			exit:       errCrash,
+			wantStarts: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newCrashComponent("worker", tt.failures, tt.exit)
+			a, err := New("test", []IComponent{c}, WithRestartPolicy("worker", tt.policy))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			errCh := run(a)
+			waitFor(t, "the worker to settle", func() bool {
+				state := states(a)["worker"]
+				return len(c.startTimes()) >= tt.wantStarts && (state == StateRunning || state == StateExited)
+			})
+			if err := shutdown(t, a, errCh); err != nil {
+				t.Fatalf("Run() = %v, want nil", err)
+			}
+			if got := len(c.startTimes()); got != tt.wantStarts {
+				t.Fatalf("Start called %d time(s), want %d", got, tt.wantStarts)
+			}
+		})
+	}
+}
+
+func TestRestartBackoff(t *testing.T) {
+	c := newCrashComponent("worker", 3, errCrash)
+	// 不受 MaxBackoff 限制时第二次退避为 20s，waitFor 会超时
+	policy := RestartPolicy{
+		Mode:           RestartOnFailure,
+		InitialBackoff: 20 * time.Millisecond,
+		Multiplier:     1000,
+		MaxBackoff:     40 * time.Millisecond,
+	}
+	a, err := New("test", []IComponent{c}, WithRestartPolicy("worker", policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errCh := run(a)
+	waitFor(t, "the worker to recover", func() bool { return len(c.startTimes()) == 4 })
+	if err := shutdown(t, a, errCh); err != nil {
+		t.Fatalf("Run() = %v, want nil", err)
+	}
+
+	starts := c.startTimes()
+	for i, want := range []time.Duration{20 * time.Millisecond, 40 * time.Millisecond, 40 * time.Millisecond} {
+		if got := starts[i+1].Sub(starts[i]); got < want {
+			t.Errorf("backoff before restart %d = %s, want at least %s", i+1, got, want)
+		}
+	}
+}
+
+func TestRestartBudgetExhausted(t *testing.T) {
+	c := newCrashComponent("worker", 10, errCrash)
+	policy := RestartPolicy{Mode: RestartOnFailure, MaxRetries: 2, InitialBackoff: time.Millisecond}
+	a, err := New("test", []IComponent{c}, WithRestartPolicy("worker", policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = wait(t, run(a))
+	if !errors.Is(err, ErrRestartBudgetExhausted) || !errors.Is(err, errCrash) {
+		t.Fatalf("Run() = %v, want %v wrapping %v", err, ErrRestartBudgetExhausted, errCrash)
+	}
+	// 第一次启动加上 2 次重启
+	if got := len(c.startTimes()); got != 3 {
+		t.Fatalf("Start called %d time(s), want 3", got)
+	}
+	report := a.ShutdownReport()
+	if report == nil || !strings.Contains(report.Cause, "gave up after 2 restart(s)") {
+		t.Fatalf("ShutdownReport() = %+v, want the exhausted budget as the cause", report)
+	}
+}
+
+func TestCrashWithoutRestartPolicyStopsTheApp(t *testing.T) {
+	c := newCrashComponent("worker", 1, errCrash)
+	api := newServer("api", &recorder{}, "worker")
+	a, err := New("test", []IComponent{c, api})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wait(t, run(a)); !errors.Is(err, errCrash) {
+		t.Fatalf("Run() = %v, want %v", err, errCrash)
+	}
+	if got := len(c.startTimes()); got != 1 {
+		t.Fatalf("Start called %d time(s), want 1", got)
+	}
+	if got := states(a)["api"]; got != StateCreated {
+		t.Fatalf("api is %s, want %s", got, StateCreated)
+	}
+}
+
+func TestShutdownDuringRestartBackoff(t *testing.T) {
+	c := newCrashComponent("worker", 1, errCrash)
+	policy := RestartPolicy{Mode: RestartOnFailure, InitialBackoff: time.Hour}
+	a, err := New("test", []IComponent{c}, WithRestartPolicy("worker", policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errCh := run(a)
+	waitFor(t, "the worker to wait for a restart", func() bool { return states(a)["worker"] == StateRestarting })
+	// 停止流程不等待退避结束
+	if err := shutdown(t, a, errCh); err != nil {
+		t.Fatalf("Run() = %v, want nil", err)
+	}
+	if got := len(c.startTimes()); got != 1 {
+		t.Fatalf("Start called %d time(s), want 1", got)
+	}
+}
+
+func TestNewRejectsRestartPolicyForUnknownComponent(t *testing.T) {
+	components := []IComponent{newCrashComponent("worker", 0, nil)}
+	_, err := New("test", components, WithRestartPolicy("workr", RestartPolicy{Mode: RestartAlways}))
+	if err == nil || !strings.Contains(err.Error(), `unknown component "workr"`) {
+		t.Fatalf("New() error = %v, want unknown component", err)
+	}
+}
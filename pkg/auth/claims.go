@@ -0,0 +1,49 @@
+// Package auth 提供基于 JWT 的身份认证：签发 token、校验 token（HS256 以及通过 JWKS 文件配置公钥的 RS256/ES256），
+// 并在 context 中传递校验后的 Claims.
+package auth
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims token 中携带的身份信息
+type Claims struct {
+	jwt.RegisteredClaims
+
+	// Roles 调用方拥有的角色
+	Roles []string `json:"roles,omitempty"`
+	// Scope 调用方被授予的权限范围，多个 scope 以空格分隔，与 OAuth 2.0 一致
+	Scope string `json:"scope,omitempty"`
+}
+
+// Scopes 返回拆分后的 scope 列表
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// HasRole 是否拥有指定角色
+func (c *Claims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
+
+// HasScope 是否被授予指定的 scope
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes(), scope)
+}
+
+type claimsKey struct{}
+
+// NewContext 将 Claims 放入 context
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// FromContext 从 context 中取出 Claims，未认证的请求返回 false
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok && claims != nil
+}
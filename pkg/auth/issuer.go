@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+type IssuerOption func(i *Issuer)
+
+// Issuer 使用 HS256 签发 token
+type Issuer struct {
+	key        []byte
+	expiration time.Duration
+	issuer     string
+	audience   []string
+	now        func() time.Time
+}
+
+// WithIssuerName 设置 token 的 iss
+func WithIssuerName(name string) IssuerOption {
+	return func(i *Issuer) {
+		i.issuer = name
+	}
+}
+
+// WithIssuerAudience 设置 token 的 aud
+func WithIssuerAudience(audience ...string) IssuerOption {
+	return func(i *Issuer) {
+		i.audience = audience
+	}
+}
+
+// NewIssuer 创建 token 签发器，key 为 HS256 的密钥，expiration 为 token 的有效期
+func NewIssuer(key string, expiration time.Duration, opts ...IssuerOption) (*Issuer, error) {
+	if key == "" {
+		return nil, errors.New("auth: signing key must not be empty")
+	}
+	if expiration <= 0 {
+		return nil, errors.New("auth: token expiration must be positive")
+	}
+
+	i := &Issuer{key: []byte(key), expiration: expiration, now: time.Now}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i, nil
+}
+
+// Issue 为 subject 签发 token
+func (i *Issuer) Issue(subject string, roles []string, scopes ...string) (string, error) {
+	now := i.now()
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   subject,
+			Issuer:    i.issuer,
+			Audience:  i.audience,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(i.expiration)),
+		},
+		Roles: roles,
+		Scope: strings.Join(scopes, " "),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(i.key)
+}
+
+// TokenSource 返回为 subject 签发 token 的函数，可以用作客户端的 TokenSource.
+// token 在有效期过半之前重复使用，之后重新签发.
+func (i *Issuer) TokenSource(subject string, roles []string, scopes ...string) func(ctx context.Context) (string, error) {
+	var (
+		mu      sync.Mutex
+		token   string
+		renewAt time.Time
+	)
+	return func(ctx context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if now := i.now(); token == "" || !now.Before(renewAt) {
+			t, err := i.Issue(subject, roles, scopes...)
+			if err != nil {
+				return "", err
+			}
+			token, renewAt = t, now.Add(i.expiration/2)
+		}
+		return token, nil
+	}
+}
@@ -0,0 +1,228 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signingKey 测试用的私钥及其 kid
+type signingKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.Signer
+}
+
+func newRSAKey(t *testing.T, kid string) signingKey {
+	t.Helper()
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signingKey{kid: kid, method: jwt.SigningMethodRS256, private: k}
+}
+
+func newECKey(t *testing.T, kid string) signingKey {
+	t.Helper()
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signingKey{kid: kid, method: jwt.SigningMethodES256, private: k}
+}
+
+// sign 签发头部带有 kid 的 token
+func (k signingKey) sign(t *testing.T, claims jwt.Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(k.method, claims)
+	token.Header["kid"] = k.kid
+	s, err := token.SignedString(k.private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// jwk 返回公钥对应的 JWK
+func (k signingKey) jwk() jwk {
+	b64 := func(i *big.Int, size int) string {
+		return base64.RawURLEncoding.EncodeToString(i.FillBytes(make([]byte, size)))
+	}
+	switch pub := k.private.Public().(type) {
+	case *rsa.PublicKey:
+		e := big.NewInt(int64(pub.E))
+		return jwk{Kty: "RSA", Kid: k.kid, Use: "sig", N: b64(pub.N, pub.Size()), E: b64(e, len(e.Bytes()))}
+	case *ecdsa.PublicKey:
+		return jwk{Kty: "EC", Kid: k.kid, Crv: "P-256", X: b64(pub.X, 32), Y: b64(pub.Y, 32)}
+	}
+	panic("unsupported key")
+}
+
+// writeJWKS 将公钥写入 JWKS 文件
+func writeJWKS(t *testing.T, path string, keys ...signingKey) {
+	t.Helper()
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{}
+	for _, k := range keys {
+		set.Keys = append(set.Keys, k.jwk())
+	}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, path, data)
+}
+
+// writeFile 写入文件，文件已存在时将修改时间推后一秒，使更新总能被发现
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	var modTime time.Time
+	if info, err := os.Stat(path); err == nil {
+		modTime = info.ModTime().Add(time.Second)
+	} else {
+		modTime = time.Now()
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyWithJWKS(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	rsaKey, ecKey := newRSAKey(t, "rsa-1"), newECKey(t, "ec-1")
+	writeJWKS(t, path, rsaKey, ecKey)
+	v, err := NewVerifier(WithJWKSFile(path), WithExpectedAudience("demo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, k := range []signingKey{rsaKey, ecKey} {
+		claims, err := v.Verify(k.sign(t, validClaims()))
+		if err != nil {
+			t.Fatalf("%s: Verify() = %v", k.kid, err)
+		}
+		if claims.Subject != "alice" {
+			t.Fatalf("%s: subject = %q, want alice", k.kid, claims.Subject)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{
+			name:    "unknown kid",
+			token:   newRSAKey(t, "rsa-2").sign(t, validClaims()),
+			wantErr: `unknown key id "rsa-2"`,
+		},
+		{
+			name:    "kid of another key",
+			token:   signingKey{kid: "rsa-1", method: jwt.SigningMethodES256, private: ecKey.private}.sign(t, validClaims()),
+			wantErr: "key is of invalid type",
+		},
+		{
+			// 使用公钥作为 HMAC 密钥签名的 token，verifier 没有配置 HS256
+			name: "hmac with the public key",
+			token: func() string {
+				pub, _ := json.Marshal(rsaKey.jwk())
+				return sign(t, jwt.SigningMethodHS256, validClaims(), pub)
+			}(),
+			wantErr: "signing method HS256 is invalid",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := v.Verify(tt.token); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJWKSRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	oldKey, newKey := newRSAKey(t, "2024"), newECKey(t, "2025")
+	writeJWKS(t, path, oldKey)
+	v, err := NewVerifier(WithJWKSFile(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newToken := newKey.sign(t, validClaims())
+
+	// 发布新公钥后，在重新读取的间隔内仍使用已加载的公钥
+	writeJWKS(t, path, oldKey, newKey)
+	if _, err := v.Verify(newToken); err == nil {
+		t.Fatal("Verify() reloaded the jwks within the reload interval")
+	}
+
+	v.jwks.lastCheck = time.Time{}
+	if _, err := v.Verify(newToken); err != nil {
+		t.Fatalf("Verify() after rotation = %v", err)
+	}
+	if _, err := v.Verify(oldKey.sign(t, validClaims())); err != nil {
+		t.Fatalf("Verify() with the old key during rotation = %v", err)
+	}
+
+	// 文件损坏时保留已加载的公钥
+	writeFile(t, path, []byte("{not json"))
+	v.jwks.lastCheck = time.Time{}
+	if _, err := v.Verify(newRSAKey(t, "2026").sign(t, validClaims())); err == nil {
+		t.Fatal("Verify() accepted a token with an unknown kid")
+	}
+	if _, err := v.Verify(newToken); err != nil {
+		t.Fatalf("Verify() after a failed reload = %v", err)
+	}
+
+	// 旧公钥撤销后不再接受其签发的 token
+	writeJWKS(t, path, newKey)
+	v.jwks.lastCheck = time.Time{}
+	if _, err := v.Verify(oldKey.sign(t, validClaims())); err == nil {
+		t.Fatal("Verify() accepted a token signed by a revoked key")
+	}
+	if _, err := v.Verify(newToken); err != nil {
+		t.Fatalf("Verify() after revoking the old key = %v", err)
+	}
+}
+
+func TestLoadJWKSErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"malformed", "{", "parse jwks"},
+		{"no signing keys", `{"keys":[{"kty":"RSA","kid":"enc","use":"enc"}]}`, "no signing keys"},
+		{"unsupported key type", `{"keys":[{"kty":"oct","kid":"k"}]}`, `unsupported key type "oct"`},
+		{"point not on curve", `{"keys":[{"kty":"EC","kid":"k","crv":"P-256","x":"AQ","y":"AQ"}]}`, "not on curve"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.ReplaceAll(tt.name, " ", "-")+".json")
+			writeFile(t, path, []byte(tt.content))
+			if _, err := NewVerifier(WithJWKSFile(path)); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("NewVerifier() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+	if _, err := NewVerifier(WithJWKSFile(filepath.Join(dir, "missing.json"))); err == nil {
+		t.Fatal("NewVerifier() with a missing jwks file succeeded")
+	}
+}
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type VerifierOption func(v *Verifier)
+
+// Verifier 校验 token 并返回其中的 Claims
+type Verifier struct {
+	hmacKey  []byte
+	jwksFile string
+	jwks     *jwks
+	issuer   string
+	audience string
+	leeway   time.Duration
+	parser   *jwt.Parser
+}
+
+// WithHMACKey 使用 HS256 密钥校验 token
+func WithHMACKey(key string) VerifierOption {
+	return func(v *Verifier) {
+		v.hmacKey = []byte(key)
+	}
+}
+
+// WithJWKSFile 使用本地 JWKS 文件中的公钥校验 RS256/ES256 token，按 token 头部的 kid 选择公钥
+func WithJWKSFile(path string) VerifierOption {
+	return func(v *Verifier) {
+		v.jwksFile = path
+	}
+}
+
+// WithExpectedIssuer 要求 token 的 iss 与之相同
+func WithExpectedIssuer(issuer string) VerifierOption {
+	return func(v *Verifier) {
+		v.issuer = issuer
+	}
+}
+
+// WithExpectedAudience 要求 token 的 aud 包含 audience
+func WithExpectedAudience(audience string) VerifierOption {
+	return func(v *Verifier) {
+		v.audience = audience
+	}
+}
+
+// WithLeeway 校验 exp、nbf 时允许的时钟误差
+func WithLeeway(leeway time.Duration) VerifierOption {
+	return func(v *Verifier) {
+		v.leeway = leeway
+	}
+}
+
+// NewVerifier 创建 token 校验器，至少需要配置 HS256 密钥和 JWKS 文件中的一个
+func NewVerifier(opts ...VerifierOption) (*Verifier, error) {
+	v := &Verifier{}
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	var methods []string
+	if len(v.hmacKey) > 0 {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if v.jwksFile != "" {
+		keys, err := loadJWKS(v.jwksFile)
+		if err != nil {
+			return nil, err
+		}
+		v.jwks = keys
+		methods = append(methods, jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg())
+	}
+	if len(methods) == 0 {
+		return nil, errors.New("auth: verifier needs an hmac key or a jwks file")
+	}
+
+	parserOpts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(v.leeway),
+	}
+	if v.issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(v.issuer))
+	}
+	if v.audience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(v.audience))
+	}
+	v.parser = jwt.NewParser(parserOpts...)
+	return v, nil
+}
+
+// Verify 校验 token 的签名和有效期，返回其中的 Claims
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	claims := &Claims{}
+	if _, err := v.parser.ParseWithClaims(token, claims, v.keyFunc); err != nil {
+		return nil, fmt.Errorf("auth: invalid token: %w", err)
+	}
+	return claims, nil
+}
+
+func (v *Verifier) keyFunc(t *jwt.Token) (interface{}, error) {
+	switch t.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		return v.hmacKey, nil
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		kid, _ := t.Header["kid"].(string)
+		return v.jwks.key(kid)
+	default:
+		return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+	}
+}
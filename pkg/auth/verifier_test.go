@@ -0,0 +1,229 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testKey = "test-signing-key"
+
+// sign 使用 method 和 key 签发 claims
+func sign(t *testing.T, method jwt.SigningMethod, claims jwt.Claims, key any) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// validClaims 返回可以通过校验的 claims
+func validClaims() *Claims {
+	now := time.Now()
+	return &Claims{RegisteredClaims: jwt.RegisteredClaims{
+		Subject:   "alice",
+		Issuer:    "app-skeleton",
+		Audience:  jwt.ClaimStrings{"demo"},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+	}}
+}
+
+func newTestVerifier(t *testing.T, opts ...VerifierOption) *Verifier {
+	t.Helper()
+	opts = append([]VerifierOption{
+		WithHMACKey(testKey),
+		WithExpectedIssuer("app-skeleton"),
+		WithExpectedAudience("demo"),
+	}, opts...)
+	v, err := NewVerifier(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestIssueAndVerify(t *testing.T) {
+	issuer, err := NewIssuer(testKey, time.Hour, WithIssuerName("app-skeleton"), WithIssuerAudience("demo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := issuer.Issue("alice", []string{"admin"}, "users:read", "users:write")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := newTestVerifier(t).Verify(token)
+	if err != nil {
+		t.Fatalf("Verify() = %v", err)
+	}
+	if claims.Subject != "alice" || claims.ID == "" {
+		t.Errorf("claims = %+v, want subject alice with an id", claims.RegisteredClaims)
+	}
+	if !claims.HasRole("admin") || claims.HasRole("viewer") {
+		t.Errorf("roles = %v, want [admin]", claims.Roles)
+	}
+	if !slices.Equal(claims.Scopes(), []string{"users:read", "users:write"}) || !claims.HasScope("users:write") {
+		t.Errorf("scopes = %v, want [users:read users:write]", claims.Scopes())
+	}
+	if got := claims.ExpiresAt.Sub(claims.IssuedAt.Time); got != time.Hour {
+		t.Errorf("token lifetime = %s, want 1h", got)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		token func(t *testing.T) string
+		// wantErr 错误中应包含的内容
+		wantErr string
+	}{
+		{
+			name: "wrong key",
+			token: func(t *testing.T) string {
+				return sign(t, jwt.SigningMethodHS256, validClaims(), []byte("another-key"))
+			},
+			wantErr: "signature is invalid",
+		},
+		{
+			name: "alg none",
+			token: func(t *testing.T) string {
+				return sign(t, jwt.SigningMethodNone, validClaims(), jwt.UnsafeAllowNoneSignatureType)
+			},
+			wantErr: "signing method none is invalid",
+		},
+		{
+			name: "alg not allowed",
+			token: func(t *testing.T) string {
+				return sign(t, jwt.SigningMethodHS512, validClaims(), []byte(testKey))
+			},
+			wantErr: "signing method HS512 is invalid",
+		},
+		{
+			name: "expired",
+			token: func(t *testing.T) string {
+				c := validClaims()
+				c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+				return sign(t, jwt.SigningMethodHS256, c, []byte(testKey))
+			},
+			wantErr: "token is expired",
+		},
+		{
+			name: "missing exp",
+			token: func(t *testing.T) string {
+				c := validClaims()
+				c.ExpiresAt = nil
+				return sign(t, jwt.SigningMethodHS256, c, []byte(testKey))
+			},
+			wantErr: "exp claim is required",
+		},
+		{
+			name: "not yet valid",
+			token: func(t *testing.T) string {
+				c := validClaims()
+				c.NotBefore = jwt.NewNumericDate(time.Now().Add(time.Minute))
+				return sign(t, jwt.SigningMethodHS256, c, []byte(testKey))
+			},
+			wantErr: "token is not valid yet",
+		},
+		{
+			name: "wrong audience",
+			token: func(t *testing.T) string {
+				c := validClaims()
+				c.Audience = jwt.ClaimStrings{"billing"}
+				return sign(t, jwt.SigningMethodHS256, c, []byte(testKey))
+			},
+			wantErr: "token has invalid audience",
+		},
+		{
+			name: "wrong issuer",
+			token: func(t *testing.T) string {
+				c := validClaims()
+				c.Issuer = "someone-else"
+				return sign(t, jwt.SigningMethodHS256, c, []byte(testKey))
+			},
+			wantErr: "token has invalid issuer",
+		},
+		{
+			name:    "malformed",
+			token:   func(t *testing.T) string { return "not-a-jwt" },
+			wantErr: "token is malformed",
+		},
+	}
+	v := newTestVerifier(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := v.Verify(tt.token(t))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Verify() = %+v, %v, want error containing %q", claims, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyLeeway(t *testing.T) {
+	c := validClaims()
+	c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-10 * time.Second))
+	token := sign(t, jwt.SigningMethodHS256, c, []byte(testKey))
+
+	if _, err := newTestVerifier(t).Verify(token); err == nil {
+		t.Fatal("Verify() accepted an expired token without leeway")
+	}
+	if _, err := newTestVerifier(t, WithLeeway(time.Minute)).Verify(token); err != nil {
+		t.Fatalf("Verify() with leeway = %v", err)
+	}
+}
+
+func TestNewVerifierRequiresKey(t *testing.T) {
+	if _, err := NewVerifier(WithExpectedIssuer("app-skeleton")); err == nil {
+		t.Fatal("NewVerifier() without keys succeeded")
+	}
+}
+
+func TestNewIssuerValidates(t *testing.T) {
+	if _, err := NewIssuer("", time.Hour); err == nil {
+		t.Error("NewIssuer() with an empty key succeeded")
+	}
+	if _, err := NewIssuer(testKey, 0); err == nil {
+		t.Error("NewIssuer() with zero expiration succeeded")
+	}
+}
+
+func TestIssuerTokenSource(t *testing.T) {
+	issuer, err := NewIssuer(testKey, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 最后一次签发的时间为当前时间，token 可以通过校验
+	now := time.Now().Add(-30 * time.Minute)
+	issuer.now = func() time.Time { return now }
+	source := issuer.TokenSource("worker", nil, "users:read")
+
+	first, err := source(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 有效期过半之前重复使用同一个 token
+	now = now.Add(29 * time.Minute)
+	if second, _ := source(context.Background()); second != first {
+		t.Fatal("token renewed before half of its lifetime")
+	}
+	now = now.Add(time.Minute)
+	third, _ := source(context.Background())
+	if third == first {
+		t.Fatal("token not renewed after half of its lifetime")
+	}
+
+	claims, err := newTestVerifier(t, WithExpectedIssuer(""), WithExpectedAudience("")).Verify(third)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "worker" || !claims.HasScope("users:read") {
+		t.Fatalf("claims = %+v", claims)
+	}
+}
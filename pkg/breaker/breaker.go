@@ -0,0 +1,213 @@
+// Package breaker 实现了经典的三态熔断器：
+// 关闭（closed）时统计最近一段时间的失败率，超过阈值后打开（open），打开期间直接拒绝请求；
+// 经过 OpenTimeout 后进入半开（half-open），放行少量探测请求，全部成功则关闭，任一失败则重新打开.
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen 熔断器处于打开状态，或半开状态下探测请求已满
+var ErrOpen = errors.New("breaker: circuit is open")
+
+// State 熔断器状态
+type State int
+
+const (
+	StateClosed State = iota
+	StateHalfOpen
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// buckets 统计窗口被划分的桶数
+const buckets = 10
+
+// Options 熔断器配置
+type Options struct {
+	// Window 统计失败率的时间窗口，默认 10s
+	Window time.Duration `json:"window" mapstructure:"window"`
+	// MinRequests 窗口内请求数达到该值后才会根据失败率打开熔断器，默认 20
+	MinRequests int `json:"min-requests" mapstructure:"min-requests"`
+	// FailureRatio 打开熔断器的失败率，默认 0.5
+	FailureRatio float64 `json:"failure-ratio" mapstructure:"failure-ratio"`
+	// OpenTimeout 打开后经过多久进入半开状态，默认 5s
+	OpenTimeout time.Duration `json:"open-timeout" mapstructure:"open-timeout"`
+	// HalfOpenRequests 半开状态下放行的探测请求数，全部成功后关闭熔断器，默认 1
+	HalfOpenRequests int `json:"half-open-requests" mapstructure:"half-open-requests"`
+}
+
+func (o Options) withDefaults() Options {
+	if o.Window <= 0 {
+		o.Window = 10 * time.Second
+	}
+	if o.MinRequests <= 0 {
+		o.MinRequests = 20
+	}
+	if o.FailureRatio <= 0 || o.FailureRatio > 1 {
+		o.FailureRatio = 0.5
+	}
+	if o.OpenTimeout <= 0 {
+		o.OpenTimeout = 5 * time.Second
+	}
+	if o.HalfOpenRequests <= 0 {
+		o.HalfOpenRequests = 1
+	}
+	return o
+}
+
+// StateChangeFunc 状态变化时的回调，在持有熔断器锁时调用，不应阻塞
+type StateChangeFunc func(name string, from, to State)
+
+type bucket struct {
+	index           int64
+	total, failures int
+}
+
+// Breaker 三态熔断器
+type Breaker struct {
+	name     string
+	opts     Options
+	onChange StateChangeFunc
+	now      func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	openedAt time.Time
+	buckets  [buckets]bucket
+	// 半开状态下已放行和已成功的探测请求数
+	probes, successes int
+}
+
+// New 创建熔断器，name 用于日志和指标
+func New(name string, opts Options, onChange StateChangeFunc) *Breaker {
+	return &Breaker{name: name, opts: opts.withDefaults(), onChange: onChange, now: time.Now}
+}
+
+// Name 返回熔断器的名称
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State 返回熔断器当前的状态
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expire(b.now())
+	return b.state
+}
+
+// Allow 判断请求是否可以通过. 通过时返回的 done 必须在请求结束后调用一次，参数表示请求是否成功.
+func (b *Breaker) Allow() (done func(success bool), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expire(b.now())
+	switch b.state {
+	case StateOpen:
+		return nil, ErrOpen
+	case StateHalfOpen:
+		if b.probes >= b.opts.HalfOpenRequests {
+			return nil, ErrOpen
+		}
+		b.probes++
+	}
+
+	generation := b.openedAt
+	var once sync.Once
+	return func(success bool) {
+		once.Do(func() { b.done(generation, success) })
+	}, nil
+}
+
+// done 记录请求结果. 请求开始后熔断器已经切换过状态时，结果不再有意义
+func (b *Breaker) done(generation time.Time, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.expire(now)
+	if !b.openedAt.Equal(generation) {
+		return
+	}
+
+	switch b.state {
+	case StateClosed:
+		bk := b.bucket(now)
+		bk.total++
+		if !success {
+			bk.failures++
+		}
+		total, failures := b.counts(now)
+		if total >= b.opts.MinRequests && float64(failures) >= b.opts.FailureRatio*float64(total) {
+			b.setState(StateOpen, now)
+		}
+	case StateHalfOpen:
+		if !success {
+			b.setState(StateOpen, now)
+			return
+		}
+		b.successes++
+		if b.successes >= b.opts.HalfOpenRequests {
+			b.setState(StateClosed, now)
+		}
+	}
+}
+
+// expire 打开时间超过 OpenTimeout 后进入半开状态
+func (b *Breaker) expire(now time.Time) {
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.opts.OpenTimeout {
+		b.setState(StateHalfOpen, now)
+	}
+}
+
+func (b *Breaker) setState(state State, now time.Time) {
+	from := b.state
+	b.state = state
+	b.probes, b.successes = 0, 0
+	switch state {
+	case StateOpen:
+		b.openedAt = now
+	case StateClosed:
+		b.buckets = [buckets]bucket{}
+	}
+	if b.onChange != nil && from != state {
+		b.onChange(b.name, from, state)
+	}
+}
+
+// bucket 返回当前时间对应的桶，复用已经过期的桶
+func (b *Breaker) bucket(now time.Time) *bucket {
+	index := now.UnixNano() / int64(b.opts.Window/buckets)
+	bk := &b.buckets[index%buckets]
+	if bk.index != index {
+		*bk = bucket{index: index}
+	}
+	return bk
+}
+
+// counts 返回窗口内的请求数和失败数
+func (b *Breaker) counts(now time.Time) (total, failures int) {
+	index := now.UnixNano() / int64(b.opts.Window/buckets)
+	for _, bk := range b.buckets {
+		if index-bk.index < buckets {
+			total += bk.total
+			failures += bk.failures
+		}
+	}
+	return total, failures
+}
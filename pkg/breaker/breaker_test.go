@@ -0,0 +1,220 @@
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+// testOptions 窗口内至少 4 个请求、失败率达到一半时打开，打开 1s 后半开并放行 2 个探测请求
+var testOptions = Options{
+	Window:           10 * time.Second,
+	MinRequests:      4,
+	FailureRatio:     0.5,
+	OpenTimeout:      time.Second,
+	HalfOpenRequests: 2,
+}
+
+// fakeClock 手动推进的时钟
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// newTestBreaker 返回使用 fakeClock 的熔断器，状态变化记录在返回的切片中
+func newTestBreaker(opts Options) (*Breaker, *fakeClock, *[]string) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	var changes []string
+	b := New("test", opts, func(name string, from, to State) {
+		changes = append(changes, fmt.Sprintf("%s -> %s", from, to))
+	})
+	b.now = clock.Now
+	return b, clock, &changes
+}
+
+// record 放行一个请求并立即记录结果
+func record(t *testing.T, b *Breaker, success bool) {
+	t.Helper()
+	done, err := b.Allow()
+	if err != nil {
+		t.Fatalf("Allow() in state %s = %v", b.State(), err)
+	}
+	done(success)
+}
+
+func assertState(t *testing.T, b *Breaker, want State) {
+	t.Helper()
+	if got := b.State(); got != want {
+		t.Fatalf("state = %s, want %s", got, want)
+	}
+}
+
+func TestBreakerStateMachine(t *testing.T) {
+	b, clock, changes := newTestBreaker(testOptions)
+
+	// 请求数不足 MinRequests 时不打开
+	record(t, b, false)
+	record(t, b, false)
+	record(t, b, true)
+	assertState(t, b, StateClosed)
+
+	// 第 4 个请求失败，失败率 3/4 达到阈值
+	record(t, b, false)
+	assertState(t, b, StateOpen)
+	if _, err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("Allow() while open = %v, want ErrOpen", err)
+	}
+
+	clock.Advance(testOptions.OpenTimeout - time.Millisecond)
+	assertState(t, b, StateOpen)
+	clock.Advance(time.Millisecond)
+	assertState(t, b, StateHalfOpen)
+
+	// 探测请求全部成功后关闭，关闭后重新开始统计
+	record(t, b, true)
+	assertState(t, b, StateHalfOpen)
+	record(t, b, true)
+	assertState(t, b, StateClosed)
+	record(t, b, false)
+	record(t, b, false)
+	assertState(t, b, StateClosed)
+
+	want := []string{"closed -> open", "open -> half-open", "half-open -> closed"}
+	if !slices.Equal(*changes, want) {
+		t.Fatalf("state changes = %v, want %v", *changes, want)
+	}
+}
+
+func TestBreakerHalfOpenFailureReopens(t *testing.T) {
+	b, clock, changes := newTestBreaker(testOptions)
+	for range testOptions.MinRequests {
+		record(t, b, false)
+	}
+	clock.Advance(testOptions.OpenTimeout)
+	assertState(t, b, StateHalfOpen)
+
+	record(t, b, true)
+	record(t, b, false)
+	assertState(t, b, StateOpen)
+
+	// 重新打开后需要再等待 OpenTimeout
+	clock.Advance(testOptions.OpenTimeout / 2)
+	assertState(t, b, StateOpen)
+	clock.Advance(testOptions.OpenTimeout / 2)
+	assertState(t, b, StateHalfOpen)
+
+	want := []string{"closed -> open", "open -> half-open", "half-open -> open", "open -> half-open"}
+	if !slices.Equal(*changes, want) {
+		t.Fatalf("state changes = %v, want %v", *changes, want)
+	}
+}
+
+func TestBreakerHalfOpenProbeLimit(t *testing.T) {
+	b, clock, _ := newTestBreaker(testOptions)
+	for range testOptions.MinRequests {
+		record(t, b, false)
+	}
+	clock.Advance(testOptions.OpenTimeout)
+
+	var probes []func(bool)
+	for range testOptions.HalfOpenRequests {
+		done, err := b.Allow()
+		if err != nil {
+			t.Fatalf("probe Allow() = %v", err)
+		}
+		probes = append(probes, done)
+	}
+	// 探测请求未结束前拒绝其他请求
+	if _, err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("Allow() with all probes in flight = %v, want ErrOpen", err)
+	}
+
+	// 重复调用 done 只记录一次
+	probes[0](true)
+	probes[0](true)
+	assertState(t, b, StateHalfOpen)
+	if _, err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("Allow() after a probe finished = %v, want ErrOpen", err)
+	}
+	probes[1](true)
+	assertState(t, b, StateClosed)
+}
+
+func TestBreakerIgnoresStaleResults(t *testing.T) {
+	b, clock, _ := newTestBreaker(testOptions)
+
+	// 熔断器打开前开始的慢请求
+	slow, err := b.Allow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range testOptions.MinRequests {
+		record(t, b, false)
+	}
+	clock.Advance(testOptions.OpenTimeout)
+	assertState(t, b, StateHalfOpen)
+
+	// 慢请求在半开状态下才失败，不应重新打开熔断器，也不占用探测名额
+	slow(false)
+	assertState(t, b, StateHalfOpen)
+	record(t, b, true)
+	record(t, b, true)
+	assertState(t, b, StateClosed)
+
+	// 打开前开始的请求在熔断器重新打开后返回，不计入新的打开周期
+	stale, err := b.Allow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Millisecond)
+	for range testOptions.MinRequests {
+		record(t, b, false)
+	}
+	clock.Advance(testOptions.OpenTimeout)
+	record(t, b, true)
+	stale(false)
+	assertState(t, b, StateHalfOpen)
+}
+
+func TestBreakerWindow(t *testing.T) {
+	b, clock, _ := newTestBreaker(testOptions)
+	record(t, b, false)
+	record(t, b, false)
+	record(t, b, false)
+
+	// 超出窗口的失败不再计入
+	clock.Advance(testOptions.Window)
+	record(t, b, false)
+	record(t, b, true)
+	record(t, b, true)
+	assertState(t, b, StateClosed)
+	record(t, b, true)
+	assertState(t, b, StateClosed)
+
+	// 窗口内的失败率达到阈值
+	record(t, b, false)
+	record(t, b, false)
+	assertState(t, b, StateOpen)
+}
+
+func TestGroup(t *testing.T) {
+	var changed []string
+	g := NewGroup(Options{MinRequests: 1, OpenTimeout: time.Hour}, func(name string, from, to State) {
+		changed = append(changed, name)
+	})
+	if g.Get("a") != g.Get("a") {
+		t.Fatal("Get() returned different breakers for the same key")
+	}
+
+	record(t, g.Get("a"), false)
+	record(t, g.Get("b"), true)
+	states := g.States()
+	if len(states) != 2 || states["a"] != StateOpen || states["b"] != StateClosed {
+		t.Fatalf("States() = %v, want a open and b closed", states)
+	}
+	if !slices.Equal(changed, []string{"a"}) {
+		t.Fatalf("onChange called for %v, want [a]", changed)
+	}
+}
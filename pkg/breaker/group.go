@@ -0,0 +1,46 @@
+package breaker
+
+import "sync"
+
+// Group 按 key 惰性创建熔断器，同一 Group 内的熔断器使用相同的配置
+type Group struct {
+	opts     Options
+	onChange StateChangeFunc
+
+	mu       sync.RWMutex
+	breakers map[string]*Breaker
+}
+
+// NewGroup 创建熔断器组，onChange 会在任一熔断器状态变化时调用
+func NewGroup(opts Options, onChange StateChangeFunc) *Group {
+	return &Group{opts: opts, onChange: onChange, breakers: make(map[string]*Breaker)}
+}
+
+// Get 返回 key 对应的熔断器，不存在时创建
+func (g *Group) Get(key string) *Breaker {
+	g.mu.RLock()
+	b, ok := g.breakers[key]
+	g.mu.RUnlock()
+	if ok {
+		return b
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if b, ok = g.breakers[key]; !ok {
+		b = New(key, g.opts, g.onChange)
+		g.breakers[key] = b
+	}
+	return b
+}
+
+// States 返回所有熔断器的当前状态
+func (g *Group) States() map[string]State {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	states := make(map[string]State, len(g.breakers))
+	for key, b := range g.breakers {
+		states[key] = b.State()
+	}
+	return states
+}
@@ -0,0 +1,163 @@
+// Package errors 提供统一的错误模型. Error 包含 gRPC 状态码、机器可读的原因、提示信息、元数据和原始错误，
+// 在 gRPC 中转换为带 ErrorInfo、BadRequest、RetryInfo 详情的 google.rpc.Status，在 gRPC-Gateway 中转换为统一的 JSON 结构，
+// 客户端收到的错误可以通过 FromError 还原.
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// FieldViolation 请求中不合法的字段，对应 BadRequest.FieldViolation
+type FieldViolation struct {
+	// Field 字段路径，如 user.email
+	Field string `json:"field"`
+	// Description 不合法的原因
+	Description string `json:"description"`
+}
+
+// Error 带有原因和详情的错误
+type Error struct {
+	// Code gRPC 状态码，通过 gRPC-Gateway 返回时转换为对应的 HTTP 状态码
+	Code codes.Code
+	// Reason 机器可读的错误原因，大写字母和下划线组成，如 USER_NOT_FOUND
+	Reason string
+	// Domain 错误所属的服务，为空时由服务端拦截器填入
+	Domain string
+	// Message 面向调用方的提示信息
+	Message string
+	// Metadata 错误相关的附加信息
+	Metadata map[string]string
+	// FieldViolations 请求中不合法的字段
+	FieldViolations []FieldViolation
+	// RetryAfter 建议的重试间隔，为 0 时不返回
+	RetryAfter time.Duration
+
+	cause error
+	// details 无法识别的其他详情，转换回 Status 时原样返回
+	details []*anypb.Any
+}
+
+// New 创建错误
+func New(code codes.Code, reason, message string) *Error {
+	return &Error{Code: code, Reason: reason, Message: message}
+}
+
+// Newf 创建错误，message 使用 fmt.Sprintf 格式化
+func Newf(code codes.Code, reason, format string, args ...any) *Error {
+	return New(code, reason, fmt.Sprintf(format, args...))
+}
+
+func (e *Error) Error() string {
+	s := fmt.Sprintf("error: code = %s reason = %s message = %s", e.Code, e.Reason, e.Message)
+	if len(e.Metadata) > 0 {
+		s += fmt.Sprintf(" metadata = %v", e.Metadata)
+	}
+	if e.cause != nil {
+		s += fmt.Sprintf(" cause = %v", e.cause)
+	}
+	return s
+}
+
+// Unwrap 返回原始错误
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is 状态码和原因都相同时认为是同一个错误，因此可以对客户端还原出的错误使用 errors.Is 判断
+func (e *Error) Is(target error) bool {
+	var t *Error
+	if !stderrors.As(target, &t) {
+		return false
+	}
+	return e.Code == t.Code && e.Reason == t.Reason
+}
+
+// clone 复制错误，With 系列方法不修改原来的错误，因此可以将错误定义为包级变量
+func (e *Error) clone() *Error {
+	c := *e
+	c.Metadata = maps.Clone(e.Metadata)
+	c.FieldViolations = append([]FieldViolation(nil), e.FieldViolations...)
+	return &c
+}
+
+// WithCause 返回带有原始错误的副本，原始错误只用于日志，不会返回给调用方
+func (e *Error) WithCause(cause error) *Error {
+	c := e.clone()
+	c.cause = cause
+	return c
+}
+
+// WithDomain 返回设置了 Domain 的副本
+func (e *Error) WithDomain(domain string) *Error {
+	c := e.clone()
+	c.Domain = domain
+	return c
+}
+
+// WithMessage 返回替换了提示信息的副本
+func (e *Error) WithMessage(format string, args ...any) *Error {
+	c := e.clone()
+	c.Message = fmt.Sprintf(format, args...)
+	return c
+}
+
+// WithMetadata 返回添加了元数据的副本
+func (e *Error) WithMetadata(md map[string]string) *Error {
+	c := e.clone()
+	if c.Metadata == nil {
+		c.Metadata = make(map[string]string, len(md))
+	}
+	maps.Copy(c.Metadata, md)
+	return c
+}
+
+// WithFieldViolation 返回添加了不合法字段的副本
+func (e *Error) WithFieldViolation(field, description string) *Error {
+	c := e.clone()
+	c.FieldViolations = append(c.FieldViolations, FieldViolation{Field: field, Description: description})
+	return c
+}
+
+// WithRetryAfter 返回带有建议重试间隔的副本
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	c := e.clone()
+	c.RetryAfter = d
+	return c
+}
+
+// Code 返回错误的状态码，err 为 nil 时返回 OK
+func Code(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+	return FromError(err).Code
+}
+
+// Reason 返回错误的原因，没有时为空
+func Reason(err error) string {
+	if err == nil {
+		return ""
+	}
+	return FromError(err).Reason
+}
+
+// Is 同标准库的 errors.Is
+func Is(err, target error) bool {
+	return stderrors.Is(err, target)
+}
+
+// As 同标准库的 errors.As
+func As(err error, target any) bool {
+	return stderrors.As(err, target)
+}
+
+// Unwrap 同标准库的 errors.Unwrap
+func Unwrap(err error) error {
+	return stderrors.Unwrap(err)
+}
@@ -0,0 +1,140 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestStatusRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *Error
+	}{
+		{"code and message only", New(codes.Internal, "", "boom")},
+		{"reason", NotFound("USER_NOT_FOUND", "user %s not found", "42")},
+		{"domain and metadata", Forbidden("MISSING_SCOPE", "denied").
+			WithDomain("demo.example.com").
+			WithMetadata(map[string]string{"method": "/demo.v1.UserService/GetUser", "missing_scopes": "users:read"})},
+		{"field violations", BadRequest("INVALID_ARGUMENT", "invalid request").
+			WithFieldViolation("name", "must not be empty").
+			WithFieldViolation("email", "must be an email address")},
+		{"retry after", TooManyRequests("RATE_LIMITED", "slow down").WithRetryAfter(1500 * time.Millisecond)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 经过 gRPC 传输后客户端收到的是 status 错误
+			st, ok := status.FromError(tt.err)
+			if !ok {
+				t.Fatal("status.FromError() failed for *Error")
+			}
+			got := FromError(status.ErrorProto(st.Proto()))
+			assertSameError(t, got, tt.err)
+
+			// 再次转换得到相同的状态
+			if !proto.Equal(got.GRPCStatus().Proto(), st.Proto()) {
+				t.Fatalf("status after round trip = %v, want %v", got.GRPCStatus().Proto(), st.Proto())
+			}
+		})
+	}
+}
+
+// assertSameError 比较两个错误中会通过 gRPC 传输的字段
+func assertSameError(t *testing.T, got, want *Error) {
+	t.Helper()
+	if got.Code != want.Code || got.Reason != want.Reason || got.Domain != want.Domain ||
+		got.Message != want.Message || got.RetryAfter != want.RetryAfter {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	if !maps.Equal(got.Metadata, want.Metadata) {
+		t.Fatalf("metadata = %v, want %v", got.Metadata, want.Metadata)
+	}
+	if !slices.Equal(got.FieldViolations, want.FieldViolations) {
+		t.Fatalf("field violations = %v, want %v", got.FieldViolations, want.FieldViolations)
+	}
+}
+
+func TestStatusKeepsUnknownDetails(t *testing.T) {
+	st, err := status.New(codes.NotFound, "not found").WithDetails(
+		&errdetails.ErrorInfo{Reason: "USER_NOT_FOUND"},
+		&errdetails.LocalizedMessage{Locale: "zh-CN", Message: "用户不存在"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := FromStatus(st)
+	if e.Reason != "USER_NOT_FOUND" {
+		t.Fatalf("reason = %q, want USER_NOT_FOUND", e.Reason)
+	}
+
+	var localized *errdetails.LocalizedMessage
+	for _, d := range e.GRPCStatus().Details() {
+		if m, ok := d.(*errdetails.LocalizedMessage); ok {
+			localized = m
+		}
+	}
+	if localized.GetMessage() != "用户不存在" {
+		t.Fatalf("LocalizedMessage detail lost: %v", e.GRPCStatus().Details())
+	}
+}
+
+func TestFromError(t *testing.T) {
+	notFound := NotFound("USER_NOT_FOUND", "user not found")
+	tests := []struct {
+		name     string
+		err      error
+		wantCode codes.Code
+		// wantSame 是否返回原来的 *Error
+		wantSame bool
+	}{
+		{"*Error", notFound, codes.NotFound, true},
+		{"wrapped *Error", fmt.Errorf("get user: %w", notFound), codes.NotFound, true},
+		{"canceled", fmt.Errorf("query: %w", context.Canceled), codes.Canceled, false},
+		{"deadline exceeded", context.DeadlineExceeded, codes.DeadlineExceeded, false},
+		{"status error", status.Error(codes.Unavailable, "down"), codes.Unavailable, false},
+		{"plain error", io.EOF, codes.Unknown, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FromError(tt.err)
+			if got.Code != tt.wantCode {
+				t.Fatalf("code = %s, want %s", got.Code, tt.wantCode)
+			}
+			if tt.wantSame && got != notFound {
+				t.Fatalf("FromError() = %p, want the original error %p", got, notFound)
+			}
+		})
+	}
+	if FromError(nil) != nil {
+		t.Fatal("FromError(nil) != nil")
+	}
+}
+
+func TestErrorCause(t *testing.T) {
+	cause := io.ErrUnexpectedEOF
+	err := Internal("DB_ERROR", "query failed").WithCause(cause)
+	if !stderrors.Is(err, cause) {
+		t.Fatal("errors.Is() does not find the cause")
+	}
+	// Is 比较状态码和原因
+	if !Is(fmt.Errorf("wrapped: %w", err), Internal("DB_ERROR", "another message")) {
+		t.Fatal("Is() = false for the same code and reason")
+	}
+	if Is(err, Internal("OTHER", "query failed")) {
+		t.Fatal("Is() = true for a different reason")
+	}
+	// 原始错误不会通过 gRPC 传输
+	if got := FromStatus(err.GRPCStatus()); got.Unwrap() != nil {
+		t.Fatalf("cause after round trip = %v, want nil", got.Unwrap())
+	}
+}
@@ -0,0 +1,89 @@
+package errors
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/code"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// requestIDHeader 与 httpmiddlewares.RequestIDHeader 相同
+const requestIDHeader = "X-Request-Id"
+
+// HTTPError gRPC-Gateway 返回的错误，响应体为 {"error": HTTPError}
+type HTTPError struct {
+	// Code HTTP 状态码
+	Code int `json:"code"`
+	// Status gRPC 状态码的名称，如 NOT_FOUND
+	Status string `json:"status"`
+	// Reason 错误原因，如 USER_NOT_FOUND
+	Reason string `json:"reason,omitempty"`
+	// Domain 错误所属的服务
+	Domain string `json:"domain,omitempty"`
+	// Message 提示信息
+	Message string `json:"message"`
+	// Metadata 错误相关的附加信息
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// FieldViolations 请求中不合法的字段
+	FieldViolations []FieldViolation `json:"field_violations,omitempty"`
+	// RetryAfter 建议的重试间隔，单位为秒，同时通过 Retry-After 响应头返回
+	RetryAfter int `json:"retry_after,omitempty"`
+	// RequestID 请求 ID，便于排查问题
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// ToHTTPError 将错误转换为 HTTPError，HTTP 状态码与 gRPC-Gateway 的映射相同
+func ToHTTPError(err error) *HTTPError {
+	e := FromError(err)
+	return &HTTPError{
+		Code:            runtime.HTTPStatusFromCode(e.Code),
+		Status:          code.Code(e.Code).String(),
+		Reason:          e.Reason,
+		Domain:          e.Domain,
+		Message:         e.Message,
+		Metadata:        e.Metadata,
+		FieldViolations: e.FieldViolations,
+		RetryAfter:      int(math.Ceil(e.RetryAfter.Seconds())),
+	}
+}
+
+// GatewayOptions 返回 gRPC-Gateway 使用统一错误结构所需的选项，通过 pkg/grpc 的 WithGatewayOptions 安装
+func GatewayOptions() []runtime.ServeMuxOption {
+	return []runtime.ServeMuxOption{
+		runtime.WithErrorHandler(gatewayErrorHandler),
+		runtime.WithForwardResponseRewriter(gatewayResponseRewriter),
+	}
+}
+
+type requestIDKey struct{}
+
+// gatewayErrorHandler 设置 Retry-After 响应头后交给 runtime.DefaultHTTPErrorHandler，保留其转发 metadata 和 trailer 的处理，
+// 响应体由 gatewayResponseRewriter 转换
+func gatewayErrorHandler(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler,
+	w http.ResponseWriter, r *http.Request, err error) {
+	if e := FromError(err); e.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds()))))
+	}
+	if id := w.Header().Get(requestIDHeader); id != "" {
+		ctx = context.WithValue(ctx, requestIDKey{}, id)
+	}
+	runtime.DefaultHTTPErrorHandler(ctx, mux, marshaler, w, r, err)
+}
+
+// gatewayResponseRewriter 将错误响应的 google.rpc.Status 转换为 {"error": HTTPError}，其他响应保持不变.
+// 因此 RPC 不应直接返回 google.rpc.Status 类型的响应
+func gatewayResponseRewriter(ctx context.Context, resp proto.Message) (any, error) {
+	s, ok := resp.(*spb.Status)
+	if !ok {
+		return resp, nil
+	}
+	he := ToHTTPError(status.FromProto(s).Err())
+	he.RequestID, _ = ctx.Value(requestIDKey{}).(string)
+	return map[string]*HTTPError{"error": he}, nil
+}
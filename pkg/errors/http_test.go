@@ -0,0 +1,130 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestToHTTPErrorCode(t *testing.T) {
+	tests := []struct {
+		err        error
+		wantCode   int
+		wantStatus string
+	}{
+		{BadRequest("INVALID", "bad"), http.StatusBadRequest, "INVALID_ARGUMENT"},
+		{Unauthorized("MISSING_TOKEN", "no token"), http.StatusUnauthorized, "UNAUTHENTICATED"},
+		{Forbidden("MISSING_ROLE", "denied"), http.StatusForbidden, "PERMISSION_DENIED"},
+		{NotFound("USER_NOT_FOUND", "missing"), http.StatusNotFound, "NOT_FOUND"},
+		{Conflict("USER_EXISTS", "exists"), http.StatusConflict, "ALREADY_EXISTS"},
+		{TooManyRequests("RATE_LIMITED", "slow down"), http.StatusTooManyRequests, "RESOURCE_EXHAUSTED"},
+		{Internal("DB_ERROR", "boom"), http.StatusInternalServerError, "INTERNAL"},
+		{Unavailable("OVERLOADED", "busy"), http.StatusServiceUnavailable, "UNAVAILABLE"},
+		{context.DeadlineExceeded, http.StatusGatewayTimeout, "DEADLINE_EXCEEDED"},
+		{status.Error(codes.FailedPrecondition, "precondition"), http.StatusBadRequest, "FAILED_PRECONDITION"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wantStatus, func(t *testing.T) {
+			he := ToHTTPError(tt.err)
+			if he.Code != tt.wantCode || he.Status != tt.wantStatus {
+				t.Fatalf("ToHTTPError() = %d %s, want %d %s", he.Code, he.Status, tt.wantCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestToHTTPErrorRoundsRetryAfterUp(t *testing.T) {
+	he := ToHTTPError(TooManyRequests("RATE_LIMITED", "slow down").WithRetryAfter(1200 * time.Millisecond))
+	if he.RetryAfter != 2 {
+		t.Fatalf("retry_after = %d, want 2", he.RetryAfter)
+	}
+}
+
+// serveGatewayError 通过安装了 GatewayOptions 的 ServeMux 返回 err，模拟 gRPC-Gateway 转发的 RPC 失败
+func serveGatewayError(t *testing.T, err error, requestID string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := runtime.NewServeMux(GatewayOptions()...)
+	if e := mux.HandlePath(http.MethodGet, "/v1/users/{id}", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		if requestID != "" {
+			w.Header().Set(requestIDHeader, requestID)
+		}
+		_, outbound := runtime.MarshalerForRequest(mux, r)
+		// 经过 gRPC 传输后网关收到的是 status 错误
+		runtime.HTTPError(r.Context(), mux, outbound, w, r, status.ErrorProto(status.Convert(err).Proto()))
+	}); e != nil {
+		t.Fatal(e)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/users/42", nil))
+	return rec
+}
+
+func TestGatewayErrorBody(t *testing.T) {
+	err := TooManyRequests("RATE_LIMITED", "rate limit exceeded").
+		WithDomain("demo.example.com").
+		WithMetadata(map[string]string{"method": "/demo.v1.UserService/GetUser"}).
+		WithFieldViolation("id", "must not be empty").
+		WithRetryAfter(3 * time.Second)
+	rec := serveGatewayError(t, err, "req-1")
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "3" {
+		t.Fatalf("Retry-After = %q, want 3", got)
+	}
+
+	var body map[string]*HTTPError
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body %s: %v", rec.Body, err)
+	}
+	got := body["error"]
+	if got == nil {
+		t.Fatalf("body = %s, want an error field", rec.Body)
+	}
+	want := &HTTPError{
+		Code:            http.StatusTooManyRequests,
+		Status:          "RESOURCE_EXHAUSTED",
+		Reason:          "RATE_LIMITED",
+		Domain:          "demo.example.com",
+		Message:         "rate limit exceeded",
+		Metadata:        map[string]string{"method": "/demo.v1.UserService/GetUser"},
+		FieldViolations: []FieldViolation{{Field: "id", Description: "must not be empty"}},
+		RetryAfter:      3,
+		RequestID:       "req-1",
+	}
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(want)
+	if string(gotJSON) != string(wantJSON) {
+		t.Fatalf("error = %s, want %s", gotJSON, wantJSON)
+	}
+}
+
+func TestGatewayErrorWithoutDetails(t *testing.T) {
+	rec := serveGatewayError(t, status.Error(codes.NotFound, "user not found"), "")
+	if rec.Code != http.StatusNotFound || rec.Header().Get("Retry-After") != "" {
+		t.Fatalf("status = %d, Retry-After = %q, want 404 without Retry-After", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	// 没有的字段不出现在响应中
+	var body map[string]map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body %s: %v", rec.Body, err)
+	}
+	want := map[string]any{"code": float64(404), "status": "NOT_FOUND", "message": "user not found"}
+	if len(body["error"]) != len(want) {
+		t.Fatalf("error = %v, want %v", body["error"], want)
+	}
+	for k, v := range want {
+		if body["error"][k] != v {
+			t.Fatalf("error = %v, want %v", body["error"], want)
+		}
+	}
+}
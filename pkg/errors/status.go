@@ -0,0 +1,96 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// GRPCStatus 转换为带详情的 gRPC 状态，gRPC 返回错误时会自动调用:
+//   - Reason、Domain 或 Metadata 不为空时添加 ErrorInfo
+//   - FieldViolations 不为空时添加 BadRequest
+//   - RetryAfter 大于 0 时添加 RetryInfo
+func (e *Error) GRPCStatus() *status.Status {
+	s := &spb.Status{Code: int32(e.Code), Message: e.Message}
+	var details []proto.Message
+	if e.Reason != "" || e.Domain != "" || len(e.Metadata) > 0 {
+		details = append(details, &errdetails.ErrorInfo{Reason: e.Reason, Domain: e.Domain, Metadata: e.Metadata})
+	}
+	if len(e.FieldViolations) > 0 {
+		br := &errdetails.BadRequest{}
+		for _, v := range e.FieldViolations {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: v.Field, Description: v.Description})
+		}
+		details = append(details, br)
+	}
+	if e.RetryAfter > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(e.RetryAfter)})
+	}
+	for _, d := range details {
+		if a, err := anypb.New(d); err == nil {
+			s.Details = append(s.Details, a)
+		}
+	}
+	s.Details = append(s.Details, e.details...)
+	return status.FromProto(s)
+}
+
+// FromError 将任意错误转换为 *Error:
+//   - *Error 或包装了 *Error 的错误直接返回其中的 *Error
+//   - context.Canceled 和 context.DeadlineExceeded 转换为对应的状态码
+//   - gRPC 状态错误从详情中还原原因、元数据、不合法字段和重试间隔
+//   - 其他错误的状态码为 Unknown
+//
+// err 为 nil 时返回 nil.
+func FromError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if stderrors.As(err, &e) {
+		return e
+	}
+	switch {
+	case stderrors.Is(err, context.Canceled):
+		return &Error{Code: codes.Canceled, Message: err.Error(), cause: err}
+	case stderrors.Is(err, context.DeadlineExceeded):
+		return &Error{Code: codes.DeadlineExceeded, Message: err.Error(), cause: err}
+	}
+	if st, ok := status.FromError(err); ok {
+		return FromStatus(st)
+	}
+	return &Error{Code: codes.Unknown, Message: err.Error(), cause: err}
+}
+
+// FromStatus 从 gRPC 状态还原错误，无法识别的详情在转换回状态时原样保留
+func FromStatus(st *status.Status) *Error {
+	p := st.Proto()
+	e := &Error{Code: st.Code(), Message: p.GetMessage()}
+	for _, a := range p.GetDetails() {
+		m, err := a.UnmarshalNew()
+		if err != nil {
+			e.details = append(e.details, a)
+			continue
+		}
+		switch d := m.(type) {
+		case *errdetails.ErrorInfo:
+			e.Reason, e.Domain, e.Metadata = d.GetReason(), d.GetDomain(), d.GetMetadata()
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				e.FieldViolations = append(e.FieldViolations, FieldViolation{Field: v.GetField(), Description: v.GetDescription()})
+			}
+		case *errdetails.RetryInfo:
+			e.RetryAfter = d.GetRetryDelay().AsDuration()
+		default:
+			e.details = append(e.details, a)
+		}
+	}
+	return e
+}
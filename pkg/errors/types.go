@@ -0,0 +1,67 @@
+package errors
+
+import (
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+)
+
+// BadRequest 请求参数不合法，对应 InvalidArgument 和 HTTP 400
+func BadRequest(reason, format string, args ...any) *Error {
+	return New(codes.InvalidArgument, reason, fmt.Sprintf(format, args...))
+}
+
+// Unauthorized 没有认证或认证失败，对应 Unauthenticated 和 HTTP 401
+func Unauthorized(reason, format string, args ...any) *Error {
+	return New(codes.Unauthenticated, reason, fmt.Sprintf(format, args...))
+}
+
+// Forbidden 没有权限，对应 PermissionDenied 和 HTTP 403
+func Forbidden(reason, format string, args ...any) *Error {
+	return New(codes.PermissionDenied, reason, fmt.Sprintf(format, args...))
+}
+
+// NotFound 资源不存在，对应 NotFound 和 HTTP 404
+func NotFound(reason, format string, args ...any) *Error {
+	return New(codes.NotFound, reason, fmt.Sprintf(format, args...))
+}
+
+// Conflict 资源已存在，对应 AlreadyExists 和 HTTP 409
+func Conflict(reason, format string, args ...any) *Error {
+	return New(codes.AlreadyExists, reason, fmt.Sprintf(format, args...))
+}
+
+// TooManyRequests 超出配额，对应 ResourceExhausted 和 HTTP 429
+func TooManyRequests(reason, format string, args ...any) *Error {
+	return New(codes.ResourceExhausted, reason, fmt.Sprintf(format, args...))
+}
+
+// Internal 服务内部错误，对应 Internal 和 HTTP 500
+func Internal(reason, format string, args ...any) *Error {
+	return New(codes.Internal, reason, fmt.Sprintf(format, args...))
+}
+
+// Unavailable 服务暂时不可用，对应 Unavailable 和 HTTP 503
+func Unavailable(reason, format string, args ...any) *Error {
+	return New(codes.Unavailable, reason, fmt.Sprintf(format, args...))
+}
+
+// IsNotFound 错误的状态码是否为 NotFound
+func IsNotFound(err error) bool {
+	return Code(err) == codes.NotFound
+}
+
+// IsBadRequest 错误的状态码是否为 InvalidArgument
+func IsBadRequest(err error) bool {
+	return Code(err) == codes.InvalidArgument
+}
+
+// IsUnauthorized 错误的状态码是否为 Unauthenticated
+func IsUnauthorized(err error) bool {
+	return Code(err) == codes.Unauthenticated
+}
+
+// IsForbidden 错误的状态码是否为 PermissionDenied
+func IsForbidden(err error) bool {
+	return Code(err) == codes.PermissionDenied
+}
@@ -0,0 +1,23 @@
+// Package balancer 提供客户端负载均衡策略. 导入该包时注册 p2c_ewma 和 weighted 两种策略，
+// 配合 resolver 包使用，通过 pkg/grpc 的 WithBalancer 选择.
+package balancer
+
+import (
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/balancer/roundrobin"
+)
+
+const (
+	// RoundRobin gRPC 内置的轮询策略
+	RoundRobin = roundrobin.Name
+	// P2C 随机选取两个节点，选择 EWMA 延迟、处理中请求数和成功率综合负载较低的一个
+	P2C = "p2c_ewma"
+	// Weighted 按注册中心中的 weight 元数据进行平滑加权轮询
+	Weighted = "weighted"
+)
+
+func init() {
+	balancer.Register(base.NewBalancerBuilder(P2C, &p2cPickerBuilder{}, base.Config{HealthCheck: true}))
+	balancer.Register(base.NewBalancerBuilder(Weighted, &weightedPickerBuilder{}, base.Config{HealthCheck: true}))
+}
@@ -0,0 +1,175 @@
+package balancer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/yanking/app-skeleton/pkg/grpc/resolver"
+	"github.com/yanking/app-skeleton/pkg/registry"
+)
+
+// backend 测试用的服务端，记录收到的请求数
+type backend struct {
+	instance *registry.ServiceInstance
+	requests atomic.Int64
+}
+
+// startBackend 启动服务端并注册到 m，delay 为每个请求的处理时间
+func startBackend(t *testing.T, m *registry.Memory, id string, weight int, delay time.Duration) *backend {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &backend{}
+	srv := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (any, error) {
+		b.requests.Add(1)
+		time.Sleep(delay)
+		return handler(ctx, req)
+	}))
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	b.instance = &registry.ServiceInstance{
+		ID:        id,
+		Name:      "demo",
+		Metadata:  map[string]string{registry.MetadataWeight: strconv.Itoa(weight)},
+		Endpoints: []string{"grpc://" + lis.Addr().String()},
+	}
+	if err := m.Register(context.Background(), b.instance); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// dial 通过 discovery:///demo 连接服务端，使用 policy 负载均衡
+func dial(t *testing.T, m *registry.Memory, policy string) healthpb.HealthClient {
+	t.Helper()
+	conn, err := grpc.NewClient("discovery:///demo",
+		grpc.WithResolvers(resolver.NewBuilder(m, true)),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig":[{%q:{}}]}`, policy)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func call(t *testing.T, client healthpb.HealthClient, n int) {
+	t.Helper()
+	for range n {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// waitAllReceived 一直发送请求直到所有服务端都收到请求，即所有子连接都已就绪，然后清空计数
+func waitAllReceived(t *testing.T, client healthpb.HealthClient, backends ...*backend) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		ready := true
+		for _, b := range backends {
+			ready = ready && b.requests.Load() > 0
+		}
+		if ready {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("not all backends received requests")
+		}
+		call(t, client, 1)
+	}
+	for _, b := range backends {
+		b.requests.Store(0)
+	}
+}
+
+func TestWeighted(t *testing.T) {
+	m := registry.NewMemory()
+	a := startBackend(t, m, "a", 1, 0)
+	b := startBackend(t, m, "b", 3, 0)
+	client := dial(t, m, Weighted)
+	waitAllReceived(t, client, a, b)
+
+	// 平滑加权轮询的分配是确定的，每 4 个请求中 a 1 个、b 3 个
+	call(t, client, 400)
+	if got, want := a.requests.Load(), int64(100); got != want {
+		t.Errorf("a received %d requests, want %d", got, want)
+	}
+	if got, want := b.requests.Load(), int64(300); got != want {
+		t.Errorf("b received %d requests, want %d", got, want)
+	}
+
+	// 注销后流量全部转移到剩下的实例
+	if err := m.Deregister(context.Background(), b.instance); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		b.requests.Store(0)
+		call(t, client, 10)
+		if b.requests.Load() == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("deregistered backend still receives requests")
+		}
+	}
+}
+
+func TestP2CPrefersFasterBackend(t *testing.T) {
+	m := registry.NewMemory()
+	fast := startBackend(t, m, "fast", 1, 0)
+	slow := startBackend(t, m, "slow", 1, 20*time.Millisecond)
+	client := dial(t, m, P2C)
+	waitAllReceived(t, client, fast, slow)
+
+	call(t, client, 100)
+	if got := fast.requests.Load(); got < 90 {
+		t.Fatalf("fast backend received %d of 100 requests, slow received %d, want at least 90", got, slow.requests.Load())
+	}
+}
+
+// fakeSubConn 用于直接测试 picker 的子连接
+type fakeSubConn struct {
+	balancer.SubConn
+	name string
+}
+
+func TestWeightedPickerIsSmooth(t *testing.T) {
+	a, b, c := &fakeSubConn{name: "a"}, &fakeSubConn{name: "b"}, &fakeSubConn{name: "c"}
+	p := &weightedPicker{nodes: []*weightedNode{{sc: a, weight: 5}, {sc: b, weight: 1}, {sc: c, weight: 1}}}
+
+	// 与 nginx 的平滑加权轮询一致，权重高的节点不会被连续集中选中
+	var got string
+	for range 7 {
+		res, err := p.Pick(balancer.PickInfo{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got += res.SubConn.(*fakeSubConn).name
+	}
+	if want := "aabacaa"; got != want {
+		t.Fatalf("pick order = %s, want %s", got, want)
+	}
+}
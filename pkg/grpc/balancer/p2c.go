@@ -0,0 +1,123 @@
+package balancer
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/status"
+
+	"github.com/yanking/app-skeleton/pkg/grpc/clientinterceptors"
+)
+
+const (
+	// tau EWMA 的衰减时间，越小越关注最近的请求
+	tau = 600 * time.Millisecond
+	// forcePick 节点超过该时间没有被选中时强制选中一次，刷新它的统计数据
+	forcePick = 3 * time.Second
+)
+
+// p2cNode 一个节点的统计数据
+type p2cNode struct {
+	sc balancer.SubConn
+
+	mu sync.Mutex
+	// lag 请求耗时的 EWMA，单位为纳秒
+	lag float64
+	// success 成功率的 EWMA，取值 0 到 1
+	success  float64
+	inflight int64
+	stamp    time.Time
+	picked   time.Time
+}
+
+// load 节点的综合负载，耗时越长、处理中的请求越多、成功率越低负载越高
+func (n *p2cNode) load() float64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.lag * float64(n.inflight+1) / max(n.success, 0.01)
+}
+
+func (n *p2cNode) lastPicked() time.Time {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.picked
+}
+
+func (n *p2cNode) start(now time.Time) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.inflight++
+	n.picked = now
+}
+
+func (n *p2cNode) done(start time.Time, err error) {
+	now := time.Now()
+	ok := !slices.Contains(clientinterceptors.DefaultFailureCodes, status.Code(err))
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.inflight--
+	w := math.Exp(-float64(now.Sub(n.stamp)) / float64(tau))
+	n.stamp = now
+	n.lag = n.lag*w + float64(now.Sub(start))*(1-w)
+	result := 0.0
+	if ok {
+		result = 1
+	}
+	n.success = n.success*w + result*(1-w)
+}
+
+type p2cPickerBuilder struct{}
+
+// Build 子连接状态变化时重建 picker，统计数据随之重置，会在几次请求内重新收敛
+func (*p2cPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	nodes := make([]*p2cNode, 0, len(info.ReadySCs))
+	for sc := range info.ReadySCs {
+		nodes = append(nodes, &p2cNode{sc: sc, success: 1, stamp: time.Now()})
+	}
+	return &p2cPicker{nodes: nodes}
+}
+
+type p2cPicker struct {
+	nodes []*p2cNode
+}
+
+func (p *p2cPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	var n *p2cNode
+	switch len(p.nodes) {
+	case 1:
+		n = p.nodes[0]
+	default:
+		i := rand.IntN(len(p.nodes))
+		j := rand.IntN(len(p.nodes) - 1)
+		if j >= i {
+			j++
+		}
+		a, b := p.nodes[i], p.nodes[j]
+		if a.load() > b.load() {
+			a, b = b, a
+		}
+		n = a
+		// 负载较高的节点长时间没有被选中时强制选中一次，否则它的统计数据永远不会更新
+		if time.Since(b.lastPicked()) > forcePick {
+			n = b
+		}
+	}
+
+	start := time.Now()
+	n.start(start)
+	return balancer.PickResult{
+		SubConn: n.sc,
+		Done: func(info balancer.DoneInfo) {
+			n.done(start, info.Err)
+		},
+	}, nil
+}
@@ -0,0 +1,53 @@
+package balancer
+
+import (
+	"sync"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+
+	"github.com/yanking/app-skeleton/pkg/grpc/resolver"
+)
+
+type weightedNode struct {
+	sc      balancer.SubConn
+	weight  int
+	current int
+}
+
+type weightedPickerBuilder struct{}
+
+func (*weightedPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	nodes := make([]*weightedNode, 0, len(info.ReadySCs))
+	for sc, sci := range info.ReadySCs {
+		nodes = append(nodes, &weightedNode{sc: sc, weight: resolver.Weight(sci.Address)})
+	}
+	return &weightedPicker{nodes: nodes}
+}
+
+// weightedPicker 平滑加权轮询（与 nginx 相同）：每次选择时所有节点的当前值加上各自的权重，
+// 选中当前值最大的节点并减去总权重，权重高的节点被更多地选中且不会连续集中
+type weightedPicker struct {
+	mu    sync.Mutex
+	nodes []*weightedNode
+}
+
+func (p *weightedPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var best *weightedNode
+	total := 0
+	for _, n := range p.nodes {
+		n.current += n.weight
+		total += n.weight
+		if best == nil || n.current > best.current {
+			best = n
+		}
+	}
+	best.current -= total
+	return balancer.PickResult{SubConn: best.sc}, nil
+}
@@ -2,12 +2,18 @@ package grpc
 
 import (
 	"context"
-
+	"crypto/tls"
 	"time"
 
+	"github.com/yanking/app-skeleton/pkg/breaker"
+	_ "github.com/yanking/app-skeleton/pkg/grpc/balancer" // 注册负载均衡策略
 	"github.com/yanking/app-skeleton/pkg/grpc/clientinterceptors"
+	"github.com/yanking/app-skeleton/pkg/grpc/resolver"
+	"github.com/yanking/app-skeleton/pkg/registry"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	grpcinsecure "google.golang.org/grpc/credentials/insecure"
 )
 
@@ -21,6 +27,20 @@ type clientOptions struct {
 	rpcOpts       []grpc.DialOption
 	enableTracing bool
 	enableMetrics bool
+	tlsConfig     *tls.Config
+	// tokenSource 为请求附加 bearer token，为 nil 时不附加
+	tokenSource clientinterceptors.TokenSource
+	// breaker 按目标地址和方法区分的熔断器，为 nil 时不启用
+	breaker      *breaker.Group
+	failureCodes []codes.Code
+	// 重试和对冲
+	retryPolicies  []methodRetryPolicy
+	retryBudget    clientinterceptors.RetryBudget
+	hedgingPolicy  *clientinterceptors.HedgingPolicy
+	hedgingMethods []string
+	// 服务发现和负载均衡
+	discovery registry.Discovery
+	balancer  string
 }
 
 func WithEnableTracing(enable bool) ClientOption {
@@ -29,7 +49,14 @@ func WithEnableTracing(enable bool) ClientOption {
 	}
 }
 
-// WithEndpoint 设置地址
+// WithClientMetrics 启用客户端指标，包括每个请求和每次尝试（含重试）的状态码
+func WithClientMetrics(enable bool) ClientOption {
+	return func(o *clientOptions) {
+		o.enableMetrics = enable
+	}
+}
+
+// WithEndpoint 设置地址，使用服务发现时为 discovery:///<服务名称>
 func WithEndpoint(endpoint string) ClientOption {
 	return func(o *clientOptions) {
 		o.endpoint = endpoint
@@ -64,10 +91,50 @@ func WithClientOptions(opts ...grpc.DialOption) ClientOption {
 	}
 }
 
+// WithClientTLSConfig 设置 Dial 使用的 TLS 配置，可以使用 tlsconfig.NewClientConfig 创建，配置证书时即为双向认证
+func WithClientTLSConfig(cfg *tls.Config) ClientOption {
+	return func(o *clientOptions) {
+		o.tlsConfig = cfg
+	}
+}
+
+// WithClientAuth 在每个请求头中附加 source 返回的 bearer token，调用方已设置 authorization 时不覆盖.
+// 与 WithClientUnaryInterceptor 不同，该选项追加到默认的拦截器链中.
+func WithClientAuth(source clientinterceptors.TokenSource) ClientOption {
+	return func(o *clientOptions) {
+		o.tokenSource = source
+	}
+}
+
+// WithCircuitBreaker 启用熔断，每个目标地址的每个方法使用独立的熔断器，熔断器打开时请求直接返回 Unavailable.
+// failureCodes 为计为失败的状态码，为空时使用 clientinterceptors.DefaultFailureCodes.
+func WithCircuitBreaker(opts breaker.Options, failureCodes ...codes.Code) ClientOption {
+	return func(o *clientOptions) {
+		o.breaker = clientinterceptors.NewBreakerGroup(opts)
+		o.failureCodes = failureCodes
+	}
+}
+
+// WithDiscovery 使用注册中心解析 discovery:///<服务名称> 形式的地址，并在实例变化时更新连接
+func WithDiscovery(discovery registry.Discovery) ClientOption {
+	return func(o *clientOptions) {
+		o.discovery = discovery
+	}
+}
+
+// WithBalancer 设置负载均衡策略，可选值：balancer.RoundRobin, balancer.P2C, balancer.Weighted，默认 pick_first
+func WithBalancer(name string) ClientOption {
+	return func(o *clientOptions) {
+		o.balancer = name
+	}
+}
+
+// DialInsecure 不使用 TLS 建立连接
 func DialInsecure(ctx context.Context, opts ...ClientOption) (*grpc.ClientConn, error) {
 	return dial(ctx, true, opts...)
 }
 
+// Dial 使用 TLS 建立连接，没有通过 WithClientTLSConfig 设置时使用系统根证书校验服务端
 func Dial(ctx context.Context, opts ...ClientOption) (*grpc.ClientConn, error) {
 	return dial(ctx, false, opts...)
 }
@@ -82,8 +149,9 @@ func dial(ctx context.Context, insecure bool, opts ...ClientOption) (*grpc.Clien
 		o(&options)
 	}
 
-	//TODO 客户端默认拦截器
+	// 错误拦截器在最外层，调用方收到的错误是 *errors.Error，其他拦截器看到的仍是原始的状态
 	ints := []grpc.UnaryClientInterceptor{
+		clientinterceptors.UnaryErrorInterceptor,
 		clientinterceptors.UnaryClientTimeoutInterceptor(options.timeout),
 	}
 
@@ -91,7 +159,28 @@ func dial(ctx context.Context, insecure bool, opts ...ClientOption) (*grpc.Clien
 		ints = append(ints, clientinterceptors.PrometheusInterceptor())
 	}
 
-	var streamInts []grpc.StreamClientInterceptor
+	streamInts := []grpc.StreamClientInterceptor{clientinterceptors.StreamErrorInterceptor}
+
+	// token 在熔断和对冲之前附加，对冲发出的每次尝试都携带 token
+	if options.tokenSource != nil {
+		ints = append(ints, clientinterceptors.UnaryAuthInterceptor(options.tokenSource))
+		streamInts = append(streamInts, clientinterceptors.StreamAuthInterceptor(options.tokenSource))
+	}
+
+	// 熔断器放在指标拦截器之后，被熔断的请求也会计入状态码指标
+	if options.breaker != nil {
+		ints = append(ints, clientinterceptors.UnaryBreakerInterceptor(options.breaker, options.failureCodes...))
+		streamInts = append(streamInts, clientinterceptors.StreamBreakerInterceptor(options.breaker, options.failureCodes...))
+	}
+
+	// 对冲放在熔断器之后，熔断器只统计整个调用的结果
+	if options.hedgingPolicy != nil {
+		methods, err := HedgeableMethodsOf(options.hedgingMethods...)
+		if err != nil {
+			return nil, err
+		}
+		ints = append(ints, clientinterceptors.UnaryHedgingInterceptor(*options.hedgingPolicy, options.retryBudget, methods...))
+	}
 
 	if len(options.unaryInts) > 0 {
 		ints = append(ints, options.unaryInts...)
@@ -109,8 +198,30 @@ func dial(ctx context.Context, insecure bool, opts ...ClientOption) (*grpc.Clien
 		grpcOpts = append(grpcOpts, grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
 	}
 
+	if options.enableMetrics {
+		grpcOpts = append(grpcOpts, grpc.WithStatsHandler(clientinterceptors.AttemptStatsHandler{}))
+	}
+
+	sc, err := buildServiceConfig(&options)
+	if err != nil {
+		return nil, err
+	}
+	if sc != "" {
+		grpcOpts = append(grpcOpts, grpc.WithDefaultServiceConfig(sc))
+	}
+
+	if options.discovery != nil {
+		grpcOpts = append(grpcOpts, grpc.WithResolvers(resolver.NewBuilder(options.discovery, insecure)))
+	}
+
 	if insecure {
 		grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(grpcinsecure.NewCredentials()))
+	} else {
+		tlsConfig := options.tlsConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
 	}
 
 	if len(options.rpcOpts) > 0 {
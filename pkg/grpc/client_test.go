@@ -0,0 +1,112 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/yanking/app-skeleton/pkg/breaker"
+	"github.com/yanking/app-skeleton/pkg/errors"
+	"github.com/yanking/app-skeleton/pkg/grpc/clientinterceptors"
+)
+
+// bufconnServer 启动只注册了健康检查服务的进程内 gRPC 服务，返回用于 Dial 的选项
+func bufconnServer(t *testing.T, opts ...grpc.ServerOption) ClientOption {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(opts...)
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	return func(o *clientOptions) {
+		o.endpoint = "passthrough:///bufnet"
+		o.enableTracing = false
+		o.rpcOpts = append(o.rpcOpts, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}))
+	}
+}
+
+func TestWithClientAuth(t *testing.T) {
+	var got []string
+	server := bufconnServer(t, grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		got = md.Get("authorization")
+		return handler(ctx, req)
+	}))
+
+	// 自定义拦截器与 token 同时生效
+	customCalled := false
+	conn, err := DialInsecure(context.Background(),
+		server,
+		WithClientAuth(clientinterceptors.StaticToken("secret")),
+		WithClientUnaryInterceptor(func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			customCalled = true
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	client := healthpb.NewHealthClient(conn)
+	if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != "Bearer secret" {
+		t.Fatalf("authorization = %q, want [Bearer secret]", got)
+	}
+	if !customCalled {
+		t.Fatal("custom interceptor was not called")
+	}
+
+	// 调用方设置的 authorization 不被覆盖
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer caller")
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != "Bearer caller" {
+		t.Fatalf("authorization = %q, want [Bearer caller]", got)
+	}
+}
+
+func TestWithCircuitBreaker(t *testing.T) {
+	calls := 0
+	server := bufconnServer(t, grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		calls++
+		return nil, status.Error(codes.Unavailable, "backend down")
+	}))
+	conn, err := DialInsecure(context.Background(), server,
+		WithCircuitBreaker(breaker.Options{MinRequests: 2, OpenTimeout: time.Hour}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	client := healthpb.NewHealthClient(conn)
+	for range 2 {
+		_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		if errors.Code(err) != codes.Unavailable || errors.Reason(err) == "CIRCUIT_OPEN" {
+			t.Fatalf("Check() = %v, want Unavailable from the server", err)
+		}
+	}
+
+	// 熔断器打开后请求不再发送到服务端
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if errors.Code(err) != codes.Unavailable || errors.Reason(err) != "CIRCUIT_OPEN" {
+		t.Fatalf("Check() = %v, want Unavailable with reason CIRCUIT_OPEN", err)
+	}
+	if calls != 2 {
+		t.Fatalf("server received %d calls, want 2", calls)
+	}
+}
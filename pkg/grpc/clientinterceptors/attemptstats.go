@@ -0,0 +1,55 @@
+package clientinterceptors
+
+import (
+	"context"
+	"strconv"
+
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+
+	"github.com/yanking/app-skeleton/pkg/metric"
+)
+
+var metricClientAttemptsTotal = metric.NewCounterVec(&metric.CounterVecOpts{
+	Namespace: serverNamespace,
+	Subsystem: "requests",
+	Name:      serverName + "_attempts_total",
+	Help:      "rpc client attempts count, including retries and transparent retries.",
+	Labels:    []string{"method", "code", "transparent"},
+})
+
+type attemptKey struct{}
+
+type attemptInfo struct {
+	method      string
+	transparent bool
+}
+
+// AttemptStatsHandler 记录每一次尝试的状态码. 拦截器只能看到整个调用的结果，
+// gRPC 自身的重试发生在拦截器之下，需要通过 stats.Handler 才能统计.
+// 与 requests_code_total 相减即为重试的次数.
+type AttemptStatsHandler struct{}
+
+// TagRPC 在每次尝试开始时调用
+func (AttemptStatsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, attemptKey{}, &attemptInfo{method: info.FullMethodName})
+}
+
+func (AttemptStatsHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	info, ok := ctx.Value(attemptKey{}).(*attemptInfo)
+	if !ok {
+		return
+	}
+	switch s := s.(type) {
+	case *stats.Begin:
+		info.transparent = s.IsTransparentRetryAttempt
+	case *stats.End:
+		metricClientAttemptsTotal.Inc(info.method, strconv.Itoa(int(status.Code(s.Error))), strconv.FormatBool(info.transparent))
+	}
+}
+
+func (AttemptStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (AttemptStatsHandler) HandleConn(context.Context, stats.ConnStats) {}
@@ -0,0 +1,105 @@
+package clientinterceptors
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/yanking/app-skeleton/pkg/breaker"
+	"github.com/yanking/app-skeleton/pkg/errors"
+	"github.com/yanking/app-skeleton/pkg/log"
+	"github.com/yanking/app-skeleton/pkg/metric"
+)
+
+// DefaultFailureCodes 默认计为失败的状态码，只包含表示服务端或网络异常的状态码，
+// 业务错误（如 InvalidArgument、NotFound）不会导致熔断
+var DefaultFailureCodes = []codes.Code{
+	codes.Unknown,
+	codes.DeadlineExceeded,
+	codes.Internal,
+	codes.Unavailable,
+	codes.DataLoss,
+}
+
+var (
+	metricClientBreakerState = metric.NewGaugeVec(&metric.GaugeVecOpts{
+		Namespace: serverNamespace,
+		Subsystem: "breaker",
+		Name:      serverName + "_state",
+		Help:      "rpc client circuit breaker state (0: closed, 1: half-open, 2: open).",
+		Labels:    []string{"target", "method"},
+	})
+
+	metricClientBreakerRejected = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: serverNamespace,
+		Subsystem: "breaker",
+		Name:      serverName + "_rejected_total",
+		Help:      "rpc client requests rejected by the circuit breaker.",
+		Labels:    []string{"target", "method"},
+	})
+)
+
+// breakerKey 熔断器的 key 由目标地址和方法组成，同一目标的不同方法互不影响
+func breakerKey(target, method string) string {
+	return target + " " + method
+}
+
+// NewBreakerGroup 创建按目标地址和方法区分的熔断器组，状态变化时打印日志并更新指标
+func NewBreakerGroup(opts breaker.Options) *breaker.Group {
+	return breaker.NewGroup(opts, func(name string, from, to breaker.State) {
+		target, method, _ := strings.Cut(name, " ")
+		metricClientBreakerState.Set(float64(to), target, method)
+		if to == breaker.StateOpen {
+			log.Warnf("[breaker] %s %s: %s -> %s", target, method, from, to)
+			return
+		}
+		log.Infof("[breaker] %s %s: %s -> %s", target, method, from, to)
+	})
+}
+
+// circuitOpen 熔断器拒绝请求时返回的错误，原因为 CIRCUIT_OPEN
+func circuitOpen(method string, cause error) error {
+	return errors.Unavailable("CIRCUIT_OPEN", "circuit breaker is open for %s", method).WithCause(cause)
+}
+
+// UnaryBreakerInterceptor 熔断拦截器，熔断器打开时直接返回 Unavailable. failureCodes 为空时使用 DefaultFailureCodes.
+func UnaryBreakerInterceptor(group *breaker.Group, failureCodes ...codes.Code) grpc.UnaryClientInterceptor {
+	if len(failureCodes) == 0 {
+		failureCodes = DefaultFailureCodes
+	}
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		done, err := group.Get(breakerKey(cc.Target(), method)).Allow()
+		if err != nil {
+			metricClientBreakerRejected.Inc(cc.Target(), method)
+			return circuitOpen(method, err)
+		}
+
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		done(!slices.Contains(failureCodes, status.Code(err)))
+		return err
+	}
+}
+
+// StreamBreakerInterceptor 流式请求的熔断拦截器，只根据建立流的结果判断是否失败
+func StreamBreakerInterceptor(group *breaker.Group, failureCodes ...codes.Code) grpc.StreamClientInterceptor {
+	if len(failureCodes) == 0 {
+		failureCodes = DefaultFailureCodes
+	}
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		done, err := group.Get(breakerKey(cc.Target(), method)).Allow()
+		if err != nil {
+			metricClientBreakerRejected.Inc(cc.Target(), method)
+			return nil, circuitOpen(method, err)
+		}
+
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		done(!slices.Contains(failureCodes, status.Code(err)))
+		return stream, err
+	}
+}
@@ -0,0 +1,48 @@
+package clientinterceptors
+
+import (
+	"context"
+	"io"
+
+	"google.golang.org/grpc"
+
+	"github.com/yanking/app-skeleton/pkg/errors"
+)
+
+// UnaryErrorInterceptor 将服务端返回的状态还原为 *errors.Error，可以通过 errors.Is 与定义的错误比较
+func UnaryErrorInterceptor(ctx context.Context, method string, req, reply interface{},
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+		return errors.FromError(err)
+	}
+	return nil
+}
+
+// StreamErrorInterceptor 流式请求的错误还原拦截器，io.EOF 保持不变
+func StreamErrorInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+	method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		return nil, errors.FromError(err)
+	}
+	return &errorClientStream{ClientStream: stream}, nil
+}
+
+type errorClientStream struct {
+	grpc.ClientStream
+}
+
+func (s *errorClientStream) SendMsg(m interface{}) error {
+	return convertStreamError(s.ClientStream.SendMsg(m))
+}
+
+func (s *errorClientStream) RecvMsg(m interface{}) error {
+	return convertStreamError(s.ClientStream.RecvMsg(m))
+}
+
+func convertStreamError(err error) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+	return errors.FromError(err)
+}
@@ -0,0 +1,194 @@
+package clientinterceptors
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/yanking/app-skeleton/pkg/metric"
+)
+
+var metricClientHedgesTotal = metric.NewCounterVec(&metric.CounterVecOpts{
+	Namespace: serverNamespace,
+	Subsystem: "requests",
+	Name:      serverName + "_hedges_total",
+	Help:      "rpc client hedged requests sent in addition to the first attempt.",
+	Labels:    []string{"method"},
+})
+
+// RetryBudget 重试预算，语义与 gRPC service config 中的 retryThrottling 相同
+type RetryBudget struct {
+	// MaxTokens 令牌数上限，默认 10
+	MaxTokens float64
+	// TokenRatio 每次成功恢复的令牌数，默认 0.1
+	TokenRatio float64
+}
+
+// WithDefaults 返回填充了默认值的预算
+func (b RetryBudget) WithDefaults() RetryBudget {
+	if b.MaxTokens <= 0 {
+		b.MaxTokens = 10
+	}
+	if b.TokenRatio <= 0 {
+		b.TokenRatio = 0.1
+	}
+	return b
+}
+
+// throttler 按 RetryBudget 限制额外请求：失败消耗 1 个令牌，成功恢复 TokenRatio 个令牌，令牌数不超过上限的一半时不再发送额外请求
+type throttler struct {
+	budget RetryBudget
+	mu     sync.Mutex
+	tokens float64
+}
+
+func newThrottler(budget RetryBudget) *throttler {
+	budget = budget.WithDefaults()
+	return &throttler{budget: budget, tokens: budget.MaxTokens}
+}
+
+func (t *throttler) allow() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.tokens > t.budget.MaxTokens/2
+}
+
+func (t *throttler) record(success bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if success {
+		t.tokens = min(t.budget.MaxTokens, t.tokens+t.budget.TokenRatio)
+		return
+	}
+	t.tokens = max(0, t.tokens-1)
+}
+
+// HedgingPolicy 对冲策略
+type HedgingPolicy struct {
+	// MaxAttempts 包含首次请求在内的最大请求数，默认 2
+	MaxAttempts int
+	// Delay 发送下一个对冲请求前等待的时间，通常取方法耗时的 P95，默认 100ms
+	Delay time.Duration
+	// NonFatalCodes 不会终止对冲的状态码，收到这些状态码时立即发送下一个请求，默认 Unavailable
+	NonFatalCodes []codes.Code
+}
+
+func (p HedgingPolicy) withDefaults() HedgingPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 2
+	}
+	if p.Delay <= 0 {
+		p.Delay = 100 * time.Millisecond
+	}
+	if len(p.NonFatalCodes) == 0 {
+		p.NonFatalCodes = []codes.Code{codes.Unavailable}
+	}
+	return p
+}
+
+type hedgeResult struct {
+	reply proto.Message
+	err   error
+}
+
+// UnaryHedgingInterceptor 对冲拦截器，只对 methods（以 /* 结尾表示整个服务）生效，额外的请求受 budget 限制.
+// 额外的请求不会填充 grpc.Header、grpc.Trailer 和 grpc.Peer 等调用选项.
+func UnaryHedgingInterceptor(policy HedgingPolicy, budget RetryBudget, methods ...string) grpc.UnaryClientInterceptor {
+	policy = policy.withDefaults()
+	hedgeable := make(map[string]bool)
+	var services []string
+	for _, m := range methods {
+		if service, ok := strings.CutSuffix(m, "*"); ok {
+			services = append(services, service)
+			continue
+		}
+		hedgeable[m] = true
+	}
+	t := newThrottler(budget)
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		msg, ok := reply.(proto.Message)
+		if !ok || !(hedgeable[method] || slices.ContainsFunc(services, func(s string) bool { return strings.HasPrefix(method, s) })) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		results := make(chan hedgeResult, policy.MaxAttempts)
+		hedgeOpts := withoutOutputOptions(opts)
+		attempts, pending := 0, 0
+		send := func() {
+			callOpts := opts
+			if attempts > 0 {
+				callOpts = hedgeOpts
+				metricClientHedgesTotal.Inc(method)
+			}
+			attempts++
+			pending++
+			r := msg.ProtoReflect().New().Interface()
+			go func() {
+				err := invoker(ctx, method, req, r, cc, callOpts...)
+				results <- hedgeResult{reply: r, err: err}
+			}()
+		}
+
+		send()
+		timer := time.NewTimer(policy.Delay)
+		defer timer.Stop()
+
+		var lastErr error
+		for {
+			select {
+			case <-timer.C:
+				if attempts < policy.MaxAttempts && t.allow() {
+					send()
+					timer.Reset(policy.Delay)
+				}
+			case res := <-results:
+				pending--
+				if res.err == nil {
+					t.record(true)
+					// reply 可能被调用方复用，先清空再写入最先成功的结果
+					proto.Reset(msg)
+					proto.Merge(msg, res.reply)
+					return nil
+				}
+				if !slices.Contains(policy.NonFatalCodes, status.Code(res.err)) {
+					return res.err
+				}
+				t.record(false)
+				lastErr = res.err
+
+				// 非致命错误时立即发送下一个请求，不再等待 Delay
+				if attempts < policy.MaxAttempts && t.allow() {
+					send()
+					timer.Reset(policy.Delay)
+				} else if pending == 0 {
+					return lastErr
+				}
+			}
+		}
+	}
+}
+
+// withoutOutputOptions 去掉会写入调用方变量的调用选项，避免并发的请求同时写入
+func withoutOutputOptions(opts []grpc.CallOption) []grpc.CallOption {
+	var out []grpc.CallOption
+	for _, o := range opts {
+		switch o.(type) {
+		case grpc.HeaderCallOption, grpc.TrailerCallOption, grpc.PeerCallOption:
+			continue
+		}
+		out = append(out, o)
+	}
+	return out
+}
@@ -0,0 +1,145 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yanking/app-skeleton/pkg/log"
+)
+
+const defaultDrainTimeout = 10 * time.Second
+
+// ErrForcedStop 在期限内没有排空请求，剩余的请求被强制取消
+var ErrForcedStop = errors.New("grpc: server did not drain before the deadline")
+
+// DrainReport 记录一次停止时排空请求的结果
+type DrainReport struct {
+	Duration time.Duration `json:"duration"`
+	// Forced 为 true 表示排空超时，剩余请求被强制取消
+	Forced bool `json:"forced"`
+	// CancelledRPCs 被强制取消的 unary 请求数
+	CancelledRPCs int64 `json:"cancelled_rpcs"`
+	// CancelledStreams 被强制取消的流数
+	CancelledStreams int64 `json:"cancelled_streams"`
+}
+
+// WithDrainTimeout 设置 Start 的 ctx 被取消而没有调用 Stop 时排空请求的期限，默认 10s.
+// 通过 Stop 停止时以 Stop 的 ctx 为准.
+func WithDrainTimeout(timeout time.Duration) ServerOption {
+	return func(s *Server) {
+		s.drainTimeout = timeout
+	}
+}
+
+// InFlight 返回正在处理中的 unary 请求数和流数
+func (s *Server) InFlight() (rpcs, streams int64) {
+	return s.inFlight.Count()
+}
+
+// DrainReport 返回停止时排空请求的结果，停止完成之前为 nil
+func (s *Server) DrainReport() *DrainReport {
+	select {
+	case <-s.stopped:
+		return s.drain
+	default:
+		return nil
+	}
+}
+
+// Stop 在 ctx 的期限内优雅停止服务，实现 app.IComponent：
+//   - 健康状态置为 NOT_SERVING
+//   - GracefulStop 立即向所有连接发送 GOAWAY，客户端不再发起新的请求，已有的请求继续处理
+//   - 同时停止 gRPC-Gateway，期限内未结束的 HTTP 请求被直接关闭. 单端口模式下 gRPC 请求也由 HTTP 服务处理，
+//     先停止 HTTP 服务再调用 GracefulStop
+//   - 期限到达时调用 Stop 强制关闭连接，剩余的请求和流被取消，并返回 ErrForcedStop
+//
+// 重复调用时等待第一次调用的结果.
+func (s *Server) Stop(ctx context.Context) error {
+	var err error
+	first := false
+	s.stopOnce.Do(func() {
+		first = true
+		err = s.stop(ctx)
+		close(s.stopped)
+	})
+	if first {
+		return err
+	}
+
+	select {
+	case <-s.stopped:
+		if s.drain.Forced {
+			return ErrForcedStop
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Server) stop(ctx context.Context) error {
+	start := time.Now()
+	s.deregister(ctx)
+	//设置服务的状态为not_serving，防止接收新的请求过来
+	s.shutdownHealth()
+
+	report := &DrainReport{}
+	rpcs, streams := s.inFlight.Count()
+	log.Infof("[grpc] draining %d in-flight rpc(s) and %d stream(s)", rpcs, streams)
+
+	// 双端口模式下 gRPC 与 gateway 同时排空，GracefulStop 立即向客户端发送 GOAWAY，两者共享 ctx 的期限.
+	// 单端口模式下 gRPC 请求由 HTTP 服务处理，Shutdown 已经向客户端发送 GOAWAY，而 GracefulStop 不能排空
+	// ServeHTTP 的连接（会 panic），因此在 HTTP 服务停止之后再调用
+	drained := make(chan struct{})
+	gracefulStop := func() {
+		go func() {
+			s.GracefulStop()
+			close(drained)
+		}()
+	}
+	if !s.singlePort {
+		gracefulStop()
+	}
+
+	if s.gatewayServer != nil {
+		if err := s.gatewayServer.Shutdown(ctx); err != nil {
+			// 单端口模式下 gRPC 请求同样由 HTTP 服务处理，关闭连接即强制取消剩余的请求
+			if s.singlePort {
+				report.Forced = true
+				report.CancelledRPCs, report.CancelledStreams = s.inFlight.Count()
+			}
+			_ = s.gatewayServer.Close()
+			log.Warnf("[gateway] server did not drain in time, connections closed: %v", err)
+		}
+		if s.gatewayConn != nil {
+			_ = s.gatewayConn.Close()
+		}
+		log.Infof("[gateway] server stopped")
+	}
+	if s.singlePort {
+		gracefulStop()
+	}
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		if !report.Forced {
+			report.Forced = true
+			report.CancelledRPCs, report.CancelledStreams = s.inFlight.Count()
+		}
+		s.Server.Stop()
+		<-drained
+	}
+	report.Duration = time.Since(start)
+	s.drain = report
+
+	if report.Forced {
+		log.Warnf("[grpc] server stopped forcibly after %s, cancelled %d rpc(s) and %d stream(s)",
+			report.Duration, report.CancelledRPCs, report.CancelledStreams)
+		return fmt.Errorf("%w: cancelled %d rpc(s) and %d stream(s)", ErrForcedStop, report.CancelledRPCs, report.CancelledStreams)
+	}
+	log.Infof("[grpc] server stopped")
+	return nil
+}
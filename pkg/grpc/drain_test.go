@@ -0,0 +1,161 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newBufconnServer 创建监听 bufconn 的服务，返回服务和连接到该服务的客户端. 注册服务之后通过 startServer 启动
+func newBufconnServer(t *testing.T, opts ...ServerOption) (*Server, *grpc.ClientConn) {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := NewServer(append(opts, WithLis(lis))...)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return srv, conn
+}
+
+// startServer 启动服务并等待就绪，测试结束时停止
+func startServer(t *testing.T, srv *Server) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() { _ = srv.Start(ctx) }()
+	<-srv.Ready()
+}
+
+// startBufconnServer 在 bufconn 上启动服务，返回服务和连接到该服务的客户端
+func startBufconnServer(t *testing.T, opts ...ServerOption) (*Server, *grpc.ClientConn) {
+	t.Helper()
+	srv, conn := newBufconnServer(t, opts...)
+	startServer(t, srv)
+	return srv, conn
+}
+
+// blockingCheck 阻塞健康检查请求，直到 release 被关闭或请求被取消
+func blockingCheck(release <-chan struct{}) ServerOption {
+	return WithUnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if info.FullMethod == grpc_health_v1.Health_Check_FullMethodName {
+			select {
+			case <-release:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		return handler(ctx, req)
+	})
+}
+
+// waitInFlight 等待服务中正在处理的请求数和流数达到期望值
+func waitInFlight(t *testing.T, srv *Server, wantRPCs, wantStreams int64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rpcs, streams := srv.InFlight()
+		if rpcs == wantRPCs && streams == wantStreams {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("in-flight = %d rpc(s), %d stream(s), want %d and %d", rpcs, streams, wantRPCs, wantStreams)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStopDrainsInFlightRPC(t *testing.T) {
+	release := make(chan struct{})
+	srv, conn := startBufconnServer(t, blockingCheck(release))
+
+	rpcErr := make(chan error, 1)
+	go func() {
+		_, err := grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		rpcErr <- err
+	}()
+	waitInFlight(t, srv, 1, 0)
+	if srv.DrainReport() != nil {
+		t.Fatal("DrainReport() before Stop != nil")
+	}
+
+	stopErr := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		stopErr <- srv.Stop(ctx)
+	}()
+	// 排空期间已有的请求继续处理
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	if err := <-stopErr; err != nil {
+		t.Fatalf("Stop() = %v", err)
+	}
+	if err := <-rpcErr; err != nil {
+		t.Fatalf("in-flight rpc failed during drain: %v", err)
+	}
+	report := srv.DrainReport()
+	if report == nil || report.Forced || report.CancelledRPCs != 0 || report.CancelledStreams != 0 {
+		t.Fatalf("DrainReport() = %+v, want a graceful drain", report)
+	}
+	if report.Duration < 50*time.Millisecond {
+		t.Fatalf("drain duration = %s, want at least the time the rpc was blocked", report.Duration)
+	}
+}
+
+func TestStopForcesAtDeadline(t *testing.T) {
+	srv, conn := startBufconnServer(t, blockingCheck(make(chan struct{})))
+	client := grpc_health_v1.NewHealthClient(conn)
+
+	rpcErr := make(chan error, 1)
+	go func() {
+		_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		rpcErr <- err
+	}()
+	// Watch 在客户端取消之前一直不会结束
+	stream, err := client.Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	waitInFlight(t, srv, 1, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = srv.Stop(ctx)
+	if !errors.Is(err, ErrForcedStop) {
+		t.Fatalf("Stop() = %v, want ErrForcedStop", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Stop() took %s after the deadline", elapsed)
+	}
+	if err := <-rpcErr; err == nil {
+		t.Fatal("in-flight rpc succeeded after a forced stop")
+	}
+
+	report := srv.DrainReport()
+	if report == nil || !report.Forced || report.CancelledRPCs != 1 || report.CancelledStreams != 1 {
+		t.Fatalf("DrainReport() = %+v, want forced with 1 rpc and 1 stream cancelled", report)
+	}
+
+	// 重复调用返回相同的结果
+	if err := srv.Stop(context.Background()); !errors.Is(err, ErrForcedStop) {
+		t.Fatalf("second Stop() = %v, want ErrForcedStop", err)
+	}
+}
@@ -0,0 +1,126 @@
+package grpc
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+
+	"github.com/yanking/app-skeleton/pkg/log"
+)
+
+// WithAdvertiseHost 设置对外公布的主机（IP 或域名），用于 Endpoint 和注册中心.
+// 适用于容器或 NAT 环境中监听地址与客户端可达地址不一致的情况.
+func WithAdvertiseHost(host string) ServerOption {
+	return func(s *Server) {
+		s.advertiseHost = host
+	}
+}
+
+// WithAdvertiseInterface 监听所有地址且没有设置 WithAdvertiseHost 时，从指定的网卡（如 eth0）选择对外公布的 IP.
+// 默认选择第一个已启用的非回环网卡.
+func WithAdvertiseInterface(name string) ServerOption {
+	return func(s *Server) {
+		s.advertiseInterface = name
+	}
+}
+
+// GatewayEndpoint 返回 gRPC-Gateway 对外公布的地址，没有启用 gRPC-Gateway 时为 nil
+func (s *Server) GatewayEndpoint() *url.URL {
+	return s.gatewayEndpoint
+}
+
+// advertiseEndpoint 返回监听器对外公布的地址:
+//   - TCP 监听器为 scheme://host:port，host 由 advertiseAddr 选择
+//   - Unix 套接字为 unix:///path（相对路径为 unix:path），与 gRPC 客户端的目标地址格式一致
+//   - 其他类型的监听器（如进程内的 bufconn）没有可以对外公布的地址，返回 nil
+func (s *Server) advertiseEndpoint(addr net.Addr, scheme string) (*url.URL, error) {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		host, err := s.advertiseAddr(a)
+		if err != nil {
+			return nil, err
+		}
+		return &url.URL{Scheme: scheme, Host: host}, nil
+	case *net.UnixAddr:
+		if filepath.IsAbs(a.Name) {
+			return &url.URL{Scheme: "unix", Path: a.Name}, nil
+		}
+		return &url.URL{Scheme: "unix", Opaque: a.Name}, nil
+	default:
+		log.Warnf("[grpc] listener address %s (%s) is not advertised", addr, addr.Network())
+		return nil, nil
+	}
+}
+
+// advertiseAddr 根据 TCP 监听器实际绑定的地址计算客户端可以访问的 host:port：
+//   - 设置了 advertiseHost 时使用 advertiseHost
+//   - 绑定到具体 IP 时使用该 IP
+//   - 绑定到 0.0.0.0 或 :: 时从网卡中选择一个 IP
+func (s *Server) advertiseAddr(addr net.Addr) (string, error) {
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return "", err
+	}
+	if s.advertiseHost != "" {
+		return net.JoinHostPort(s.advertiseHost, port), nil
+	}
+	if ip := net.ParseIP(host); ip != nil && !ip.IsUnspecified() {
+		return addr.String(), nil
+	}
+	ip, err := interfaceIP(s.advertiseInterface)
+	if err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(ip.String(), port), nil
+}
+
+// interfaceIP 从网卡中选择一个全局单播地址，优先 IPv4. name 为空时遍历所有已启用的非回环网卡，
+// 都没有可用地址时返回 127.0.0.1.
+func interfaceIP(name string) (net.IP, error) {
+	var ifaces []net.Interface
+	if name != "" {
+		iface, err := net.InterfaceByName(name)
+		if err != nil {
+			return nil, fmt.Errorf("grpc: advertise interface %q: %w", name, err)
+		}
+		ifaces = []net.Interface{*iface}
+	} else {
+		all, err := net.Interfaces()
+		if err != nil {
+			return nil, err
+		}
+		for _, iface := range all {
+			if iface.Flags&net.FlagUp != 0 && iface.Flags&net.FlagLoopback == 0 {
+				ifaces = append(ifaces, iface)
+			}
+		}
+	}
+
+	var v6 net.IP
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || !ipNet.IP.IsGlobalUnicast() {
+				continue
+			}
+			if ip4 := ipNet.IP.To4(); ip4 != nil {
+				return ip4, nil
+			}
+			if v6 == nil {
+				v6 = ipNet.IP
+			}
+		}
+	}
+	if v6 != nil {
+		return v6, nil
+	}
+	if name != "" {
+		return nil, fmt.Errorf("grpc: advertise interface %q has no global unicast address", name)
+	}
+	return net.IPv4(127, 0, 0, 1), nil
+}
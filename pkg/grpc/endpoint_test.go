@@ -0,0 +1,116 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestEndpoint(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "grpc.sock")
+	tests := []struct {
+		name   string
+		listen func(t *testing.T) net.Listener
+		opts   []ServerOption
+		// want 为空时不公布地址，包含 {port} 时替换为实际绑定的端口
+		want string
+	}{
+		{
+			name:   "tcp",
+			listen: listenTCP,
+			want:   "grpc://127.0.0.1:{port}",
+		},
+		{
+			name:   "tcp with advertise host",
+			listen: listenTCP,
+			opts:   []ServerOption{WithAdvertiseHost("demo.internal")},
+			want:   "grpc://demo.internal:{port}",
+		},
+		{
+			name: "unix",
+			listen: func(t *testing.T) net.Listener {
+				lis, err := net.Listen("unix", sock)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return lis
+			},
+			// 单端口模式的 gateway 没有可以公布的地址
+			opts: []ServerOption{WithGateway(true, ""), WithSinglePort(true)},
+			want: "unix://" + sock,
+		},
+		{
+			name: "in-process",
+			listen: func(t *testing.T) net.Listener {
+				return bufconn.Listen(1024)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lis := tt.listen(t)
+			t.Cleanup(func() { _ = lis.Close() })
+			srv := NewServer(append(tt.opts, WithLis(lis))...)
+
+			got := ""
+			if srv.Endpoint() != nil {
+				got = srv.Endpoint().String()
+			}
+			want := tt.want
+			if addr, ok := lis.Addr().(*net.TCPAddr); ok {
+				want = strings.ReplaceAll(want, "{port}", strconv.Itoa(addr.Port))
+			}
+			if got != want {
+				t.Fatalf("Endpoint() = %q, want %q", got, want)
+			}
+			if srv.singlePort && srv.GatewayEndpoint() != nil {
+				t.Fatalf("GatewayEndpoint() = %s, want nil", srv.GatewayEndpoint())
+			}
+		})
+	}
+}
+
+// TestUnixEndpointIsDialable 公布的 unix:// 地址可以直接作为 gRPC 客户端的目标地址
+func TestUnixEndpointIsDialable(t *testing.T) {
+	lis, err := net.Listen("unix", filepath.Join(t.TempDir(), "grpc.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(WithLis(lis))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = srv.Start(ctx) }()
+	<-srv.Ready()
+
+	conn, err := grpc.NewClient(srv.Endpoint().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	checkCtx, checkCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer checkCancel()
+	res, err := grpc_health_v1.NewHealthClient(conn).Check(checkCtx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.GetStatus() != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Fatalf("health status = %s, want SERVING", res.GetStatus())
+	}
+}
+
+func listenTCP(t *testing.T) net.Listener {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lis
+}
@@ -0,0 +1,160 @@
+package grpc
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+
+	pkghealth "github.com/yanking/app-skeleton/pkg/health"
+	"github.com/yanking/app-skeleton/pkg/log"
+
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// WithServiceChecks 将健康检查项绑定到某个 gRPC 服务（完整名称，如 demo.v1.UserService）.
+// 绑定的检查项失败（包括非关键检查项降级）时只将该服务置为 NOT_SERVING，其他服务不受影响.
+// 需要同时使用 WithHealthChecks.
+func WithServiceChecks(service string, checks ...string) ServerOption {
+	return func(s *Server) {
+		if s.serviceChecks == nil {
+			s.serviceChecks = make(map[string][]string)
+		}
+		s.serviceChecks[service] = append(s.serviceChecks[service], checks...)
+	}
+}
+
+// Services 返回注册在 gRPC 服务器上的业务服务名称，不包含 health 服务本身
+func (s *Server) Services() []string {
+	var services []string
+	for name := range s.GetServiceInfo() {
+		if name == grpc_health_v1.Health_ServiceDesc.ServiceName {
+			continue
+		}
+		services = append(services, name)
+	}
+	sort.Strings(services)
+	return services
+}
+
+// SetServingStatus 手动设置某个服务的状态，service 为空表示整个服务器.
+// 手动置为 NOT_SERVING 的服务不会被健康检查的结果恢复，直到再次调用 SetServingStatus(service, true).
+func (s *Server) SetServingStatus(service string, serving bool) error {
+	if service != "" && !slices.Contains(s.Services(), service) {
+		return fmt.Errorf("grpc: unknown service %q", service)
+	}
+
+	s.healthMu.Lock()
+	if serving {
+		delete(s.manualDown, service)
+	} else {
+		s.manualDown[service] = true
+	}
+	s.healthMu.Unlock()
+
+	log.Infof("[grpc] service %q manually set to serving=%t", service, serving)
+	s.syncHealth()
+	return nil
+}
+
+// ServingStatus 返回各服务当前的状态，key 为空字符串表示整个服务器
+func (s *Server) ServingStatus() map[string]grpc_health_v1.HealthCheckResponse_ServingStatus {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+	return maps.Clone(s.servingStatus)
+}
+
+// applyHealth 记录最新的 readiness 检查结果，并同步到 gRPC health 服务
+func (s *Server) applyHealth(report pkghealth.Report) {
+	s.healthMu.Lock()
+	s.lastReport = &report
+	s.healthMu.Unlock()
+	s.syncHealth()
+}
+
+// syncHealth 根据手动设置的状态和健康检查结果计算每个服务的状态：
+//   - 整个服务器的状态由 readiness 探针决定
+//   - 每个服务在整个服务器可用、绑定的检查项全部通过且没有被手动置为 NOT_SERVING 时为 SERVING
+func (s *Server) syncHealth() {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+
+	// 进入停止流程后状态保持为 NOT_SERVING
+	if s.healthDown {
+		return
+	}
+
+	overall := !s.manualDown[""]
+	if s.lastReport != nil && !s.lastReport.Healthy() {
+		overall = false
+	}
+
+	statuses := map[string]bool{"": overall}
+	for _, service := range s.Services() {
+		serving := overall && !s.manualDown[service]
+		for _, name := range s.serviceChecks[service] {
+			if !s.checkPassed(name) {
+				serving = false
+			}
+		}
+		statuses[service] = serving
+	}
+
+	for service, serving := range statuses {
+		status := grpc_health_v1.HealthCheckResponse_SERVING
+		if !serving {
+			status = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+		}
+		if prev, ok := s.servingStatus[service]; ok && prev != status && service != "" {
+			log.Infof("[grpc] health status of %s changed to %s", service, status)
+		}
+		s.servingStatus[service] = status
+		s.health.SetServingStatus(service, status)
+	}
+}
+
+// checkPassed 绑定的检查项在最近一次 readiness 结果中是否通过，尚无结果时视为通过
+func (s *Server) checkPassed(name string) bool {
+	if s.lastReport == nil {
+		return true
+	}
+	for _, c := range s.lastReport.Checks {
+		if c.Name == name {
+			return c.Status == pkghealth.StatusPass
+		}
+	}
+	return true
+}
+
+// validateServiceChecks 检查绑定的服务和检查项是否存在，只记录警告
+func (s *Server) validateServiceChecks() {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+
+	services := s.Services()
+	for service, checks := range s.serviceChecks {
+		if !slices.Contains(services, service) {
+			log.Warnf("[grpc] health checks bound to unknown service %q", service)
+		}
+		if s.lastReport == nil {
+			continue
+		}
+		for _, name := range checks {
+			if !slices.ContainsFunc(s.lastReport.Checks, func(r pkghealth.Result) bool { return r.Name == name }) {
+				log.Warnf("[grpc] service %s is bound to unknown readiness check %q", service, name)
+			}
+		}
+	}
+}
+
+// shutdownHealth 将所有服务置为 NOT_SERVING，之后的状态变化都会被忽略
+func (s *Server) shutdownHealth() {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+
+	s.healthDown = true
+	s.health.Shutdown()
+	for service := range s.servingStatus {
+		s.servingStatus[service] = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+}
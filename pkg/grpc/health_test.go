@@ -0,0 +1,170 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	v1 "github.com/yanking/app-skeleton/api/proto/gen/demo/v1"
+	pkghealth "github.com/yanking/app-skeleton/pkg/health"
+)
+
+var (
+	demoService = v1.DemoService_ServiceDesc.ServiceName
+	userService = v1.UserService_ServiceDesc.ServiceName
+)
+
+// startDemoServer 启动注册了 demo 服务的服务
+func startDemoServer(t *testing.T, opts ...ServerOption) (*Server, *grpc.ClientConn) {
+	t.Helper()
+	srv, conn := newBufconnServer(t, opts...)
+	v1.RegisterDemoServiceServer(srv.Server, v1.UnimplementedDemoServiceServer{})
+	v1.RegisterUserServiceServer(srv.Server, v1.UnimplementedUserServiceServer{})
+	startServer(t, srv)
+	return srv, conn
+}
+
+// servingStatus 各服务的状态，key 为空字符串表示整个服务器
+type servingStatus map[string]grpc_health_v1.HealthCheckResponse_ServingStatus
+
+const (
+	serving    = grpc_health_v1.HealthCheckResponse_SERVING
+	notServing = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+)
+
+// assertServing 校验 ServingStatus 和 gRPC health 服务返回的状态都与 want 一致
+func assertServing(t *testing.T, srv *Server, conn *grpc.ClientConn, want servingStatus) {
+	t.Helper()
+	got := srv.ServingStatus()
+	client := grpc_health_v1.NewHealthClient(conn)
+	for service, status := range want {
+		if got[service] != status {
+			t.Fatalf("ServingStatus()[%q] = %s, want %s", service, got[service], status)
+		}
+		res, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.GetStatus() != status {
+			t.Fatalf("Check(%q) = %s, want %s", service, res.GetStatus(), status)
+		}
+	}
+}
+
+func TestSetServingStatus(t *testing.T) {
+	srv, conn := startDemoServer(t)
+	assertServing(t, srv, conn, servingStatus{"": serving, demoService: serving, userService: serving})
+
+	if err := srv.SetServingStatus(userService, false); err != nil {
+		t.Fatal(err)
+	}
+	assertServing(t, srv, conn, servingStatus{"": serving, demoService: serving, userService: notServing})
+
+	// 整个服务器不可用时所有服务都不可用
+	if err := srv.SetServingStatus("", false); err != nil {
+		t.Fatal(err)
+	}
+	assertServing(t, srv, conn, servingStatus{"": notServing, demoService: notServing, userService: notServing})
+	if err := srv.SetServingStatus("", true); err != nil {
+		t.Fatal(err)
+	}
+	assertServing(t, srv, conn, servingStatus{"": serving, demoService: serving, userService: notServing})
+
+	if err := srv.SetServingStatus(userService, true); err != nil {
+		t.Fatal(err)
+	}
+	assertServing(t, srv, conn, servingStatus{"": serving, demoService: serving, userService: serving})
+
+	if err := srv.SetServingStatus("demo.v1.NoSuchService", false); err == nil {
+		t.Fatal("SetServingStatus() for an unknown service succeeded")
+	}
+}
+
+// switchCheck 结果可以在运行时切换的检查项
+type switchCheck struct{ failing atomic.Bool }
+
+func (c *switchCheck) check(ctx context.Context) error {
+	if c.failing.Load() {
+		return errors.New("down")
+	}
+	return nil
+}
+
+func TestServiceChecks(t *testing.T) {
+	db, cache, search := &switchCheck{}, &switchCheck{}, &switchCheck{}
+	checks := pkghealth.NewRegistry()
+	checks.MustRegister(pkghealth.Checker{Name: "db", Check: db.check, Critical: true})
+	checks.MustRegister(pkghealth.Checker{Name: "cache", Check: cache.check})
+	checks.MustRegister(pkghealth.Checker{Name: "search", Check: search.check})
+	srv, conn := startDemoServer(t,
+		WithHealthChecks(checks),
+		WithServiceChecks(userService, "cache"),
+		WithServiceChecks(demoService, "search"),
+	)
+
+	steps := []struct {
+		name  string
+		setup func()
+		want  servingStatus
+	}{
+		{"all pass", func() {}, servingStatus{"": serving, demoService: serving, userService: serving}},
+		{"bound check degrades", func() { cache.failing.Store(true) },
+			servingStatus{"": serving, demoService: serving, userService: notServing}},
+		// 探针整体状态仍为 warn，只有绑定的检查项发生变化
+		{"another bound check degrades", func() { search.failing.Store(true) },
+			servingStatus{"": serving, demoService: notServing, userService: notServing}},
+		{"bound check recovers while readiness is unchanged", func() { cache.failing.Store(false) },
+			servingStatus{"": serving, demoService: notServing, userService: serving}},
+		{"critical check fails", func() { db.failing.Store(true) },
+			servingStatus{"": notServing, demoService: notServing, userService: notServing}},
+		{"critical check recovers", func() { db.failing.Store(false); search.failing.Store(false) },
+			servingStatus{"": serving, demoService: serving, userService: serving}},
+	}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			step.setup()
+			checks.Check(context.Background(), pkghealth.Readiness)
+			assertServing(t, srv, conn, step.want)
+		})
+	}
+
+	// 手动置为 NOT_SERVING 的服务不会被检查结果恢复
+	if err := srv.SetServingStatus(userService, false); err != nil {
+		t.Fatal(err)
+	}
+	cache.failing.Store(true)
+	checks.Check(context.Background(), pkghealth.Readiness)
+	cache.failing.Store(false)
+	checks.Check(context.Background(), pkghealth.Readiness)
+	assertServing(t, srv, conn, servingStatus{"": serving, userService: notServing})
+
+	// 停止时所有服务置为 NOT_SERVING，之后的状态变化被忽略
+	if err := srv.PreStop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.SetServingStatus(userService, true); err != nil {
+		t.Fatal(err)
+	}
+	got := srv.ServingStatus()
+	for _, service := range []string{"", demoService, userService} {
+		if got[service] != notServing {
+			t.Fatalf("ServingStatus()[%q] after PreStop = %s, want NOT_SERVING", service, got[service])
+		}
+	}
+}
+
+func TestServices(t *testing.T) {
+	srv, _ := startDemoServer(t)
+	services := srv.Services()
+	if !slices.Contains(services, demoService) || !slices.Contains(services, userService) {
+		t.Fatalf("Services() = %v, want %s and %s", services, demoService, userService)
+	}
+	if slices.Contains(services, grpc_health_v1.Health_ServiceDesc.ServiceName) {
+		t.Fatalf("Services() = %v includes the health service", services)
+	}
+}
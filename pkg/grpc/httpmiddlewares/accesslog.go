@@ -0,0 +1,37 @@
+package httpmiddlewares
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/yanking/app-skeleton/pkg/log"
+)
+
+// AccessLog 每个请求结束后记录一条访问日志，5xx 使用 warn 级别
+func AccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		r, route := withRoute(r)
+		rw := newResponseWriter(w)
+		next.ServeHTTP(rw, r)
+
+		keyvals := []any{
+			"method", r.Method,
+			"path", r.URL.Path,
+			"route", routeOf(r, route),
+			"status", rw.Status(),
+			"bytes", rw.bytes,
+			"duration", time.Since(start).String(),
+			"remote", r.RemoteAddr,
+			"user_agent", r.UserAgent(),
+		}
+		if id := w.Header().Get(RequestIDHeader); id != "" {
+			keyvals = append(keyvals, "request_id", id)
+		}
+		if rw.Status() >= http.StatusInternalServerError {
+			log.Warnw("[gateway] access", keyvals...)
+			return
+		}
+		log.Infow("[gateway] access", keyvals...)
+	})
+}
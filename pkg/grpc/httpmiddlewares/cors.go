@@ -0,0 +1,84 @@
+package httpmiddlewares
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions 跨域资源共享配置
+type CORSOptions struct {
+	// Enabled 是否处理跨域请求
+	Enabled bool `json:"enabled" mapstructure:"enabled"`
+	// AllowedOrigins 允许的来源，* 表示允许所有来源
+	AllowedOrigins []string `json:"allowed-origins" mapstructure:"allowed-origins"`
+	// AllowedMethods 允许的方法，为空时允许 GET, POST, PUT, PATCH, DELETE
+	AllowedMethods []string `json:"allowed-methods" mapstructure:"allowed-methods"`
+	// AllowedHeaders 允许的请求头，为空时允许预检请求中声明的所有请求头
+	AllowedHeaders []string `json:"allowed-headers" mapstructure:"allowed-headers"`
+	// ExposedHeaders 允许浏览器读取的响应头
+	ExposedHeaders []string `json:"exposed-headers" mapstructure:"exposed-headers"`
+	// AllowCredentials 是否允许携带 cookie 等凭证，此时不会返回 *
+	AllowCredentials bool `json:"allow-credentials" mapstructure:"allow-credentials"`
+	// MaxAge 预检请求结果的缓存时间
+	MaxAge time.Duration `json:"max-age" mapstructure:"max-age"`
+}
+
+var defaultCORSMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// CORS 处理跨域请求，来源不在 AllowedOrigins 中的请求不添加任何 CORS 响应头，预检请求直接返回 204
+func CORS(opts CORSOptions) Middleware {
+	methods := opts.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(opts.AllowedHeaders, ", ")
+	exposeHeaders := strings.Join(opts.ExposedHeaders, ", ")
+	anyOrigin := slices.Contains(opts.AllowedOrigins, "*")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" || !(anyOrigin || slices.Contains(opts.AllowedOrigins, origin)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			if anyOrigin && !opts.AllowCredentials {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if opts.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if !preflight {
+				if exposeHeaders != "" {
+					h.Set("Access-Control-Expose-Headers", exposeHeaders)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", allowMethods)
+			if allowHeaders != "" {
+				h.Set("Access-Control-Allow-Headers", allowHeaders)
+			} else if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+				h.Set("Access-Control-Allow-Headers", requested)
+			}
+			if opts.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge/time.Second)))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
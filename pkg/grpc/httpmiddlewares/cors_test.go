@@ -0,0 +1,78 @@
+package httpmiddlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	restricted := CORSOptions{
+		AllowedOrigins: []string{"https://app.example.com"},
+		ExposedHeaders: []string{RequestIDHeader},
+		MaxAge:         10 * time.Minute,
+	}
+	anyOrigin := CORSOptions{AllowedOrigins: []string{"*"}, AllowedMethods: []string{http.MethodGet}, AllowedHeaders: []string{"Authorization"}}
+	credentials := CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true}
+
+	tests := []struct {
+		name   string
+		opts   CORSOptions
+		method string
+		header map[string]string
+		// wantNext 请求是否交给下一个处理程序，wantHeader 期望的响应头，值为空表示不应设置
+		wantNext   bool
+		wantHeader map[string]string
+	}{
+		{"no origin", restricted, http.MethodGet, nil, true, map[string]string{"Access-Control-Allow-Origin": ""}},
+		{"origin not allowed", restricted, http.MethodGet, map[string]string{"Origin": "https://evil.example.com"}, true,
+			map[string]string{"Access-Control-Allow-Origin": "", "Vary": ""}},
+		{"allowed origin", restricted, http.MethodGet, map[string]string{"Origin": "https://app.example.com"}, true, map[string]string{
+			"Access-Control-Allow-Origin": "https://app.example.com", "Access-Control-Expose-Headers": RequestIDHeader,
+			"Vary": "Origin", "Access-Control-Allow-Credentials": "",
+		}},
+		{"any origin", anyOrigin, http.MethodGet, map[string]string{"Origin": "https://app.example.com"}, true,
+			map[string]string{"Access-Control-Allow-Origin": "*"}},
+		// 携带凭证时不能返回 *
+		{"any origin with credentials", credentials, http.MethodGet, map[string]string{"Origin": "https://app.example.com"}, true,
+			map[string]string{"Access-Control-Allow-Origin": "https://app.example.com", "Access-Control-Allow-Credentials": "true"}},
+		{"preflight", restricted, http.MethodOptions, map[string]string{
+			"Origin": "https://app.example.com", "Access-Control-Request-Method": http.MethodPut, "Access-Control-Request-Headers": "Authorization, X-Custom",
+		}, false, map[string]string{
+			"Access-Control-Allow-Methods": "GET, POST, PUT, PATCH, DELETE", "Access-Control-Allow-Headers": "Authorization, X-Custom",
+			"Access-Control-Max-Age": "600", "Access-Control-Expose-Headers": "",
+		}},
+		{"preflight with allowed headers", anyOrigin, http.MethodOptions, map[string]string{
+			"Origin": "https://app.example.com", "Access-Control-Request-Method": http.MethodGet, "Access-Control-Request-Headers": "X-Custom",
+		}, false, map[string]string{
+			"Access-Control-Allow-Methods": "GET", "Access-Control-Allow-Headers": "Authorization", "Access-Control-Max-Age": "",
+		}},
+		{"options without request method", restricted, http.MethodOptions, map[string]string{"Origin": "https://app.example.com"}, true,
+			map[string]string{"Access-Control-Allow-Origin": "https://app.example.com", "Access-Control-Allow-Methods": ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			h := CORS(tt.opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+			req := httptest.NewRequest(tt.method, "/v1/users", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if called != tt.wantNext {
+				t.Fatalf("next handler called = %t, want %t", called, tt.wantNext)
+			}
+			if !tt.wantNext && rec.Code != http.StatusNoContent {
+				t.Fatalf("preflight status = %d, want 204", rec.Code)
+			}
+			for k, want := range tt.wantHeader {
+				if got := rec.Header().Get(k); got != want {
+					t.Fatalf("%s = %q, want %q", k, got, want)
+				}
+			}
+		})
+	}
+}
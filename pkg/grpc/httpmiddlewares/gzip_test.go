@@ -0,0 +1,101 @@
+package httpmiddlewares
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGzip(t *testing.T) {
+	body := strings.Repeat("hello, world. ", 100)
+	tests := []struct {
+		name           string
+		method         string
+		acceptEncoding string
+		handler        http.HandlerFunc
+		wantGzip       bool
+	}{
+		{"accepts gzip", http.MethodGet, "deflate, gzip;q=0.8", writeBody(body), true},
+		{"gzip refused", http.MethodGet, "gzip;q=0", writeBody(body), false},
+		{"no accept-encoding", http.MethodGet, "", writeBody(body), false},
+		{"head", http.MethodHead, "gzip", writeBody(""), false},
+		{"already encoded", http.MethodGet, "gzip", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "br")
+			_, _ = io.WriteString(w, body)
+		}, false},
+		{"no content", http.MethodGet, "gzip", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, false},
+	}
+	// 同一个中间件处理多个请求，复用压缩器
+	mw := Gzip(gzip.BestSpeed)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+			mw(tt.handler).ServeHTTP(rec, req)
+
+			if rec.Header().Get("Vary") != "Accept-Encoding" {
+				t.Fatalf("Vary = %q, want Accept-Encoding", rec.Header().Get("Vary"))
+			}
+			gzipped := rec.Header().Get("Content-Encoding") == "gzip"
+			if gzipped != tt.wantGzip {
+				t.Fatalf("Content-Encoding = %q, want gzip = %t", rec.Header().Get("Content-Encoding"), tt.wantGzip)
+			}
+			if !gzipped {
+				return
+			}
+			// 压缩后的长度未知，不能保留处理程序设置的 Content-Length
+			if rec.Header().Get("Content-Length") != "" {
+				t.Fatalf("Content-Length = %q on a compressed response", rec.Header().Get("Content-Length"))
+			}
+			zr, err := gzip.NewReader(rec.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(zr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != body {
+				t.Fatalf("decompressed body = %q", got)
+			}
+			// 压缩前根据内容检测 Content-Type
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+				t.Fatalf("Content-Type = %q, want the detected type of the uncompressed body", ct)
+			}
+		})
+	}
+}
+
+func writeBody(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		_, _ = io.WriteString(w, body)
+	}
+}
+
+// TestGzipFlush 流式响应每次 Flush 都输出已压缩的数据
+func TestGzipFlush(t *testing.T) {
+	rec := httptest.NewRecorder()
+	h := Gzip(gzip.DefaultCompression)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"result":1}`)
+		http.NewResponseController(w).Flush()
+		if rec.Body.Len() == 0 || !rec.Flushed {
+			t.Error("Flush() did not write the compressed data")
+		}
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	h.ServeHTTP(rec, req)
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", rec.Header().Get("Content-Encoding"))
+	}
+}
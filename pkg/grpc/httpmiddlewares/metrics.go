@@ -0,0 +1,46 @@
+package httpmiddlewares
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/yanking/app-skeleton/pkg/metric"
+)
+
+var (
+	serverNamespace = "http_server"
+	serverName      = os.Getenv("APP_NAME")
+
+	metricServerReqDur = metric.NewHistogramVec(&metric.HistogramVecOpts{
+		Namespace: serverNamespace,
+		Subsystem: "requests",
+		Name:      serverName + "_duration_ms",
+		Help:      "http server requests duration(ms).",
+		Labels:    []string{"method", "route"},
+		Buckets:   []float64{5, 10, 25, 50, 100, 250, 500, 1000},
+	})
+
+	metricServerReqCodeTotal = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: serverNamespace,
+		Subsystem: "requests",
+		Name:      serverName + "_code_total",
+		Help:      "http server requests code count.",
+		Labels:    []string{"method", "route", "code"},
+	})
+)
+
+// Metrics 按 HTTP 方法和路由模板统计请求耗时和状态码
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		r, route := withRoute(r)
+		rw := newResponseWriter(w)
+		next.ServeHTTP(rw, r)
+
+		path := routeOf(r, route)
+		metricServerReqDur.Observe(int64(time.Since(start)/time.Millisecond), r.Method, path)
+		metricServerReqCodeTotal.Inc(r.Method, path, strconv.Itoa(rw.Status()))
+	})
+}
@@ -0,0 +1,110 @@
+// Package httpmiddlewares 提供 gRPC-Gateway 及其他 HTTP 处理程序使用的中间件，通过 pkg/grpc 的 WithGatewayMiddleware 安装.
+package httpmiddlewares
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+// Middleware HTTP 中间件
+type Middleware = func(http.Handler) http.Handler
+
+// Chain 按顺序组合中间件，第一个中间件在最外层
+func Chain(h http.Handler, mws ...Middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+type routeKey struct{}
+
+// withRoute 在 context 中放入记录路由的位置，已经存在时直接返回
+func withRoute(r *http.Request) (*http.Request, *string) {
+	if route, ok := r.Context().Value(routeKey{}).(*string); ok {
+		return r, route
+	}
+	route := new(string)
+	return r.WithContext(context.WithValue(r.Context(), routeKey{}, route)), route
+}
+
+// routeOf 返回请求匹配的路由模板，如 /v1/users/{id=*}，没有匹配到时为 unmatched. 路由模板的数量有限，可以用作指标的标签
+func routeOf(r *http.Request, route *string) string {
+	if *route != "" {
+		return *route
+	}
+	if r.Pattern != "" {
+		return r.Pattern
+	}
+	return "unmatched"
+}
+
+// GatewayRoute 记录 gRPC-Gateway 匹配的路由模板，供 Metrics 和 AccessLog 使用. pkg/grpc 创建 gateway 的 mux 时自动安装
+func GatewayRoute(next runtime.HandlerFunc) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		if route, ok := r.Context().Value(routeKey{}).(*string); ok {
+			if pattern, ok := runtime.HTTPPattern(r.Context()); ok {
+				*route = pattern.String()
+			}
+		}
+		next(w, r, pathParams)
+	}
+}
+
+// responseWriter 记录响应的状态码和字节数
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func newResponseWriter(w http.ResponseWriter) *responseWriter {
+	if rw, ok := w.(*responseWriter); ok {
+		return rw
+	}
+	return &responseWriter{ResponseWriter: w}
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Status 返回响应的状态码，没有写入响应时为 200
+func (w *responseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Flush gRPC-Gateway 的流式接口每条消息之后调用 Flush
+func (w *responseWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,173 @@
+package httpmiddlewares
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	prom "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/yanking/app-skeleton/pkg/log"
+)
+
+func TestChain(t *testing.T) {
+	var order []string
+	mw := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	h := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { order = append(order, "handler") }),
+		mw("first"), mw("second"))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if strings.Join(order, ",") != "first,second,handler" {
+		t.Fatalf("order = %v, want the first middleware outermost", order)
+	}
+}
+
+// routedHandler 在 /v1/ 下挂载 gateway 的 mux，并通过 http.ServeMux 提供 /healthz
+func routedHandler(t *testing.T, mws ...Middleware) http.Handler {
+	t.Helper()
+	gateway := runtime.NewServeMux(runtime.WithMiddlewares(GatewayRoute))
+	err := gateway.HandlePath(http.MethodGet, "/v1/users/{id}", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = gateway.HandlePath(http.MethodGet, "/v1/unavailable", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/v1/", gateway)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("ok")) })
+	return Chain(mux, mws...)
+}
+
+// codeTotal 返回 Metrics 记录的指定路由和状态码的请求数
+func codeTotal(t *testing.T, route, code string) float64 {
+	t.Helper()
+	families, err := prom.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total float64
+	for _, family := range families {
+		if !strings.HasPrefix(family.GetName(), serverNamespace+"_requests_") || !strings.HasSuffix(family.GetName(), "_code_total") {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["route"] == route && labels["code"] == code {
+				total += m.GetCounter().GetValue()
+			}
+		}
+	}
+	return total
+}
+
+func TestMetrics(t *testing.T) {
+	h := routedHandler(t, Metrics)
+	tests := []struct {
+		target string
+		route  string
+		code   string
+	}{
+		// 路由模板而不是实际的路径，避免标签数量无限增长
+		{"/v1/users/42", "/v1/users/{id=*}", "201"},
+		{"/healthz", "GET /healthz", "200"},
+		{"/nothing", "unmatched", "404"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			before := codeTotal(t, tt.route, tt.code)
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if got := codeTotal(t, tt.route, tt.code) - before; got != 1 {
+				t.Fatalf("requests with route %q and code %s increased by %v, want 1", tt.route, tt.code, got)
+			}
+		})
+	}
+}
+
+// captureLog 将全局日志以 JSON 格式写入临时文件，返回读取已记录日志的函数
+func captureLog(t *testing.T) func() []map[string]any {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "access.log")
+	log.Init(&log.Options{Level: "info", Format: "json", OutputPaths: []string{path}})
+	t.Cleanup(func() { log.Init(log.NewOptions()) })
+	return func() []map[string]any {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var entries []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			var entry map[string]any
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Fatal(err)
+			}
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	entries := captureLog(t)
+	h := routedHandler(t, RequestID, Metrics, AccessLog)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/users/42", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/unavailable", nil))
+
+	got := entries()
+	if len(got) != 2 {
+		t.Fatalf("logged %d entries, want 2", len(got))
+	}
+	want := map[string]any{"level": "info", "method": "GET", "path": "/v1/users/42", "route": "/v1/users/{id=*}", "status": float64(201), "request_id": "req-1"}
+	for k, v := range want {
+		if got[0][k] != v {
+			t.Fatalf("access log %s = %v, want %v", k, got[0][k], v)
+		}
+	}
+	// 5xx 使用 warn 级别
+	if got[1]["level"] != "warn" || got[1]["status"] != float64(http.StatusServiceUnavailable) {
+		t.Fatalf("access log for a 5xx = %v", got[1])
+	}
+}
+
+func TestResponseWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := newResponseWriter(rec)
+	if newResponseWriter(rw) != rw {
+		t.Fatal("newResponseWriter() wrapped a responseWriter twice")
+	}
+	if rw.Status() != http.StatusOK {
+		t.Fatalf("Status() before writing = %d, want 200", rw.Status())
+	}
+	rw.WriteHeader(http.StatusAccepted)
+	rw.WriteHeader(http.StatusInternalServerError)
+	_, _ = rw.Write([]byte("hello"))
+	rw.Flush()
+	if rw.Status() != http.StatusAccepted || rw.bytes != 5 || !rec.Flushed {
+		t.Fatalf("status = %d, bytes = %d, flushed = %t", rw.Status(), rw.bytes, rec.Flushed)
+	}
+	if rw.Unwrap() != rec {
+		t.Fatal("Unwrap() did not return the underlying ResponseWriter")
+	}
+}
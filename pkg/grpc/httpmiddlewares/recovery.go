@@ -0,0 +1,27 @@
+package httpmiddlewares
+
+import (
+	"errors"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/yanking/app-skeleton/pkg/log"
+)
+
+// Recovery 捕获处理程序的 panic，记录堆栈并返回 500. http.ErrAbortHandler 按 net/http 的约定继续向上抛出
+func Recovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if err, ok := rec.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(rec)
+			}
+			log.Errorf("[gateway] panic serving %s %s: %+v\n \n %s", r.Method, r.URL.Path, rec, debug.Stack())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,43 @@
+package httpmiddlewares
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/yanking/app-skeleton/pkg/grpc/serverinterceptors"
+)
+
+// RequestIDHeader 请求 ID 的 HTTP 头
+const RequestIDHeader = "X-Request-Id"
+
+type requestIDKey struct{}
+
+// RequestID 使用请求中的 X-Request-Id，没有时生成一个，并在响应中返回
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" {
+			id = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// RequestIDFromContext 返回 RequestID 中间件设置的请求 ID
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestIDMetadata 将请求 ID 转发给 gRPC 服务，与 serverinterceptors 使用相同的 metadata key，
+// 通过 runtime.WithMetadata(RequestIDMetadata) 安装
+func RequestIDMetadata(ctx context.Context, _ *http.Request) metadata.MD {
+	if id := RequestIDFromContext(ctx); id != "" {
+		return metadata.Pairs(serverinterceptors.ContextRequestIDKey, id)
+	}
+	return nil
+}
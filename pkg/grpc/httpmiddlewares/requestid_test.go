@@ -0,0 +1,45 @@
+package httpmiddlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/yanking/app-skeleton/pkg/grpc/serverinterceptors"
+)
+
+func TestRequestID(t *testing.T) {
+	var id string
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = RequestIDFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if id != "req-1" || rec.Header().Get(RequestIDHeader) != "req-1" {
+		t.Fatalf("request id = %q, response header = %q, want the id from the request", id, rec.Header().Get(RequestIDHeader))
+	}
+
+	// 请求中没有时生成新的 ID
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if _, err := uuid.Parse(id); err != nil || rec.Header().Get(RequestIDHeader) != id {
+		t.Fatalf("generated request id = %q, response header = %q", id, rec.Header().Get(RequestIDHeader))
+	}
+}
+
+func TestRequestIDMetadata(t *testing.T) {
+	if md := RequestIDMetadata(context.Background(), nil); md != nil {
+		t.Fatalf("RequestIDMetadata() without a request id = %v, want nil", md)
+	}
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-1")
+	md := RequestIDMetadata(ctx, nil)
+	if got := md.Get(serverinterceptors.ContextRequestIDKey); len(got) != 1 || got[0] != "req-1" {
+		t.Fatalf("RequestIDMetadata() = %v", md)
+	}
+}
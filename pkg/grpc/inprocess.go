@@ -0,0 +1,178 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// inProcessBufferSize 进程内连接的缓冲区大小
+const inProcessBufferSize = 1 << 20
+
+// GatewayRegisterFunc 将 gRPC-Gateway 的处理程序注册到 mux，与生成的 RegisterXXXHandler 函数签名相同
+type GatewayRegisterFunc func(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error
+
+// RegisterGateway 将 gRPC-Gateway 的处理程序通过进程内连接注册到当前服务：
+//
+//	srv.RegisterGateway(ctx, v1.RegisterDemoServiceHandler, v1.RegisterUserServiceHandler)
+//
+// 请求不经过网络，与 gRPC 监听地址及是否启用 TLS 无关，同时经过完整的拦截器链，与原生 gRPC 请求一样进行认证、统计和超时控制.
+func (s *Server) RegisterGateway(ctx context.Context, fns ...GatewayRegisterFunc) error {
+	if !s.enableGateway {
+		return fmt.Errorf("grpc: gateway is not enabled")
+	}
+	if s.gatewayConn == nil {
+		conn, err := grpc.NewClient("passthrough:///inprocess",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return s.inProcessLis.DialContext(ctx)
+			}),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		if err != nil {
+			return err
+		}
+		s.gatewayConn = conn
+	}
+	for _, fn := range fns {
+		if err := fn(ctx, s.gatewayMux, s.gatewayConn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inProcessAddr 进程内连接的地址
+type inProcessAddr struct{}
+
+func (inProcessAddr) Network() string { return "inprocess" }
+func (inProcessAddr) String() string  { return "inprocess" }
+
+// inProcessListener 接收 gRPC-Gateway 的进程内连接
+type inProcessListener struct {
+	*bufconn.Listener
+}
+
+func newInProcessListener() *inProcessListener {
+	return &inProcessListener{Listener: bufconn.Listen(inProcessBufferSize)}
+}
+
+func (l *inProcessListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return inProcessConn{conn}, nil
+}
+
+func (l *inProcessListener) Addr() net.Addr { return inProcessAddr{} }
+
+type inProcessConn struct {
+	net.Conn
+}
+
+func (inProcessConn) LocalAddr() net.Addr  { return inProcessAddr{} }
+func (inProcessConn) RemoteAddr() net.Addr { return inProcessAddr{} }
+
+// inProcessCredentials 进程内连接不进行 TLS 握手，其余连接使用原有的证书
+type inProcessCredentials struct {
+	credentials.TransportCredentials
+}
+
+func (c inProcessCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	if _, ok := conn.(inProcessConn); ok {
+		return insecure.NewCredentials().ServerHandshake(conn)
+	}
+	return c.TransportCredentials.ServerHandshake(conn)
+}
+
+func (c inProcessCredentials) Clone() credentials.TransportCredentials {
+	return inProcessCredentials{c.TransportCredentials.Clone()}
+}
+
+// WithTrustedProxies 设置 gRPC-Gateway 前面可信的代理（如负载均衡器）的地址，可以使用 ParseTrustedProxies 解析配置.
+// 进程内请求的 peer 从 x-forwarded-for 中从右向左选出第一个不属于可信代理的地址，没有设置时为 HTTP 连接的地址
+func WithTrustedProxies(proxies ...netip.Prefix) ServerOption {
+	return func(s *Server) {
+		s.trustedProxies = append(s.trustedProxies, proxies...)
+	}
+}
+
+// ParseTrustedProxies 解析可信代理的地址，支持 CIDR（如 10.0.0.0/8）和单个 IP
+func ParseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, p := range proxies {
+		if prefix, err := netip.ParsePrefix(p); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(p)
+		if err != nil {
+			return nil, fmt.Errorf("grpc: invalid trusted proxy %q, must be an IP or CIDR", p)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// gatewayPeer 进程内的请求使用 gRPC-Gateway 转发的 x-forwarded-for 作为 peer.
+// gRPC-Gateway 总是将 HTTP 连接的地址追加为最后一个 x-forwarded-for，因此最后一个值是可信的，
+// 之前的值由客户端或上游代理提供，只有其右侧的地址属于 trusted 时才被采用，避免客户端伪造来源地址
+func gatewayPeer(ctx context.Context, trusted []netip.Prefix) context.Context {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil || p.Addr.Network() != (inProcessAddr{}).Network() {
+		return ctx
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("x-forwarded-for")
+	if len(values) == 0 {
+		return ctx
+	}
+
+	var client netip.Addr
+	forwarded := strings.Split(values[len(values)-1], ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			break
+		}
+		client = addr.Unmap()
+		if !slices.ContainsFunc(trusted, func(prefix netip.Prefix) bool { return prefix.Contains(client) }) {
+			break
+		}
+	}
+	if !client.IsValid() {
+		return ctx
+	}
+	gp := *p
+	gp.Addr = net.TCPAddrFromAddrPort(netip.AddrPortFrom(client, 0))
+	return peer.NewContext(ctx, &gp)
+}
+
+func (s *Server) gatewayPeerUnaryInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return handler(gatewayPeer(ctx, s.trustedProxies), req)
+}
+
+func (s *Server) gatewayPeerStreamInterceptor(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &gatewayPeerStream{ServerStream: ss, ctx: gatewayPeer(ss.Context(), s.trustedProxies)})
+}
+
+type gatewayPeerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *gatewayPeerStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,180 @@
+package grpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"math/big"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	v1 "github.com/yanking/app-skeleton/api/proto/gen/demo/v1"
+)
+
+// peerEcho 返回调用方的 peer 地址
+type peerEcho struct {
+	v1.UnimplementedDemoServiceServer
+}
+
+func (peerEcho) Echo(ctx context.Context, req *v1.EchoRequest) (*v1.EchoResponse, error) {
+	p, _ := peer.FromContext(ctx)
+	return &v1.EchoResponse{Value: req.GetValue() + " from " + p.Addr.String()}, nil
+}
+
+// startGatewayServer 启动注册了 peerEcho 和 gRPC-Gateway 的服务，返回 gateway 的 HTTP 地址
+func startGatewayServer(t *testing.T, opts ...ServerOption) (*Server, string) {
+	t.Helper()
+	srv, _ := newBufconnServer(t, append([]ServerOption{WithGateway(true, "127.0.0.1:0")}, opts...)...)
+	v1.RegisterDemoServiceServer(srv.Server, peerEcho{})
+	if err := srv.RegisterGateway(context.Background(), v1.RegisterDemoServiceHandler); err != nil {
+		t.Fatal(err)
+	}
+	startServer(t, srv)
+	return srv, srv.gatewayLis.Addr().String()
+}
+
+// echo 通过 gateway 调用 Echo，返回响应中的 value
+func echo(t *testing.T, addr string, header http.Header) string {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, "http://"+addr+"/v1/demo/echo", strings.NewReader(`{"value":"hello"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var body v1.EchoResponse
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", res.StatusCode)
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	return body.GetValue()
+}
+
+func TestGatewayInProcess(t *testing.T) {
+	_, addr := startGatewayServer(t)
+	// 请求经过进程内连接，peer 为 HTTP 客户端的地址
+	if got := echo(t, addr, nil); got != "hello from 127.0.0.1:0" {
+		t.Fatalf("Echo() = %q, want the HTTP client address as peer", got)
+	}
+
+	// 没有配置可信代理时忽略客户端提供的 X-Forwarded-For
+	spoofed := http.Header{"X-Forwarded-For": {"203.0.113.7"}, "Grpc-Metadata-X-Forwarded-For": {"198.51.100.1"}}
+	if got := echo(t, addr, spoofed); got != "hello from 127.0.0.1:0" {
+		t.Fatalf("Echo() with a spoofed X-Forwarded-For = %q", got)
+	}
+}
+
+func TestGatewayTrustedProxies(t *testing.T) {
+	_, addr := startGatewayServer(t, WithTrustedProxies(netip.MustParsePrefix("127.0.0.0/8")))
+	got := echo(t, addr, http.Header{"X-Forwarded-For": {"203.0.113.7"}})
+	if got != "hello from 203.0.113.7:0" {
+		t.Fatalf("Echo() behind a trusted proxy = %q, want the forwarded address", got)
+	}
+}
+
+// selfSignedTLS 返回使用自签名证书的服务端 TLS 配置
+func selfSignedTLS(t *testing.T) *tls.Config {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+}
+
+// TestGatewaySkipsTLS gRPC 服务启用 TLS 时，gateway 的进程内连接不进行 TLS 握手
+func TestGatewaySkipsTLS(t *testing.T) {
+	_, addr := startGatewayServer(t, WithTLSConfig(selfSignedTLS(t)))
+	if got := echo(t, addr, nil); got != "hello from 127.0.0.1:0" {
+		t.Fatalf("Echo() = %q", got)
+	}
+}
+
+func TestGatewayPeer(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.0.2.1/32")}
+	inProcess := &peer.Peer{Addr: inProcessAddr{}}
+	tests := []struct {
+		name    string
+		peer    *peer.Peer
+		xff     []string
+		trusted []netip.Prefix
+		// want 为空时 peer 保持不变
+		want string
+	}{
+		{"connection address", inProcess, []string{"203.0.113.7"}, nil, "203.0.113.7:0"},
+		{"untrusted forwarded address is ignored", inProcess, []string{"198.51.100.1, 203.0.113.7"}, nil, "203.0.113.7:0"},
+		{"trusted proxy", inProcess, []string{"198.51.100.1, 10.1.2.3"}, trusted, "198.51.100.1:0"},
+		{"chain of trusted proxies", inProcess, []string{"198.51.100.1, 192.0.2.1, 10.1.2.3"}, trusted, "198.51.100.1:0"},
+		{"spoofed address left of an untrusted hop", inProcess, []string{"198.51.100.1, 203.0.113.7, 10.1.2.3"}, trusted, "203.0.113.7:0"},
+		{"only the last value is used", inProcess, []string{"198.51.100.1", "203.0.113.7"}, nil, "203.0.113.7:0"},
+		{"invalid address left of a trusted proxy", inProcess, []string{"unknown, 10.1.2.3"}, trusted, "10.1.2.3:0"},
+		{"ipv4-mapped ipv6", inProcess, []string{"198.51.100.1, ::ffff:10.1.2.3"}, trusted, "198.51.100.1:0"},
+		{"invalid connection address", inProcess, []string{"unknown"}, nil, ""},
+		{"no x-forwarded-for", inProcess, nil, nil, ""},
+		{"not an in-process request", &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 1, 2, 3), Port: 5000}}, []string{"203.0.113.7"}, trusted, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), tt.peer)
+			md := metadata.MD{}
+			for _, v := range tt.xff {
+				md.Append("x-forwarded-for", v)
+			}
+			ctx = metadata.NewIncomingContext(ctx, md)
+
+			p, _ := peer.FromContext(gatewayPeer(ctx, tt.trusted))
+			want := tt.peer.Addr.String()
+			if tt.want != "" {
+				want = tt.want
+			}
+			if p.Addr.String() != want {
+				t.Fatalf("peer = %s, want %s", p.Addr, want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	prefixes, err := ParseTrustedProxies([]string{"10.1.2.3/8", "192.0.2.1", "::ffff:192.0.2.2", "2001:db8::/32"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"10.0.0.0/8", "192.0.2.1/32", "192.0.2.2/32", "2001:db8::/32"}
+	for i, p := range prefixes {
+		if p.String() != want[i] {
+			t.Fatalf("ParseTrustedProxies() = %v, want %v", prefixes, want)
+		}
+	}
+	if _, err := ParseTrustedProxies([]string{"proxy.internal"}); err == nil {
+		t.Fatal("ParseTrustedProxies() accepted a host name")
+	}
+}
@@ -0,0 +1,103 @@
+package grpc
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/yanking/app-skeleton/pkg/grpc/httpmiddlewares"
+	"github.com/yanking/app-skeleton/pkg/log"
+)
+
+// WithSinglePort 在 gRPC 的监听地址上同时提供 gRPC、gRPC-Gateway 和 WithHTTPHandler 注册的 HTTP 服务，
+// 按 HTTP/2 和 content-type 区分 gRPC 请求，明文连接使用 h2c. WithGateway 的地址和 WithGatewayTLSConfig 被忽略，
+// 启用 TLS 时使用 WithTLSConfig 的证书.
+//
+// 单端口模式下 gRPC 请求由 net/http 处理（grpc.Server.ServeHTTP），keepalive、MaxConcurrentStreams 等
+// 连接相关的 grpc.ServerOption 不生效.
+func WithSinglePort(enable bool) ServerOption {
+	return func(s *Server) {
+		s.singlePort = enable
+	}
+}
+
+// WithHTTPHandler 注册额外的 HTTP 处理程序，pattern 与 http.ServeMux 相同，优先于 gRPC-Gateway 的路由.
+// 在启用 gRPC-Gateway 或单端口模式时生效.
+func WithHTTPHandler(pattern string, handler http.Handler) ServerOption {
+	return func(s *Server) {
+		s.httpHandlers = append(s.httpHandlers, httpHandler{pattern: pattern, handler: handler})
+	}
+}
+
+type httpHandler struct {
+	pattern string
+	handler http.Handler
+}
+
+// httpServer 创建提供 HTTP 服务的 http.Server，双端口模式下监听 gateway 的地址，单端口模式下与 gRPC 共用监听器
+func (s *Server) httpServer() *http.Server {
+	var handler http.Handler = http.NotFoundHandler()
+	if s.gatewayMux != nil {
+		handler = s.gatewayMux
+	}
+	if len(s.httpHandlers) > 0 {
+		mux := http.NewServeMux()
+		for _, h := range s.httpHandlers {
+			mux.Handle(h.pattern, h.handler)
+		}
+		mux.Handle("/", handler)
+		handler = mux
+	}
+	handler = httpmiddlewares.Chain(handler, s.gatewayMiddlewares...)
+
+	if !s.singlePort {
+		return &http.Server{
+			Addr:              s.gatewayAddr,
+			Handler:           handler,
+			TLSConfig:         s.gatewayTLS,
+			ReadHeaderTimeout: s.gatewayTimeout,
+			ReadTimeout:       s.gatewayTimeout,
+			WriteTimeout:      s.gatewayTimeout,
+			IdleTimeout:       2 * s.gatewayTimeout,
+		}
+	}
+
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	// gRPC 客户端在明文连接上直接使用 HTTP/2（h2c prior knowledge）
+	protocols.SetUnencryptedHTTP2(s.tlsConfig == nil)
+	return &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isGRPCRequest(r) {
+				s.Server.ServeHTTP(w, r)
+				return
+			}
+			handler.ServeHTTP(w, r)
+		}),
+		TLSConfig:         s.tlsConfig,
+		Protocols:         protocols,
+		ReadHeaderTimeout: s.gatewayTimeout,
+		IdleTimeout:       2 * s.gatewayTimeout,
+	}
+}
+
+// isGRPCRequest gRPC 请求总是使用 HTTP/2，content-type 为 application/grpc 或 application/grpc+proto 等
+func isGRPCRequest(r *http.Request) bool {
+	return r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
+// serveSinglePort 在 gRPC 的监听器上提供所有服务，直到 Stop 关闭 HTTP 服务
+func (s *Server) serveSinglePort() error {
+	log.Infof("[grpc] serving grpc and http on: %s", s.lis.Addr().String())
+	var err error
+	if s.tlsConfig != nil {
+		err = s.gatewayServer.ServeTLS(s.lis, "", "")
+	} else {
+		err = s.gatewayServer.Serve(s.lis)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,171 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	v1 "github.com/yanking/app-skeleton/api/proto/gen/demo/v1"
+)
+
+func TestSinglePort(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(WithLis(lis), WithSinglePort(true), WithGateway(true, ""),
+		WithHTTPHandler("/hello", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, "hello "+r.Proto)
+		})))
+	v1.RegisterDemoServiceServer(srv.Server, peerEcho{})
+	if err := srv.RegisterGateway(context.Background(), v1.RegisterDemoServiceHandler); err != nil {
+		t.Fatal(err)
+	}
+	startServer(t, srv)
+	addr := lis.Addr().String()
+
+	t.Run("grpc over h2c", func(t *testing.T) {
+		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		res, err := v1.NewDemoServiceClient(conn).Echo(context.Background(), &v1.EchoRequest{Value: "hello"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(res.GetValue(), "hello from 127.0.0.1:") {
+			t.Fatalf("Echo() = %q", res.GetValue())
+		}
+		health, err := grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		if err != nil || health.GetStatus() != serving {
+			t.Fatalf("Check() = %v, %v", health, err)
+		}
+	})
+
+	t.Run("gateway over http/1.1", func(t *testing.T) {
+		if got := echo(t, addr, nil); got != "hello from 127.0.0.1:0" {
+			t.Fatalf("Echo() = %q", got)
+		}
+	})
+
+	t.Run("http handler", func(t *testing.T) {
+		for _, tt := range []struct {
+			name      string
+			transport *http.Transport
+			want      string
+		}{
+			{"http/1.1", &http.Transport{}, "hello HTTP/1.1"},
+			// 非 gRPC 的 HTTP/2 请求同样由 HTTP 处理程序处理
+			{"h2c", h2cTransport(), "hello HTTP/2.0"},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				defer tt.transport.CloseIdleConnections()
+				res, err := (&http.Client{Transport: tt.transport}).Get("http://" + addr + "/hello")
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer res.Body.Close()
+				body, _ := io.ReadAll(res.Body)
+				if res.StatusCode != http.StatusOK || string(body) != tt.want {
+					t.Fatalf("status = %d, body = %q, want %q", res.StatusCode, body, tt.want)
+				}
+			})
+		}
+	})
+}
+
+// h2cTransport 在明文连接上直接使用 HTTP/2 的 Transport
+func h2cTransport() *http.Transport {
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	return &http.Transport{Protocols: protocols}
+}
+
+func TestIsGRPCRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		protoMajor  int
+		contentType string
+		want        bool
+	}{
+		{"grpc", 2, "application/grpc", true},
+		{"grpc proto", 2, "application/grpc+proto", true},
+		{"grpc web over http/1.1", 1, "application/grpc-web", false},
+		{"grpc content type over http/1.1", 1, "application/grpc", false},
+		{"json over http/2", 2, "application/json", false},
+		{"no content type", 2, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/demo.v1.DemoService/Echo", nil)
+			r.ProtoMajor = tt.protoMajor
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			if got := isGRPCRequest(r); got != tt.want {
+				t.Fatalf("isGRPCRequest() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGatewayTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []ServerOption
+		// 期望的读取请求头、读取、写入和空闲超时
+		wantReadHeader, wantRead, wantWrite, wantIdle time.Duration
+	}{
+		{"no timeout", []ServerOption{WithGateway(true, "127.0.0.1:0")}, 0, 0, 0, 0},
+		{"gateway", []ServerOption{WithGateway(true, "127.0.0.1:0"), WithGatewayTimeout(2 * time.Second)},
+			2 * time.Second, 2 * time.Second, 2 * time.Second, 4 * time.Second},
+		// 单端口模式下不限制读写，避免中断长时间的 gRPC 流
+		{"single port", []ServerOption{WithSinglePort(true), WithGatewayTimeout(2 * time.Second)},
+			2 * time.Second, 0, 0, 4 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := newBufconnServer(t, tt.opts...)
+			if srv.gatewayLis != nil {
+				t.Cleanup(func() { _ = srv.gatewayLis.Close() })
+			}
+			hs := srv.gatewayServer
+			if hs.ReadHeaderTimeout != tt.wantReadHeader || hs.ReadTimeout != tt.wantRead ||
+				hs.WriteTimeout != tt.wantWrite || hs.IdleTimeout != tt.wantIdle {
+				t.Fatalf("timeouts = %s, %s, %s, %s, want %s, %s, %s, %s",
+					hs.ReadHeaderTimeout, hs.ReadTimeout, hs.WriteTimeout, hs.IdleTimeout,
+					tt.wantReadHeader, tt.wantRead, tt.wantWrite, tt.wantIdle)
+			}
+		})
+	}
+}
+
+// TestGatewayTimeoutClosesSlowConnection 在超时时间内没有发送完请求头的连接被关闭
+func TestGatewayTimeoutClosesSlowConnection(t *testing.T) {
+	_, addr := startGatewayServer(t, WithGatewayTimeout(100*time.Millisecond))
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := io.WriteString(conn, "GET /v1/demo/echo HTTP/1.1\r\nHost: localhost\r\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	start := time.Now()
+	_, _ = io.ReadAll(conn)
+	if elapsed := time.Since(start); elapsed >= 5*time.Second {
+		t.Fatal("connection with incomplete headers was not closed")
+	}
+}
@@ -0,0 +1,75 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/yanking/app-skeleton/pkg/log"
+	"github.com/yanking/app-skeleton/pkg/registry"
+	"github.com/yanking/app-skeleton/pkg/version"
+)
+
+// WithRegistrar 在 Start 时将服务注册到注册中心，在 PreStop 或 Stop 时注销.
+// instance 的 Name 必须设置；ID 为空时使用主机名和 Endpoint 的地址；Version 为空时使用 pkg/version 的版本；
+// Endpoints 为空时使用 Endpoint()，启用 gRPC-Gateway 时再加上 GatewayEndpoint()，监听器没有对外公布的地址时必须设置.
+func WithRegistrar(r registry.Registrar, instance *registry.ServiceInstance) ServerOption {
+	return func(s *Server) {
+		s.registrar = r
+		s.instance = instance
+	}
+}
+
+// Instance 返回注册到注册中心的实例，没有设置 WithRegistrar 时为 nil
+func (s *Server) Instance() *registry.ServiceInstance {
+	return s.instance
+}
+
+// register 将服务注册到注册中心
+func (s *Server) register(ctx context.Context) error {
+	if s.registrar == nil {
+		return nil
+	}
+	if s.instance.Name == "" {
+		return fmt.Errorf("grpc: registry instance name is empty")
+	}
+	if len(s.instance.Endpoints) == 0 && s.endpoint == nil {
+		return fmt.Errorf("grpc: listener %s has no advertised endpoint, set the instance endpoints", s.lis.Addr())
+	}
+	if len(s.instance.Endpoints) == 0 {
+		s.instance.Endpoints = []string{s.endpoint.String()}
+		if s.gatewayEndpoint != nil {
+			s.instance.Endpoints = append(s.instance.Endpoints, s.gatewayEndpoint.String())
+		}
+	}
+	if s.instance.ID == "" {
+		hostname, _ := os.Hostname()
+		addr := s.lis.Addr().String()
+		if s.endpoint != nil && s.endpoint.Host != "" {
+			addr = s.endpoint.Host
+		}
+		s.instance.ID = fmt.Sprintf("%s-%s", hostname, addr)
+	}
+	if s.instance.Version == "" {
+		s.instance.Version = version.Get().GitVersion
+	}
+
+	if err := s.registrar.Register(ctx, s.instance); err != nil {
+		return fmt.Errorf("grpc: register %s: %w", s.instance.Name, err)
+	}
+	s.registered.Store(true)
+	log.Infof("[grpc] registered %s (%s) with endpoints %v", s.instance.Name, s.instance.ID, s.instance.Endpoints)
+	return nil
+}
+
+// deregister 从注册中心注销，只会执行一次. 在停止接收请求之前注销，让客户端尽早摘除该实例.
+func (s *Server) deregister(ctx context.Context) {
+	if s.registrar == nil || !s.registered.CompareAndSwap(true, false) {
+		return
+	}
+	if err := s.registrar.Deregister(ctx, s.instance); err != nil {
+		log.Warnf("[grpc] deregister %s (%s): %v", s.instance.Name, s.instance.ID, err)
+		return
+	}
+	log.Infof("[grpc] deregistered %s (%s)", s.instance.Name, s.instance.ID)
+}
@@ -0,0 +1,135 @@
+// Package resolver 提供基于 registry.Discovery 的 gRPC resolver，目标地址形如 discovery:///demo-server.
+package resolver
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/attributes"
+	"google.golang.org/grpc/resolver"
+
+	"github.com/yanking/app-skeleton/pkg/log"
+	"github.com/yanking/app-skeleton/pkg/registry"
+)
+
+// Scheme 服务发现使用的 target scheme
+const Scheme = "discovery"
+
+// retryInterval Watcher 出错后重试的间隔
+const retryInterval = time.Second
+
+type weightKey struct{}
+
+// Weight 返回地址上由注册中心的 weight 元数据设置的权重，没有设置时为 1
+func Weight(addr resolver.Address) int {
+	if w, ok := addr.BalancerAttributes.Value(weightKey{}).(int); ok && w > 0 {
+		return w
+	}
+	return 1
+}
+
+type builder struct {
+	discovery registry.Discovery
+	insecure  bool
+}
+
+// NewBuilder 创建 resolver.Builder. insecure 为 true 时选择 grpc:// 地址，否则选择 grpcs:// 地址.
+func NewBuilder(discovery registry.Discovery, insecure bool) resolver.Builder {
+	return &builder{discovery: discovery, insecure: insecure}
+}
+
+func (b *builder) Scheme() string {
+	return Scheme
+}
+
+func (b *builder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	name := strings.TrimPrefix(target.URL.Path, "/")
+	if name == "" {
+		name = target.URL.Host
+	}
+	if name == "" {
+		return nil, errors.New("resolver: service name is empty, the target should look like discovery:///service-name")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w, err := b.discovery.Watch(ctx, name)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	r := &discoveryResolver{name: name, watcher: w, cc: cc, ctx: ctx, cancel: cancel, insecure: b.insecure}
+	go r.watch()
+	return r, nil
+}
+
+type discoveryResolver struct {
+	name     string
+	watcher  registry.Watcher
+	cc       resolver.ClientConn
+	ctx      context.Context
+	cancel   context.CancelFunc
+	insecure bool
+}
+
+func (r *discoveryResolver) watch() {
+	for {
+		instances, err := r.watcher.Next()
+		if err != nil {
+			if r.ctx.Err() != nil || errors.Is(err, registry.ErrWatcherStopped) {
+				return
+			}
+			log.Warnf("[resolver] watch %s: %v", r.name, err)
+			r.cc.ReportError(err)
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-time.After(retryInterval):
+			}
+			continue
+		}
+		r.update(instances)
+	}
+}
+
+func (r *discoveryResolver) update(instances []*registry.ServiceInstance) {
+	scheme := "grpcs"
+	if r.insecure {
+		scheme = "grpc"
+	}
+
+	var addrs []resolver.Address
+	for _, instance := range instances {
+		for _, endpoint := range instance.Endpoints {
+			u, err := url.Parse(endpoint)
+			if err != nil || u.Scheme != scheme || u.Host == "" {
+				continue
+			}
+			weight, _ := strconv.Atoi(instance.Metadata[registry.MetadataWeight])
+			addrs = append(addrs, resolver.Address{
+				Addr:               u.Host,
+				BalancerAttributes: attributes.New(weightKey{}, weight),
+			})
+		}
+	}
+
+	// 注册中心短暂异常时可能返回空列表，保留之前的地址，避免摘除所有连接
+	if len(addrs) == 0 {
+		log.Warnf("[resolver] no %s endpoints found for %s, keeping the previous addresses", scheme, r.name)
+		return
+	}
+	if err := r.cc.UpdateState(resolver.State{Addresses: addrs}); err != nil {
+		log.Warnf("[resolver] update state for %s: %v", r.name, err)
+	}
+}
+
+func (r *discoveryResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (r *discoveryResolver) Close() {
+	r.cancel()
+	_ = r.watcher.Stop()
+}
@@ -0,0 +1,127 @@
+package resolver
+
+import (
+	"context"
+	"maps"
+	"net/url"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+
+	"github.com/yanking/app-skeleton/pkg/registry"
+)
+
+// fakeClientConn 记录 resolver 推送的地址
+type fakeClientConn struct {
+	resolver.ClientConn
+	states chan resolver.State
+}
+
+func (c *fakeClientConn) UpdateState(s resolver.State) error {
+	c.states <- s
+	return nil
+}
+
+func (c *fakeClientConn) ReportError(error) {}
+
+// build 为 discovery:///demo 创建 resolver
+func build(t *testing.T, discovery registry.Discovery, insecure bool) *fakeClientConn {
+	t.Helper()
+	u, err := url.Parse("discovery:///demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc := &fakeClientConn{states: make(chan resolver.State, 10)}
+	r, err := NewBuilder(discovery, insecure).Build(resolver.Target{URL: *u}, cc, resolver.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(r.Close)
+	return cc
+}
+
+// addrs 等待下一次推送的地址，返回地址和对应的权重
+func (c *fakeClientConn) addrs(t *testing.T) map[string]int {
+	t.Helper()
+	select {
+	case s := <-c.states:
+		got := make(map[string]int)
+		for _, addr := range s.Addresses {
+			got[addr.Addr] = Weight(addr)
+		}
+		return got
+	case <-time.After(5 * time.Second):
+		t.Fatal("resolver did not update the addresses")
+		return nil
+	}
+}
+
+// noUpdate 确认 resolver 没有推送新的地址
+func (c *fakeClientConn) noUpdate(t *testing.T) {
+	t.Helper()
+	select {
+	case s := <-c.states:
+		t.Fatalf("unexpected update %v", s.Addresses)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func instance(id string, weight string, endpoints ...string) *registry.ServiceInstance {
+	return &registry.ServiceInstance{
+		ID: id, Name: "demo", Endpoints: endpoints, Metadata: map[string]string{registry.MetadataWeight: weight},
+	}
+}
+
+func TestResolverUpdates(t *testing.T) {
+	ctx := context.Background()
+	m := registry.NewMemory()
+	a := instance("a", "", "grpc://127.0.0.1:1", "http://127.0.0.1:11")
+	b := instance("b", "3", "grpc://127.0.0.1:2")
+	if err := m.Register(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	cc := build(t, m, true)
+
+	// 只选择 grpc:// 地址，没有设置权重时为 1
+	if got, want := cc.addrs(t), map[string]int{"127.0.0.1:1": 1}; !maps.Equal(got, want) {
+		t.Fatalf("addresses = %v, want %v", got, want)
+	}
+	if err := m.Register(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := cc.addrs(t), map[string]int{"127.0.0.1:1": 1, "127.0.0.1:2": 3}; !maps.Equal(got, want) {
+		t.Fatalf("addresses after register = %v, want %v", got, want)
+	}
+	if err := m.Deregister(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := cc.addrs(t), map[string]int{"127.0.0.1:2": 3}; !maps.Equal(got, want) {
+		t.Fatalf("addresses after deregister = %v, want %v", got, want)
+	}
+
+	// 所有实例都被注销时保留之前的地址
+	if err := m.Deregister(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+	cc.noUpdate(t)
+}
+
+func TestResolverSelectsSecureEndpoints(t *testing.T) {
+	m := registry.NewMemory()
+	if err := m.Register(context.Background(), instance("a", "2", "grpc://127.0.0.1:1", "grpcs://127.0.0.1:2")); err != nil {
+		t.Fatal(err)
+	}
+	cc := build(t, m, false)
+	if got, want := cc.addrs(t), map[string]int{"127.0.0.1:2": 2}; !maps.Equal(got, want) {
+		t.Fatalf("addresses = %v, want %v", got, want)
+	}
+}
+
+func TestBuildRequiresServiceName(t *testing.T) {
+	u, _ := url.Parse("discovery:///")
+	cc := &fakeClientConn{states: make(chan resolver.State, 1)}
+	if _, err := NewBuilder(registry.NewMemory(), true).Build(resolver.Target{URL: *u}, cc, resolver.BuildOptions{}); err == nil {
+		t.Fatal("Build() without service name succeeded")
+	}
+}
@@ -0,0 +1,283 @@
+package grpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/yanking/app-skeleton/pkg/grpc/clientinterceptors"
+)
+
+// RetryPolicy 重试策略，通过 gRPC service config 交给 gRPC 自身执行.
+// 只应用于幂等的方法，可以使用 RetryableMethods 从 proto 的 idempotency_level 中读取.
+type RetryPolicy struct {
+	// MaxAttempts 包含首次请求在内的最大尝试次数，默认 3，gRPC 最多允许 5 次
+	MaxAttempts int
+	// InitialBackoff 第一次重试前的最大等待时间，实际等待时间在 0 到该值之间随机，默认 100ms
+	InitialBackoff time.Duration
+	// MaxBackoff 重试等待时间的上限，默认 1s
+	MaxBackoff time.Duration
+	// BackoffMultiplier 每次重试后等待时间的倍数，默认 2
+	BackoffMultiplier float64
+	// RetryableCodes 可以重试的状态码，默认 Unavailable
+	RetryableCodes []codes.Code
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 3
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = 100 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = time.Second
+	}
+	if p.BackoffMultiplier <= 0 {
+		p.BackoffMultiplier = 2
+	}
+	if len(p.RetryableCodes) == 0 {
+		p.RetryableCodes = []codes.Code{codes.Unavailable}
+	}
+	return p
+}
+
+// methodRetryPolicy 应用于一组方法的重试策略
+type methodRetryPolicy struct {
+	policy  RetryPolicy
+	methods []string
+}
+
+// WithRetryPolicy 为 methods（完整名称，如 /demo.v1.UserService/GetUser，以 /* 结尾表示整个服务）启用重试.
+// 只有 proto 中声明了 idempotency_level = IDEMPOTENT 或 NO_SIDE_EFFECTS 的方法会被重试：以 /* 结尾时只包含服务中
+// 声明为幂等的方法，明确列出非幂等的方法（如 CreateUser）时建立连接失败.
+// 可以多次调用为不同的方法设置不同的策略，同一方法以最后一次设置为准.
+func WithRetryPolicy(policy RetryPolicy, methods ...string) ClientOption {
+	return func(o *clientOptions) {
+		o.retryPolicies = append(o.retryPolicies, methodRetryPolicy{policy: policy.withDefaults(), methods: methods})
+	}
+}
+
+// WithRetryBudget 设置重试预算，防止下游故障时重试放大流量. 每次失败消耗 1 个令牌，每次成功恢复 tokenRatio 个令牌，
+// 令牌数不超过 maxTokens 的一半时停止重试和对冲. 默认 maxTokens 为 10，tokenRatio 为 0.1.
+func WithRetryBudget(maxTokens, tokenRatio float64) ClientOption {
+	return func(o *clientOptions) {
+		o.retryBudget = clientinterceptors.RetryBudget{MaxTokens: maxTokens, TokenRatio: tokenRatio}
+	}
+}
+
+// WithHedgingPolicy 为 methods 启用对冲请求：首次请求在 Delay 内没有返回时并行发送新的请求，使用最先成功的结果.
+// 对冲会增加下游负载，只应用于 proto 中声明了 idempotency_level = NO_SIDE_EFFECTS 的方法，与 WithRetryPolicy 一样
+// 以 /* 结尾时只包含这些方法，明确列出其他方法时建立连接失败.
+// 与 WithRetryPolicy 同时作用于一个方法时，每个对冲请求都可能被重试.
+func WithHedgingPolicy(policy clientinterceptors.HedgingPolicy, methods ...string) ClientOption {
+	return func(o *clientOptions) {
+		o.hedgingPolicy = &policy
+		o.hedgingMethods = methods
+	}
+}
+
+// serviceConfig 对应 gRPC service config 中与负载均衡和重试相关的部分
+type serviceConfig struct {
+	LoadBalancingConfig []map[string]struct{} `json:"loadBalancingConfig,omitempty"`
+	MethodConfig        []methodConfig        `json:"methodConfig,omitempty"`
+	RetryThrottling     *retryThrottling      `json:"retryThrottling,omitempty"`
+}
+
+type methodConfig struct {
+	Name        []methodName     `json:"name"`
+	RetryPolicy *jsonRetryPolicy `json:"retryPolicy"`
+}
+
+type methodName struct {
+	Service string `json:"service"`
+	Method  string `json:"method,omitempty"`
+}
+
+type jsonRetryPolicy struct {
+	MaxAttempts          int          `json:"maxAttempts"`
+	InitialBackoff       string       `json:"initialBackoff"`
+	MaxBackoff           string       `json:"maxBackoff"`
+	BackoffMultiplier    float64      `json:"backoffMultiplier"`
+	RetryableStatusCodes []codes.Code `json:"retryableStatusCodes"`
+}
+
+type retryThrottling struct {
+	MaxTokens  float64 `json:"maxTokens"`
+	TokenRatio float64 `json:"tokenRatio"`
+}
+
+// buildServiceConfig 根据负载均衡策略和重试策略生成 gRPC service config，都没有设置时返回空字符串
+func buildServiceConfig(options *clientOptions) (string, error) {
+	if options.balancer == "" && len(options.retryPolicies) == 0 {
+		return "", nil
+	}
+
+	var sc serviceConfig
+	if options.balancer != "" {
+		sc.LoadBalancingConfig = []map[string]struct{}{{options.balancer: {}}}
+	}
+	if len(options.retryPolicies) > 0 {
+		budget := options.retryBudget.WithDefaults()
+		sc.RetryThrottling = &retryThrottling{MaxTokens: budget.MaxTokens, TokenRatio: budget.TokenRatio}
+	}
+
+	// 同一方法出现在多个策略中时以最后一个为准，gRPC 不允许 service config 中出现重复的方法
+	policies := options.retryPolicies
+	seen := make(map[methodName]bool)
+	for i := len(policies) - 1; i >= 0; i-- {
+		p := policies[i]
+		methods, err := RetryableMethodsOf(p.methods...)
+		if err != nil {
+			return "", err
+		}
+		var names []methodName
+		for _, m := range methods {
+			name, err := parseMethodName(m)
+			if err != nil {
+				return "", err
+			}
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+		if len(names) == 0 {
+			continue
+		}
+
+		sc.MethodConfig = append(sc.MethodConfig, methodConfig{
+			Name: names,
+			RetryPolicy: &jsonRetryPolicy{
+				MaxAttempts:          p.policy.MaxAttempts,
+				InitialBackoff:       formatDuration(p.policy.InitialBackoff),
+				MaxBackoff:           formatDuration(p.policy.MaxBackoff),
+				BackoffMultiplier:    p.policy.BackoffMultiplier,
+				RetryableStatusCodes: p.policy.RetryableCodes,
+			},
+		})
+	}
+
+	b, err := json.Marshal(sc)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// parseMethodName 将 /demo.v1.UserService/GetUser 或 /demo.v1.UserService/* 转换为 service config 中的方法名
+func parseMethodName(fullMethod string) (methodName, error) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok || service == "" || method == "" || !strings.HasPrefix(fullMethod, "/") {
+		return methodName{}, fmt.Errorf("grpc: invalid method name %q", fullMethod)
+	}
+	if method == "*" {
+		method = ""
+	}
+	return methodName{Service: service, Method: method}, nil
+}
+
+// formatDuration 将时长格式化为 service config 要求的格式，如 0.1s
+func formatDuration(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
+
+// RetryableMethods 返回 services（完整名称，如 demo.v1.UserService）中在 proto 里声明为
+// idempotency_level = IDEMPOTENT 或 NO_SIDE_EFFECTS 的方法，用于 WithRetryPolicy
+func RetryableMethods(services ...string) ([]string, error) {
+	return methodsWithIdempotency(services, descriptorpb.MethodOptions_IDEMPOTENT, descriptorpb.MethodOptions_NO_SIDE_EFFECTS)
+}
+
+// HedgeableMethods 返回 services 中在 proto 里声明为 idempotency_level = NO_SIDE_EFFECTS 的方法，用于 WithHedgingPolicy
+func HedgeableMethods(services ...string) ([]string, error) {
+	return methodsWithIdempotency(services, descriptorpb.MethodOptions_NO_SIDE_EFFECTS)
+}
+
+// RetryableMethodsOf 从 methods（以 /* 结尾表示整个服务）中筛选出可以重试的方法：服务展开为其中声明为幂等的方法，
+// 明确列出的方法没有声明为幂等时返回错误
+func RetryableMethodsOf(methods ...string) ([]string, error) {
+	return filterMethods(methods, descriptorpb.MethodOptions_IDEMPOTENT, descriptorpb.MethodOptions_NO_SIDE_EFFECTS)
+}
+
+// HedgeableMethodsOf 从 methods 中筛选出可以对冲的方法，规则与 RetryableMethodsOf 相同，只接受 NO_SIDE_EFFECTS
+func HedgeableMethodsOf(methods ...string) ([]string, error) {
+	return filterMethods(methods, descriptorpb.MethodOptions_NO_SIDE_EFFECTS)
+}
+
+func filterMethods(methods []string, levels ...descriptorpb.MethodOptions_IdempotencyLevel) ([]string, error) {
+	var out []string
+	for _, m := range methods {
+		name, err := parseMethodName(m)
+		if err != nil {
+			return nil, err
+		}
+		if name.Method == "" {
+			expanded, err := methodsWithIdempotency([]string{name.Service}, levels...)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, expanded...)
+			continue
+		}
+
+		sd, err := findService(name.Service)
+		if err != nil {
+			return nil, err
+		}
+		md := sd.Methods().ByName(protoreflect.Name(name.Method))
+		if md == nil {
+			return nil, fmt.Errorf("grpc: method %s not found", m)
+		}
+		if !hasIdempotency(md, levels) {
+			return nil, fmt.Errorf("grpc: method %s is not declared with idempotency_level %v in proto and must not be retried or hedged", m, levels)
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+func methodsWithIdempotency(services []string, levels ...descriptorpb.MethodOptions_IdempotencyLevel) ([]string, error) {
+	var methods []string
+	for _, service := range services {
+		sd, err := findService(service)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := 0; i < sd.Methods().Len(); i++ {
+			md := sd.Methods().Get(i)
+			if !hasIdempotency(md, levels) {
+				continue
+			}
+			methods = append(methods, fmt.Sprintf("/%s/%s", sd.FullName(), md.Name()))
+		}
+	}
+	return methods, nil
+}
+
+// findService 从已链接的 proto 中查找服务，没有找到时无法判断方法是否幂等
+func findService(service string) (protoreflect.ServiceDescriptor, error) {
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("grpc: service %s: %w", service, err)
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("grpc: %s is not a service", service)
+	}
+	return sd, nil
+}
+
+func hasIdempotency(md protoreflect.MethodDescriptor, levels []descriptorpb.MethodOptions_IdempotencyLevel) bool {
+	opts, ok := md.Options().(*descriptorpb.MethodOptions)
+	return ok && slices.Contains(levels, opts.GetIdempotencyLevel())
+}
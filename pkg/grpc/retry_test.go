@@ -0,0 +1,103 @@
+package grpc
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	// 注册 demo.v1 的 proto 描述，用于读取方法的 idempotency_level
+	_ "github.com/yanking/app-skeleton/api/proto/gen/demo/v1"
+)
+
+func TestRetryableMethodsOf(t *testing.T) {
+	tests := []struct {
+		name    string
+		methods []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "wildcard only includes idempotent methods",
+			methods: []string{"/demo.v1.UserService/*"},
+			want:    []string{"/demo.v1.UserService/GetUser", "/demo.v1.UserService/ListUsers"},
+		},
+		{
+			name:    "idempotent method",
+			methods: []string{"/demo.v1.UserService/GetUser"},
+			want:    []string{"/demo.v1.UserService/GetUser"},
+		},
+		{
+			name:    "non-idempotent method is rejected",
+			methods: []string{"/demo.v1.UserService/CreateUser"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown method",
+			methods: []string{"/demo.v1.UserService/DeleteUser"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown service",
+			methods: []string{"/demo.v1.OrderService/*"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid method name",
+			methods: []string{"demo.v1.UserService"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RetryableMethodsOf(tt.methods...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RetryableMethodsOf() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("RetryableMethodsOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildServiceConfigExcludesNonIdempotentMethods(t *testing.T) {
+	var options clientOptions
+	WithRetryPolicy(RetryPolicy{}, "/demo.v1.UserService/*")(&options)
+	// 后设置的策略优先，GetUser 不会重复出现
+	WithRetryPolicy(RetryPolicy{MaxAttempts: 5}, "/demo.v1.UserService/GetUser")(&options)
+
+	s, err := buildServiceConfig(&options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sc serviceConfig
+	if err := json.Unmarshal([]byte(s), &sc); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]int)
+	for _, mc := range sc.MethodConfig {
+		for _, name := range mc.Name {
+			got[name.Method] = mc.RetryPolicy.MaxAttempts
+		}
+	}
+	want := map[string]int{"GetUser": 5, "ListUsers": 3}
+	if len(got) != len(want) || got["GetUser"] != want["GetUser"] || got["ListUsers"] != want["ListUsers"] {
+		t.Fatalf("retried methods = %v, want %v", got, want)
+	}
+}
+
+func TestHedgeableMethodsOf(t *testing.T) {
+	got, err := HedgeableMethodsOf("/demo.v1.UserService/*", "/demo.v1.DemoService/Echo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/demo.v1.UserService/GetUser", "/demo.v1.UserService/ListUsers", "/demo.v1.DemoService/Echo"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("HedgeableMethodsOf() = %v, want %v", got, want)
+	}
+
+	if _, err := HedgeableMethodsOf("/demo.v1.UserService/CreateUser"); err == nil {
+		t.Fatal("HedgeableMethodsOf() accepted non-idempotent CreateUser")
+	}
+}
@@ -2,19 +2,27 @@ package grpc
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"net"
 	"net/http"
+	"net/netip"
 	"net/url"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/yanking/app-skeleton/pkg/grpc/httpmiddlewares"
 	srvintc "github.com/yanking/app-skeleton/pkg/grpc/serverinterceptors"
+	pkghealth "github.com/yanking/app-skeleton/pkg/health"
 	"github.com/yanking/app-skeleton/pkg/log"
+	"github.com/yanking/app-skeleton/pkg/registry"
 
 	apimd "github.com/go-kratos/kratos/v2/api/metadata"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
@@ -30,20 +38,82 @@ type Server struct {
 	streamInts []grpc.StreamServerInterceptor
 	grpcOpts   []grpc.ServerOption
 	lis        net.Listener
-	timeout    time.Duration
+	// timeout 单个 unary 请求的超时时间，可以通过 SetTimeout 在运行时调整
+	timeout atomic.Int64
 
 	health   *health.Server
 	metadata *apimd.Server
+	// endpoint 对外公布的地址，由监听器实际绑定的端口和 advertiseAddr 选出的主机组成，
+	// Unix 套接字为 unix:///path，其他类型的监听器为 nil
 	endpoint *url.URL
 
+	// advertiseHost 和 advertiseInterface 用于选择 endpoint 的主机
+	advertiseHost      string
+	advertiseInterface string
+
+	// checks 驱动 gRPC health 状态的健康检查
+	checks *pkghealth.Registry
+	// serviceChecks 每个服务绑定的检查项
+	serviceChecks map[string][]string
+	// healthMu 保护以下与健康状态相关的字段
+	healthMu      sync.Mutex
+	lastReport    *pkghealth.Report
+	manualDown    map[string]bool
+	servingStatus map[string]grpc_health_v1.HealthCheckResponse_ServingStatus
+	healthDown    bool
+
+	// ready 在服务开始接收请求时关闭
+	ready     chan struct{}
+	readyOnce sync.Once
+
+	// inFlight 正在处理中的请求，停止时用于报告被强制取消的请求数
+	inFlight srvintc.InFlight
+	// drainTimeout 未经过 Stop 直接取消 Start 的 ctx 时排空请求的期限
+	drainTimeout time.Duration
+	stopOnce     sync.Once
+	stopped      chan struct{}
+	drain        *DrainReport
+
 	enableMetrics bool
 	enableTracing bool
 
+	// errorDomain 错误的 ErrorInfo 中默认的 domain
+	errorDomain string
+
+	// shedding 自适应限流配置，为 nil 时不启用
+	shedding *SheddingOptions
+
+	// registrar 注册中心，为 nil 时不注册
+	registrar  registry.Registrar
+	instance   *registry.ServiceInstance
+	registered atomic.Bool
+
+	// tlsConfig gRPC 服务的 TLS 配置，为 nil 时不启用 TLS
+	tlsConfig *tls.Config
+
+	// singlePort 为 true 时 gRPC 和 HTTP 共用一个监听器，由 gatewayServer 处理所有连接
+	singlePort   bool
+	httpHandlers []httpHandler
+
 	// gRPC-Gateway 相关字段
 	enableGateway bool
 	gatewayAddr   string
-	gatewayServer *http.Server
-	gatewayMux    *runtime.ServeMux
+	gatewayLis    net.Listener
+	// gatewayEndpoint gRPC-Gateway 对外公布的地址
+	gatewayEndpoint *url.URL
+	gatewayServer   *http.Server
+	gatewayMux      *runtime.ServeMux
+	gatewayTLS      *tls.Config
+	gatewayOpts     []runtime.ServeMuxOption
+	// gatewayMiddlewares 包裹 gRPC-Gateway 和 WithHTTPHandler 注册的处理程序，不作用于单端口模式下的 gRPC 请求
+	gatewayMiddlewares []httpmiddlewares.Middleware
+	// gatewayTimeout HTTP 服务的读写超时，为 0 时不限制
+	gatewayTimeout time.Duration
+	// inProcessLis 和 gatewayConn 是 gRPC-Gateway 连接 gRPC 服务使用的进程内连接
+	inProcessLis *inProcessListener
+	gatewayConn  *grpc.ClientConn
+	// trustedProxies 可信代理的地址，其转发的 x-forwarded-for 才会被采用
+	trustedProxies []netip.Prefix
 }
 
 func (s *Server) Name() string {
@@ -61,31 +131,61 @@ func (s *Server) Address() string {
 	return s.address
 }
 
+// Ready 返回一个在 gRPC 服务开始处理请求后关闭的 channel，实现 app.IReadiness
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
 func NewServer(opts ...ServerOption) *Server {
 	srv := &Server{
 		address: ":0",
 		health:  health.NewServer(),
+		ready:   make(chan struct{}),
+
+		drainTimeout: defaultDrainTimeout,
+		stopped:      make(chan struct{}),
+
+		manualDown:    make(map[string]bool),
+		servingStatus: make(map[string]grpc_health_v1.HealthCheckResponse_ServingStatus),
 	}
 
 	for _, opt := range opts {
 		opt(srv)
 	}
 
+	// 错误拦截器在其他拦截器之外，拦截器返回的错误同样转换为统一的错误模型
 	unaryInts := []grpc.UnaryServerInterceptor{
+		srv.inFlight.UnaryInterceptor,
 		srvintc.UnaryCrashInterceptor,
+		srvintc.UnaryErrorInterceptor(srv.errorDomain),
 	}
 
 	streamInts := []grpc.StreamServerInterceptor{
+		srv.inFlight.StreamInterceptor,
 		srvintc.StreamCrashInterceptor,
+		srvintc.StreamErrorInterceptor(srv.errorDomain),
+	}
+
+	// gRPC-Gateway 的请求通过进程内连接到达，之后的拦截器看到的 peer 是 HTTP 客户端的地址
+	if srv.enableGateway {
+		srv.inProcessLis = newInProcessListener()
+		unaryInts = append([]grpc.UnaryServerInterceptor{srv.gatewayPeerUnaryInterceptor}, unaryInts...)
+		streamInts = append([]grpc.StreamServerInterceptor{srv.gatewayPeerStreamInterceptor}, streamInts...)
+	}
+
+	// 过载时尽早丢弃请求，被丢弃的请求不计入请求耗时
+	if srv.shedding != nil {
+		limiter := srv.shedding.limiter()
+		unaryInts = append(unaryInts, srvintc.UnaryShedInterceptor(limiter, srv.shedding.CriticalMethods...))
+		streamInts = append(streamInts, srvintc.StreamShedInterceptor(limiter, srv.shedding.CriticalMethods...))
 	}
 
 	if srv.enableMetrics {
 		unaryInts = append(unaryInts, srvintc.UnaryPrometheusInterceptor)
 	}
 
-	if srv.timeout > 0 {
-		unaryInts = append(unaryInts, srvintc.UnaryTimeoutInterceptor(srv.timeout))
-	}
+	// 超时拦截器总是安装，超时时间为 0 时直接放行，便于运行时开启
+	unaryInts = append(unaryInts, srvintc.UnaryDynamicTimeoutInterceptor(srv.Timeout))
 
 	if len(srv.unaryInts) > 0 {
 		unaryInts = append(unaryInts, srv.unaryInts...)
@@ -94,6 +194,14 @@ func NewServer(opts ...ServerOption) *Server {
 	//把我们传入的拦截器转换成grpc的ServerOption
 	grpcOpts := []grpc.ServerOption{grpc.ChainUnaryInterceptor(unaryInts...)}
 
+	if srv.tlsConfig != nil {
+		creds := credentials.NewTLS(srv.tlsConfig)
+		if srv.enableGateway {
+			creds = inProcessCredentials{creds}
+		}
+		grpcOpts = append(grpcOpts, grpc.Creds(creds))
+	}
+
 	//把用户自己传入的grpc.ServerOption放在一起
 	if len(srv.grpcOpts) > 0 {
 		grpcOpts = append(grpcOpts, srv.grpcOpts...)
@@ -126,6 +234,9 @@ func NewServer(opts ...ServerOption) *Server {
 
 	//注册health
 	grpc_health_v1.RegisterHealthServer(srv.Server, srv.health)
+	if srv.checks != nil {
+		srv.checks.Subscribe(pkghealth.Readiness, srv.applyHealth)
+	}
 	apimd.RegisterMetadataServer(srv.Server, srv.metadata)
 
 	reflection.Register(srv.Server)
@@ -133,16 +244,24 @@ func NewServer(opts ...ServerOption) *Server {
 
 	// 初始化 gRPC-Gateway
 	if srv.enableGateway {
-		srv.gatewayMux = runtime.NewServeMux()
-		srv.gatewayServer = &http.Server{
-			Addr:    srv.gatewayAddr,
-			Handler: srv.gatewayMux,
-		}
+		// 记录匹配的路由模板，供指标和访问日志使用
+		muxOpts := append([]runtime.ServeMuxOption{runtime.WithMiddlewares(httpmiddlewares.GatewayRoute)}, srv.gatewayOpts...)
+		srv.gatewayMux = runtime.NewServeMux(muxOpts...)
+	}
+	if srv.enableGateway || srv.singlePort {
+		srv.gatewayServer = srv.httpServer()
 	}
 
 	return srv
 }
 
+// WithErrorDomain 设置返回的错误中 ErrorInfo 默认的 domain，通常为服务名
+func WithErrorDomain(domain string) ServerOption {
+	return func(s *Server) {
+		s.errorDomain = domain
+	}
+}
+
 func WithAddress(address string) ServerOption {
 	return func(o *Server) {
 		o.address = address
@@ -163,7 +282,39 @@ func WithTracing(tracing bool) ServerOption {
 
 func WithTimeout(timeout time.Duration) ServerOption {
 	return func(o *Server) {
-		o.timeout = timeout
+		o.timeout.Store(int64(timeout))
+	}
+}
+
+// Timeout 返回当前 unary 请求的超时时间
+func (s *Server) Timeout() time.Duration {
+	return time.Duration(s.timeout.Load())
+}
+
+// SetTimeout 在运行时调整 unary 请求的超时时间，为 0 时不限制
+func (s *Server) SetTimeout(timeout time.Duration) {
+	s.timeout.Store(int64(timeout))
+	log.Infof("[grpc] request timeout set to %s", timeout)
+}
+
+// WithTLSConfig 为 gRPC 服务启用 TLS，可以使用 tlsconfig.NewServerConfig 创建支持证书热加载的配置
+func WithTLSConfig(cfg *tls.Config) ServerOption {
+	return func(s *Server) {
+		s.tlsConfig = cfg
+	}
+}
+
+// WithGatewayTLSConfig 为 gRPC-Gateway 的 HTTP 服务启用 TLS
+func WithGatewayTLSConfig(cfg *tls.Config) ServerOption {
+	return func(s *Server) {
+		s.gatewayTLS = cfg
+	}
+}
+
+// WithHealthChecks 使用健康检查的 readiness 结果驱动 gRPC health 服务的状态
+func WithHealthChecks(checks *pkghealth.Registry) ServerOption {
+	return func(s *Server) {
+		s.checks = checks
 	}
 }
 
@@ -191,6 +342,28 @@ func WithOptions(opts ...grpc.ServerOption) ServerOption {
 	}
 }
 
+// WithGatewayOptions 设置创建 gRPC-Gateway 的 mux 使用的选项，如 marshaler、header matcher、错误处理和 metadata annotator
+func WithGatewayOptions(opts ...runtime.ServeMuxOption) ServerOption {
+	return func(s *Server) {
+		s.gatewayOpts = append(s.gatewayOpts, opts...)
+	}
+}
+
+// WithGatewayMiddleware 设置 HTTP 中间件，第一个中间件在最外层. 常用的中间件见 httpmiddlewares 包
+func WithGatewayMiddleware(mws ...httpmiddlewares.Middleware) ServerOption {
+	return func(s *Server) {
+		s.gatewayMiddlewares = append(s.gatewayMiddlewares, mws...)
+	}
+}
+
+// WithGatewayTimeout 设置 HTTP 服务读取请求和写入响应的超时时间，空闲连接在两倍的超时时间后关闭.
+// 单端口模式下 gRPC 的流可能持续很长时间，只限制读取请求头的时间和空闲连接.
+func WithGatewayTimeout(timeout time.Duration) ServerOption {
+	return func(s *Server) {
+		s.gatewayTimeout = timeout
+	}
+}
+
 // WithGateway 启用 gRPC-Gateway
 func WithGateway(enable bool, addr string) ServerOption {
 	return func(s *Server) {
@@ -204,7 +377,7 @@ func (s *Server) GetGatewayMux() *runtime.ServeMux {
 	return s.gatewayMux
 }
 
-// 完成ip和端口的提取
+// 完成监听以及对外公布的ip和端口的提取，启用 TLS 时 scheme 分别为 grpcs 和 https
 func (s *Server) listenAndEndpoint() error {
 	if s.lis == nil {
 		lis, err := net.Listen("tcp", s.address)
@@ -214,48 +387,101 @@ func (s *Server) listenAndEndpoint() error {
 		s.lis = lis
 	}
 
-	s.endpoint = &url.URL{Scheme: "grpc", Host: s.address}
-	return nil
+	scheme := "grpc"
+	if s.tlsConfig != nil {
+		scheme = "grpcs"
+	}
+	var err error
+	if s.endpoint, err = s.advertiseEndpoint(s.lis.Addr(), scheme); err != nil {
+		return err
+	}
+
+	if !s.enableGateway {
+		return nil
+	}
+	if s.singlePort {
+		// 只有 TCP 监听器的 gateway 地址可以用 http(s)://host:port 表示
+		if s.endpoint != nil && s.endpoint.Host != "" {
+			s.gatewayEndpoint = &url.URL{Scheme: "http", Host: s.endpoint.Host}
+			if s.tlsConfig != nil {
+				s.gatewayEndpoint.Scheme = "https"
+			}
+		}
+		return nil
+	}
+	// 在这里监听 gateway 的地址，配置为 :0 时同样可以得到实际的端口
+	if s.gatewayLis, err = net.Listen("tcp", s.gatewayAddr); err != nil {
+		return err
+	}
+	scheme = "http"
+	if s.gatewayTLS != nil {
+		scheme = "https"
+	}
+	s.gatewayEndpoint, err = s.advertiseEndpoint(s.gatewayLis.Addr(), scheme)
+	return err
 }
 
 // Start 启动grpc的服务
 func (s *Server) Start(ctx context.Context) error {
 	// 启动 gRPC 服务器
-	log.Infof("[grpc] server listening on: %s", s.lis.Addr().String())
+	log.Infof("[grpc] server listening on: %s, endpoint: %s", s.lis.Addr().String(), s.endpoint)
 	s.health.Resume()
+	// Resume 会将状态全部置为 SERVING，这里为每个已注册的服务设置状态，并以当前的检查结果为准
+	if s.checks != nil {
+		s.applyHealth(s.checks.Check(ctx, pkghealth.Readiness))
+		s.validateServiceChecks()
+	} else {
+		s.syncHealth()
+	}
 
 	// 在单独的goroutine中启动服务器，以便可以监听上下文取消
 	go func() {
 		<-ctx.Done()
-		s.GracefulStop()
-		if s.gatewayServer != nil {
-			_ = s.gatewayServer.Shutdown(context.Background())
-		}
+		// 没有经过 Stop 而直接取消时，同样在期限内排空请求
+		stopCtx, cancel := context.WithTimeout(context.Background(), s.drainTimeout)
+		defer cancel()
+		_ = s.Stop(stopCtx)
 	}()
 
 	// 如果启用了 gRPC-Gateway，则同时启动 HTTP 服务器
 	if s.enableGateway {
 		go func() {
-			log.Infof("[gateway] server listening on: %s", s.gatewayAddr)
-			if err := s.gatewayServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			if err := s.Serve(s.inProcessLis); err != nil {
+				log.Errorf("[gateway] in-process listener error: %v", err)
+			}
+		}()
+	}
+	if s.enableGateway && !s.singlePort {
+		go func() {
+			log.Infof("[gateway] server listening on: %s, endpoint: %s", s.gatewayLis.Addr().String(), s.gatewayEndpoint)
+			var err error
+			if s.gatewayTLS != nil {
+				// 证书由 TLSConfig 提供
+				err = s.gatewayServer.ServeTLS(s.gatewayLis, "", "")
+			} else {
+				err = s.gatewayServer.Serve(s.gatewayLis)
+			}
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
 				log.Errorf("[gateway] server error: %v", err)
 			}
 		}()
 	}
 
+	// 监听器在 NewServer 中已经创建，Serve 之前到达的连接会在 backlog 中排队，因此这里即可视为就绪
+	if err := s.register(ctx); err != nil {
+		return err
+	}
+	s.readyOnce.Do(func() { close(s.ready) })
+	if s.singlePort {
+		return s.serveSinglePort()
+	}
 	return s.Serve(s.lis)
 }
 
-func (s *Server) Stop(ctx context.Context) error {
-	//设置服务的状态为not_serving，防止接收新的请求过来
-	s.health.Shutdown()
-
-	if s.gatewayServer != nil {
-		_ = s.gatewayServer.Shutdown(ctx)
-		log.Infof("[gateway] server stopped")
-	}
-
-	s.GracefulStop()
-	log.Infof("[grpc] server stopped")
+// PreStop 将所有服务的健康状态置为 NOT_SERVING，让负载均衡器在连接关闭前摘除流量，实现 app.IPreStopper
+func (s *Server) PreStop(ctx context.Context) error {
+	s.deregister(ctx)
+	s.shutdownHealth()
+	log.Infof("[grpc] health status set to NOT_SERVING")
 	return nil
 }
@@ -0,0 +1,122 @@
+package serverinterceptors
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/yanking/app-skeleton/pkg/auth"
+	"github.com/yanking/app-skeleton/pkg/errors"
+)
+
+// DefaultPublicMethods 不需要认证的基础设施服务：健康检查、反射和元数据
+var DefaultPublicMethods = []string{
+	"/grpc.health.v1.Health/*",
+	"/grpc.reflection.v1.ServerReflection/*",
+	"/grpc.reflection.v1alpha.ServerReflection/*",
+	"/kratos.api.Metadata/*",
+}
+
+// TokenVerifier 校验 token，*auth.Verifier 实现了该接口
+type TokenVerifier interface {
+	Verify(token string) (*auth.Claims, error)
+}
+
+// methodSet 一组方法. 以 /* 结尾的表示整个服务，如 /demo.v1.DemoService/*
+type methodSet struct {
+	methods  map[string]bool
+	services []string
+}
+
+func newMethodSet(methods ...string) *methodSet {
+	p := &methodSet{methods: make(map[string]bool)}
+	for _, m := range methods {
+		if service, ok := strings.CutSuffix(m, "*"); ok {
+			p.services = append(p.services, service)
+			continue
+		}
+		p.methods[m] = true
+	}
+	return p
+}
+
+func (p *methodSet) contains(fullMethod string) bool {
+	if p.methods[fullMethod] {
+		return true
+	}
+	for _, service := range p.services {
+		if strings.HasPrefix(fullMethod, service) {
+			return true
+		}
+	}
+	return false
+}
+
+// newPublicMethods 不需要认证的方法，总是包含 DefaultPublicMethods
+func newPublicMethods(methods []string) *methodSet {
+	return newMethodSet(append(append([]string{}, DefaultPublicMethods...), methods...)...)
+}
+
+// authenticate 校验请求头 authorization 中的 bearer token，并将 Claims 放入 context
+func authenticate(ctx context.Context, verifier TokenVerifier) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, errors.Unauthorized("MISSING_TOKEN", "missing authorization header")
+	}
+
+	scheme, token, ok := strings.Cut(values[0], " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") || token == "" {
+		return nil, errors.Unauthorized("MISSING_TOKEN", "authorization header must be a bearer token")
+	}
+
+	claims, err := verifier.Verify(token)
+	if err != nil {
+		return nil, errors.Unauthorized("INVALID_TOKEN", "%s", err.Error()).WithCause(err)
+	}
+	return auth.NewContext(ctx, claims), nil
+}
+
+// UnaryAuthInterceptor 校验 bearer token，publicMethods 中的方法（完整名称，如 /demo.v1.DemoService/Healthz）不需要认证
+func UnaryAuthInterceptor(verifier TokenVerifier, publicMethods ...string) grpc.UnaryServerInterceptor {
+	public := newPublicMethods(publicMethods)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if public.contains(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		ctx, err := authenticate(ctx, verifier)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuthInterceptor 流式请求的认证拦截器
+func StreamAuthInterceptor(verifier TokenVerifier, publicMethods ...string) grpc.StreamServerInterceptor {
+	public := newPublicMethods(publicMethods)
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if public.contains(info.FullMethod) {
+			return handler(srv, stream)
+		}
+
+		ctx, err := authenticate(stream.Context(), verifier)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &wrappedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// wrappedStream 替换 ServerStream 的 context
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (w *wrappedStream) Context() context.Context {
+	return w.ctx
+}
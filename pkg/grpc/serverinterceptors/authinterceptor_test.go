@@ -0,0 +1,184 @@
+package serverinterceptors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/yanking/app-skeleton/pkg/auth"
+	"github.com/yanking/app-skeleton/pkg/errors"
+)
+
+const testKey = "test-signing-key"
+
+// newTestToken 签发可以被 newTestTokenVerifier 校验通过的 token
+func newTestToken(t *testing.T, subject string, roles []string, scopes ...string) string {
+	t.Helper()
+	issuer, err := auth.NewIssuer(testKey, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := issuer.Issue(subject, roles, scopes...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func newTestTokenVerifier(t *testing.T) *auth.Verifier {
+	t.Helper()
+	v, err := auth.NewVerifier(auth.WithHMACKey(testKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// incoming 返回带有 authorization 请求头的 context，header 为空时不设置
+func incoming(header string) context.Context {
+	if header == "" {
+		return context.Background()
+	}
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", header))
+}
+
+// assertUnauthenticated 校验错误为 Unauthenticated 且原因为 reason
+func assertUnauthenticated(t *testing.T, err error, reason string) {
+	t.Helper()
+	if errors.Code(err) != codes.Unauthenticated || errors.Reason(err) != reason {
+		t.Fatalf("err = %v, want Unauthenticated with reason %s", err, reason)
+	}
+}
+
+func TestUnaryAuthInterceptor(t *testing.T) {
+	token := newTestToken(t, "alice", []string{"admin"})
+	interceptor := UnaryAuthInterceptor(newTestTokenVerifier(t))
+
+	tests := []struct {
+		name   string
+		header string
+		// wantReason 为空时请求应当通过认证
+		wantReason string
+	}{
+		{"valid token", "Bearer " + token, ""},
+		{"scheme is case insensitive", "bearer " + token, ""},
+		{"missing header", "", "MISSING_TOKEN"},
+		{"basic auth", "Basic YWxpY2U6c2VjcmV0", "MISSING_TOKEN"},
+		{"empty bearer token", "Bearer ", "MISSING_TOKEN"},
+		{"no scheme", token, "MISSING_TOKEN"},
+		{"invalid token", "Bearer not-a-jwt", "INVALID_TOKEN"},
+		{"tampered signature", "Bearer " + token[:len(token)-2] + "xx", "INVALID_TOKEN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				called bool
+				claims *auth.Claims
+			)
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				claims, _ = auth.FromContext(ctx)
+				return req, nil
+			}
+			_, err := interceptor(incoming(tt.header), nil, &grpc.UnaryServerInfo{FullMethod: "/demo.v1.UserService/GetUser"}, handler)
+			if tt.wantReason != "" {
+				assertUnauthenticated(t, err, tt.wantReason)
+				if called {
+					t.Fatal("handler called for an unauthenticated request")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			if claims == nil || claims.Subject != "alice" || !claims.HasRole("admin") {
+				t.Fatalf("claims in context = %+v, want alice with role admin", claims)
+			}
+		})
+	}
+}
+
+func TestAuthInterceptorPublicMethods(t *testing.T) {
+	interceptor := UnaryAuthInterceptor(newTestTokenVerifier(t),
+		"/demo.v1.UserService/ListUsers",
+		"/demo.v1.DemoService/*",
+	)
+
+	tests := []struct {
+		method string
+		public bool
+	}{
+		{"/demo.v1.UserService/ListUsers", true},
+		{"/demo.v1.UserService/GetUser", false},
+		{"/demo.v1.DemoService/Healthz", true},
+		{"/demo.v1.DemoServiceV2/Healthz", false},
+		{"/grpc.health.v1.Health/Check", true},
+		{"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo", true},
+		{"/kratos.api.Metadata/ListServices", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			called := false
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				return req, nil
+			}
+			_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if tt.public {
+				if err != nil || !called {
+					t.Fatalf("public method: err = %v, handler called = %t", err, called)
+				}
+				return
+			}
+			assertUnauthenticated(t, err, "MISSING_TOKEN")
+		})
+	}
+}
+
+// ctxStream 返回指定 context 的 ServerStream
+type ctxStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *ctxStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamAuthInterceptor(t *testing.T) {
+	interceptor := StreamAuthInterceptor(newTestTokenVerifier(t), "/demo.v1.DemoService/*")
+	info := &grpc.StreamServerInfo{FullMethod: "/demo.v1.UserService/WatchUsers"}
+
+	var (
+		called bool
+		claims *auth.Claims
+	)
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		called = true
+		claims, _ = auth.FromContext(ss.Context())
+		return nil
+	}
+	stream := &ctxStream{ctx: incoming("Bearer " + newTestToken(t, "bob", nil, "users:read"))}
+	if err := interceptor(nil, stream, info, handler); err != nil {
+		t.Fatal(err)
+	}
+	if claims == nil || claims.Subject != "bob" || !claims.HasScope("users:read") {
+		t.Fatalf("claims in stream context = %+v, want bob with scope users:read", claims)
+	}
+
+	called = false
+	err := interceptor(nil, &ctxStream{ctx: incoming("Bearer not-a-jwt")}, info, handler)
+	assertUnauthenticated(t, err, "INVALID_TOKEN")
+	if called {
+		t.Fatal("handler called for an unauthenticated stream")
+	}
+
+	public := &grpc.StreamServerInfo{FullMethod: "/demo.v1.DemoService/Watch"}
+	if err := interceptor(nil, &ctxStream{ctx: context.Background()}, public, handler); err != nil {
+		t.Fatalf("public stream method: %v", err)
+	}
+}
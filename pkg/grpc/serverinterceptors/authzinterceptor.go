@@ -0,0 +1,200 @@
+package serverinterceptors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	authv1 "github.com/yanking/app-skeleton/api/proto/gen/auth/v1"
+	"github.com/yanking/app-skeleton/pkg/auth"
+	pkgerrors "github.com/yanking/app-skeleton/pkg/errors"
+)
+
+// ErrUnprotectedService 服务没有交给 Authorizer 管理
+var ErrUnprotectedService = errors.New("authz: service is not covered by the authorizer")
+
+// ErrNotPublic 方法在 proto 中没有声明为 public
+var ErrNotPublic = errors.New("authz: method is not declared public")
+
+// Authorizer 根据 proto 中声明的 (auth.v1.policy) 对请求进行授权.
+// 策略在构造时从已注册的服务描述中读取，运行时不再进行反射.
+type Authorizer struct {
+	// policies key 为方法的完整名称，如 /demo.v1.UserService/GetUser
+	policies map[string]*authv1.Policy
+}
+
+// NewAuthorizer 读取 services（完整名称，如 demo.v1.UserService）中所有方法的访问策略.
+// 存在没有声明策略的方法时返回错误，避免未受保护的方法被意外发布.
+func NewAuthorizer(services ...string) (*Authorizer, error) {
+	a := &Authorizer{policies: make(map[string]*authv1.Policy)}
+
+	var missing []string
+	for _, service := range services {
+		d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(service))
+		if err != nil {
+			return nil, fmt.Errorf("authz: service %s: %w", service, err)
+		}
+		sd, ok := d.(protoreflect.ServiceDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("authz: %s is not a service", service)
+		}
+
+		methods := sd.Methods()
+		for i := 0; i < methods.Len(); i++ {
+			md := methods.Get(i)
+			fullMethod := fmt.Sprintf("/%s/%s", sd.FullName(), md.Name())
+			opts := md.Options()
+			if opts == nil || !proto.HasExtension(opts, authv1.E_Policy) {
+				missing = append(missing, fullMethod)
+				continue
+			}
+			a.policies[fullMethod] = proto.GetExtension(opts, authv1.E_Policy).(*authv1.Policy)
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("authz: methods without (auth.v1.policy): %s", strings.Join(missing, ", "))
+	}
+	return a, nil
+}
+
+// PublicMethods 返回声明为 public 的方法，可以传给 UnaryAuthInterceptor 跳过认证
+func (a *Authorizer) PublicMethods() []string {
+	var methods []string
+	for m, p := range a.policies {
+		if p.GetPublic() {
+			methods = append(methods, m)
+		}
+	}
+	slices.Sort(methods)
+	return methods
+}
+
+// CheckPublic 检查 methods（格式同 UnaryAuthInterceptor 的 publicMethods）在授权时是否都是公开的，
+// 即在 proto 中声明为 public 或属于 DefaultPublicMethods. 访问策略以 proto 为准，
+// 跳过认证但会被授权拒绝的方法在这里返回 ErrNotPublic.
+func (a *Authorizer) CheckPublic(methods ...string) error {
+	defaults := newPublicMethods(nil)
+	var notPublic []string
+	for _, m := range methods {
+		service, wildcard := strings.CutSuffix(m, "*")
+		if !wildcard {
+			if !defaults.contains(m) && !a.policies[m].GetPublic() {
+				notPublic = append(notPublic, m)
+			}
+			continue
+		}
+		if defaults.contains(service) {
+			continue
+		}
+		found, public := false, true
+		for method, p := range a.policies {
+			if strings.HasPrefix(method, service) {
+				found = true
+				public = public && p.GetPublic()
+			}
+		}
+		if !found || !public {
+			notPublic = append(notPublic, m)
+		}
+	}
+	if len(notPublic) > 0 {
+		return fmt.Errorf("%w: %s", ErrNotPublic, strings.Join(notPublic, ", "))
+	}
+	return nil
+}
+
+// authorize 校验调用方是否满足方法的访问策略. 没有策略的方法只允许 DefaultPublicMethods 中的基础设施服务访问.
+func (a *Authorizer) authorize(ctx context.Context, fullMethod string) error {
+	policy, ok := a.policies[fullMethod]
+	if !ok {
+		if newPublicMethods(nil).contains(fullMethod) {
+			return nil
+		}
+		return permissionDenied(fullMethod, "NO_POLICY", "method has no access policy", nil)
+	}
+	if policy.GetPublic() {
+		return nil
+	}
+
+	claims, ok := auth.FromContext(ctx)
+	if !ok {
+		return pkgerrors.Unauthorized("UNAUTHENTICATED", "authentication required")
+	}
+
+	if roles := policy.GetRoles(); len(roles) > 0 && !slices.ContainsFunc(roles, claims.HasRole) {
+		return permissionDenied(fullMethod, "MISSING_ROLE", "caller has none of the required roles",
+			map[string]string{"required_roles": strings.Join(roles, ",")})
+	}
+
+	var missing []string
+	for _, scope := range policy.GetScopes() {
+		if !claims.HasScope(scope) {
+			missing = append(missing, scope)
+		}
+	}
+	if len(missing) > 0 {
+		return permissionDenied(fullMethod, "MISSING_SCOPE", "caller lacks the required scopes",
+			map[string]string{"missing_scopes": strings.Join(missing, ",")})
+	}
+	return nil
+}
+
+// permissionDenied 返回带 ErrorInfo 详情的 PermissionDenied 错误，domain 由错误拦截器统一设置
+func permissionDenied(fullMethod, reason, msg string, metadata map[string]string) error {
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+	metadata["method"] = fullMethod
+	return pkgerrors.Forbidden(reason, "%s", msg).WithMetadata(metadata)
+}
+
+// UnaryInterceptor 授权拦截器，需要放在认证拦截器之后
+func (a *Authorizer) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	if err := a.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamInterceptor 流式请求的授权拦截器，需要放在认证拦截器之后
+func (a *Authorizer) StreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	if err := a.authorize(stream.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+// Covers 检查 services 是否都在构造 Authorizer 时被读取了策略，用于在服务注册完成后确认没有遗漏
+func (a *Authorizer) Covers(services ...string) error {
+	var uncovered []string
+	for _, service := range services {
+		if newPublicMethods(nil).contains("/" + service + "/") {
+			continue
+		}
+		prefix := "/" + service + "/"
+		found := false
+		for m := range a.policies {
+			if strings.HasPrefix(m, prefix) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			uncovered = append(uncovered, service)
+		}
+	}
+	if len(uncovered) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnprotectedService, strings.Join(uncovered, ", "))
+	}
+	return nil
+}
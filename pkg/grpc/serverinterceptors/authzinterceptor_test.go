@@ -0,0 +1,187 @@
+package serverinterceptors
+
+import (
+	"context"
+	stderrors "errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	_ "google.golang.org/grpc/health/grpc_health_v1" // 注册没有声明访问策略的健康检查服务
+
+	v1 "github.com/yanking/app-skeleton/api/proto/gen/demo/v1"
+	"github.com/yanking/app-skeleton/pkg/auth"
+	"github.com/yanking/app-skeleton/pkg/errors"
+)
+
+var (
+	demoService = v1.DemoService_ServiceDesc.ServiceName
+	userService = v1.UserService_ServiceDesc.ServiceName
+)
+
+func newTestAuthorizer(t *testing.T) *Authorizer {
+	t.Helper()
+	a, err := NewAuthorizer(demoService, userService)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// withClaims 返回携带指定角色和 scope 的 context
+func withClaims(roles []string, scope string) context.Context {
+	return auth.NewContext(context.Background(), &auth.Claims{Roles: roles, Scope: scope})
+}
+
+func TestNewAuthorizer(t *testing.T) {
+	tests := []struct {
+		name     string
+		services []string
+		wantErr  string
+	}{
+		{"all methods have a policy", []string{demoService, userService}, ""},
+		{"method without policy", []string{demoService, "grpc.health.v1.Health"}, "methods without (auth.v1.policy): /grpc.health.v1.Health/Check"},
+		{"unknown service", []string{"demo.v1.NoSuchService"}, "service demo.v1.NoSuchService"},
+		{"not a service", []string{"demo.v1.GetUserRequest"}, "demo.v1.GetUserRequest is not a service"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAuthorizer(tt.services...)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("NewAuthorizer() = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("NewAuthorizer() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	a := newTestAuthorizer(t)
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		method string
+		// wantCode 为 OK 时请求应当被允许
+		wantCode   codes.Code
+		wantReason string
+	}{
+		{"public method without claims", context.Background(), "/demo.v1.DemoService/Healthz", codes.OK, ""},
+		{"default public method without policy", context.Background(), "/grpc.health.v1.Health/Check", codes.OK, ""},
+		{"unauthenticated", context.Background(), "/demo.v1.DemoService/Echo", codes.Unauthenticated, "UNAUTHENTICATED"},
+		{"authenticated without requirements", withClaims(nil, ""), "/demo.v1.DemoService/Echo", codes.OK, ""},
+		{"scope granted", withClaims(nil, "users:read"), "/demo.v1.UserService/GetUser", codes.OK, ""},
+		{"scope missing", withClaims([]string{"admin"}, "users:write"), "/demo.v1.UserService/GetUser", codes.PermissionDenied, "MISSING_SCOPE"},
+		{"role and scope granted", withClaims([]string{"admin"}, "users:read users:write"), "/demo.v1.UserService/CreateUser", codes.OK, ""},
+		{"role missing", withClaims([]string{"viewer"}, "users:write"), "/demo.v1.UserService/CreateUser", codes.PermissionDenied, "MISSING_ROLE"},
+		{"role granted, scope missing", withClaims([]string{"admin"}, "users:read"), "/demo.v1.UserService/CreateUser", codes.PermissionDenied, "MISSING_SCOPE"},
+		{"unknown method", withClaims([]string{"admin"}, "users:read users:write"), "/demo.v1.UserService/DeleteUser", codes.PermissionDenied, "NO_POLICY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				return req, nil
+			}
+			_, err := a.UnaryInterceptor(tt.ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if tt.wantCode == codes.OK {
+				if err != nil || !called {
+					t.Fatalf("err = %v, handler called = %t", err, called)
+				}
+				return
+			}
+			if called {
+				t.Fatal("handler called for a denied request")
+			}
+			if errors.Code(err) != tt.wantCode || errors.Reason(err) != tt.wantReason {
+				t.Fatalf("err = %v, want %s with reason %s", err, tt.wantCode, tt.wantReason)
+			}
+			if tt.wantCode == codes.PermissionDenied && errors.FromError(err).Metadata["method"] != tt.method {
+				t.Fatalf("metadata = %v, want method %s", errors.FromError(err).Metadata, tt.method)
+			}
+		})
+	}
+}
+
+func TestAuthorizeMetadata(t *testing.T) {
+	a := newTestAuthorizer(t)
+	err := a.authorize(withClaims([]string{"admin"}, ""), "/demo.v1.UserService/CreateUser")
+	if got := errors.FromError(err).Metadata["missing_scopes"]; got != "users:write" {
+		t.Fatalf("missing_scopes = %q, want users:write", got)
+	}
+	err = a.authorize(withClaims(nil, ""), "/demo.v1.UserService/CreateUser")
+	if got := errors.FromError(err).Metadata["required_roles"]; got != "admin" {
+		t.Fatalf("required_roles = %q, want admin", got)
+	}
+}
+
+func TestStreamAuthorize(t *testing.T) {
+	a := newTestAuthorizer(t)
+	info := &grpc.StreamServerInfo{FullMethod: "/demo.v1.UserService/GetUser"}
+	handler := func(srv interface{}, ss grpc.ServerStream) error { return nil }
+
+	if err := a.StreamInterceptor(nil, &ctxStream{ctx: withClaims(nil, "users:read")}, info, handler); err != nil {
+		t.Fatalf("StreamInterceptor() = %v", err)
+	}
+	err := a.StreamInterceptor(nil, &ctxStream{ctx: withClaims(nil, "")}, info, handler)
+	if errors.Reason(err) != "MISSING_SCOPE" {
+		t.Fatalf("StreamInterceptor() = %v, want MISSING_SCOPE", err)
+	}
+}
+
+func TestAuthorizerCheckPublic(t *testing.T) {
+	a := newTestAuthorizer(t)
+	if got, want := a.PublicMethods(), []string{"/demo.v1.DemoService/Healthz"}; !slices.Equal(got, want) {
+		t.Fatalf("PublicMethods() = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		name    string
+		methods []string
+		// wantErr 为空时应当通过检查
+		wantErr string
+	}{
+		{"declared public", []string{"/demo.v1.DemoService/Healthz"}, ""},
+		{"default public service", []string{"/grpc.health.v1.Health/*", "/grpc.health.v1.Health/Check"}, ""},
+		{"protected method", []string{"/demo.v1.DemoService/Healthz", "/demo.v1.UserService/GetUser"}, "/demo.v1.UserService/GetUser"},
+		{"service with protected methods", []string{"/demo.v1.DemoService/*"}, "/demo.v1.DemoService/*"},
+		{"unknown method", []string{"/demo.v1.UserService/DeleteUser"}, "/demo.v1.UserService/DeleteUser"},
+		{"unknown service", []string{"/demo.v1.NoSuchService/*"}, "/demo.v1.NoSuchService/*"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := a.CheckPublic(tt.methods...)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("CheckPublic() = %v", err)
+				}
+				return
+			}
+			if !stderrors.Is(err, ErrNotPublic) || !strings.HasSuffix(err.Error(), ": "+tt.wantErr) {
+				t.Fatalf("CheckPublic() = %v, want ErrNotPublic for %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthorizerCovers(t *testing.T) {
+	a := newTestAuthorizer(t)
+	if err := a.Covers(demoService, userService, "grpc.health.v1.Health", "grpc.reflection.v1.ServerReflection"); err != nil {
+		t.Fatalf("Covers() = %v", err)
+	}
+	err := a.Covers(demoService, "billing.v1.BillingService", "demo.v1.User")
+	if !stderrors.Is(err, ErrUnprotectedService) {
+		t.Fatalf("Covers() = %v, want ErrUnprotectedService", err)
+	}
+	// demo.v1.User 是 demo.v1.UserService 的前缀，不应当被视为已覆盖
+	if !strings.HasSuffix(err.Error(), ": billing.v1.BillingService, demo.v1.User") {
+		t.Fatalf("Covers() = %v, want both uncovered services", err)
+	}
+}
@@ -0,0 +1,45 @@
+package serverinterceptors
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/yanking/app-skeleton/pkg/errors"
+	"github.com/yanking/app-skeleton/pkg/log"
+)
+
+// convertError 将处理程序返回的错误转换为 *errors.Error，带有原因但没有 domain 的错误使用 domain.
+// 原始错误不会返回给调用方，Unknown、Internal 和 DataLoss 错误在这里记录日志.
+func convertError(ctx context.Context, fullMethod, domain string, err error) error {
+	if err == nil {
+		return nil
+	}
+	e := errors.FromError(err)
+	if e.Domain == "" && e.Reason != "" && domain != "" {
+		e = e.WithDomain(domain)
+	}
+	switch e.Code {
+	case codes.Unknown, codes.Internal, codes.DataLoss:
+		log.Ctx(ctx).Errorw(err, "[grpc] request failed", "method", fullMethod, "code", e.Code.String(), "reason", e.Reason)
+	}
+	return e
+}
+
+// UnaryErrorInterceptor 统一错误拦截器，返回的错误包含 ErrorInfo、BadRequest、RetryInfo 等详情
+func UnaryErrorInterceptor(domain string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		return resp, convertError(ctx, info.FullMethod, domain, err)
+	}
+}
+
+// StreamErrorInterceptor 流式请求的统一错误拦截器
+func StreamErrorInterceptor(domain string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		return convertError(stream.Context(), info.FullMethod, domain, handler(srv, stream))
+	}
+}
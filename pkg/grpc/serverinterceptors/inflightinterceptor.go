@@ -0,0 +1,37 @@
+package serverinterceptors
+
+import (
+	"context"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+)
+
+// InFlight 统计正在处理中的 unary 请求和流，用于停止时报告被强制取消的请求数
+type InFlight struct {
+	unary   atomic.Int64
+	streams atomic.Int64
+}
+
+// Count 返回正在处理中的 unary 请求数和流数
+func (f *InFlight) Count() (unary, streams int64) {
+	return f.unary.Load(), f.streams.Load()
+}
+
+// UnaryInterceptor 统计 unary 请求
+func (f *InFlight) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	f.unary.Add(1)
+	defer f.unary.Add(-1)
+
+	return handler(ctx, req)
+}
+
+// StreamInterceptor 统计流式请求
+func (f *InFlight) StreamInterceptor(svr interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	f.streams.Add(1)
+	defer f.streams.Add(-1)
+
+	return handler(svr, stream)
+}
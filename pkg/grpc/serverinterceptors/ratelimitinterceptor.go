@@ -0,0 +1,56 @@
+package serverinterceptors
+
+import (
+	"context"
+	"math"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/yanking/app-skeleton/pkg/errors"
+	"github.com/yanking/app-skeleton/pkg/ratelimit"
+)
+
+// RetryAfterHeader 被限流时返回的响应头，值为建议等待的秒数
+const RetryAfterHeader = "retry-after"
+
+// RateLimiter 判断请求是否超出配额，*ratelimit.Limiter 实现了该接口
+type RateLimiter interface {
+	Allow(ctx context.Context, fullMethod string) ratelimit.Result
+}
+
+// rateLimited 返回带 RetryInfo 详情的 ResourceExhausted 错误，以及 retry-after 响应头
+func rateLimited(fullMethod string, res ratelimit.Result) (metadata.MD, error) {
+	seconds := int(math.Ceil(res.RetryAfter.Seconds()))
+	header := metadata.Pairs(RetryAfterHeader, strconv.Itoa(seconds))
+
+	return header, errors.TooManyRequests("RATE_LIMITED", "rate limit exceeded for %s", fullMethod).
+		WithRetryAfter(res.RetryAfter)
+}
+
+// UnaryRateLimitInterceptor 限流拦截器，按 subject 限流时需要放在认证拦截器之后
+func UnaryRateLimitInterceptor(limiter RateLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		if res := limiter.Allow(ctx, info.FullMethod); !res.Allowed {
+			header, err := rateLimited(info.FullMethod, res)
+			_ = grpc.SetHeader(ctx, header)
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamRateLimitInterceptor 流式请求的限流拦截器，只在建立流时消耗一个配额
+func StreamRateLimitInterceptor(limiter RateLimiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		if res := limiter.Allow(stream.Context(), info.FullMethod); !res.Allowed {
+			header, err := rateLimited(info.FullMethod, res)
+			_ = stream.SetHeader(header)
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
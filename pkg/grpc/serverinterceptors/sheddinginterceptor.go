@@ -0,0 +1,100 @@
+package serverinterceptors
+
+import (
+	"context"
+
+	"github.com/go-kratos/aegis/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/yanking/app-skeleton/pkg/errors"
+	"github.com/yanking/app-skeleton/pkg/metric"
+)
+
+const (
+	// PriorityHeader 请求优先级的 metadata key. 该值由调用方设置，需要在入口代理处清除外部请求携带的值.
+	PriorityHeader = "x-request-priority"
+	// PriorityCritical 关键请求，过载时也不会被丢弃
+	PriorityCritical = "critical"
+)
+
+// DefaultCriticalMethods 过载时也不会被丢弃的方法：健康检查在过载时仍需如实反映服务状态
+var DefaultCriticalMethods = []string{
+	"/grpc.health.v1.Health/*",
+}
+
+var metricServerReqShedTotal = metric.NewCounterVec(&metric.CounterVecOpts{
+	Namespace: serverNamespace,
+	Subsystem: "requests",
+	Name:      serverName + "_shed_total",
+	Help:      "rpc server requests shed by the adaptive limiter.",
+	Labels:    []string{"method"},
+})
+
+// shedder 根据方法和请求优先级决定是否经过自适应限流器
+type shedder struct {
+	limiter  ratelimit.Limiter
+	critical *methodSet
+}
+
+func newShedder(limiter ratelimit.Limiter, criticalMethods []string) *shedder {
+	return &shedder{
+		limiter:  limiter,
+		critical: newMethodSet(append(append([]string{}, DefaultCriticalMethods...), criticalMethods...)...),
+	}
+}
+
+// allow 返回请求结束时需要调用的 done，关键请求不经过限流器，done 为 nil
+func (s *shedder) allow(ctx context.Context, fullMethod string) (ratelimit.DoneFunc, error) {
+	if s.critical.contains(fullMethod) {
+		return nil, nil
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(PriorityHeader); len(values) > 0 && values[0] == PriorityCritical {
+			return nil, nil
+		}
+	}
+
+	done, err := s.limiter.Allow()
+	if err != nil {
+		metricServerReqShedTotal.Inc(fullMethod)
+		return nil, errors.TooManyRequests("OVERLOADED", "server is overloaded, please retry later")
+	}
+	return done, nil
+}
+
+// UnaryShedInterceptor 自适应限流拦截器，服务过载时丢弃请求并返回 ResourceExhausted.
+// limiter 通常是 bbr.NewLimiter 创建的限流器，它根据 CPU 使用率、处理中的请求数和请求耗时判断是否过载.
+// criticalMethods 和携带 x-request-priority: critical 的请求不会被丢弃.
+func UnaryShedInterceptor(limiter ratelimit.Limiter, criticalMethods ...string) grpc.UnaryServerInterceptor {
+	s := newShedder(limiter, criticalMethods)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		done, err := s.allow(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := handler(ctx, req)
+		if done != nil {
+			done(ratelimit.DoneInfo{Err: err})
+		}
+		return resp, err
+	}
+}
+
+// StreamShedInterceptor 流式请求的自适应限流拦截器. 只在建立流时判断是否过载，
+// 长连接的流不计入处理中的请求和耗时，避免影响对 unary 请求的判断.
+func StreamShedInterceptor(limiter ratelimit.Limiter, criticalMethods ...string) grpc.StreamServerInterceptor {
+	s := newShedder(limiter, criticalMethods)
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		done, err := s.allow(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		if done != nil {
+			done(ratelimit.DoneInfo{})
+		}
+		return handler(srv, stream)
+	}
+}
@@ -0,0 +1,139 @@
+package serverinterceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/aegis/ratelimit"
+	"github.com/go-kratos/aegis/ratelimit/bbr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/yanking/app-skeleton/pkg/errors"
+)
+
+// fakeLimiter overloaded 为 true 时拒绝所有请求，并记录放行的请求的结果
+type fakeLimiter struct {
+	overloaded bool
+	allowed    int
+	done       []ratelimit.DoneInfo
+}
+
+func (l *fakeLimiter) Allow() (ratelimit.DoneFunc, error) {
+	if l.overloaded {
+		return nil, ratelimit.ErrLimitExceed
+	}
+	l.allowed++
+	return func(info ratelimit.DoneInfo) { l.done = append(l.done, info) }, nil
+}
+
+func TestUnaryShedInterceptor(t *testing.T) {
+	critical := metadata.NewIncomingContext(context.Background(), metadata.Pairs(PriorityHeader, PriorityCritical))
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		method     string
+		overloaded bool
+		// wantShed 请求是否被丢弃，wantLimited 请求是否经过限流器
+		wantShed    bool
+		wantLimited bool
+	}{
+		{"not overloaded", context.Background(), "/demo.v1.UserService/GetUser", false, false, true},
+		{"overloaded", context.Background(), "/demo.v1.UserService/GetUser", true, true, false},
+		{"health check is never shed", context.Background(), "/grpc.health.v1.Health/Check", true, false, false},
+		{"critical method", context.Background(), "/demo.v1.DemoService/Healthz", true, false, false},
+		{"critical service", context.Background(), "/demo.v1.AdminService/Drain", true, false, false},
+		{"critical priority", critical, "/demo.v1.UserService/GetUser", true, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := &fakeLimiter{overloaded: tt.overloaded}
+			interceptor := UnaryShedInterceptor(limiter, "/demo.v1.DemoService/Healthz", "/demo.v1.AdminService/*")
+			called := false
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				return req, nil
+			}
+			_, err := interceptor(tt.ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if tt.wantShed {
+				if errors.Code(err) != codes.ResourceExhausted || errors.Reason(err) != "OVERLOADED" || called {
+					t.Fatalf("err = %v, handler called = %t, want ResourceExhausted with reason OVERLOADED", err, called)
+				}
+				return
+			}
+			if err != nil || !called {
+				t.Fatalf("err = %v, handler called = %t", err, called)
+			}
+			if limited := limiter.allowed > 0; limited != tt.wantLimited {
+				t.Fatalf("passed through the limiter = %t, want %t", limited, tt.wantLimited)
+			}
+			if len(limiter.done) != limiter.allowed {
+				t.Fatalf("done called %d time(s) for %d allowed request(s)", len(limiter.done), limiter.allowed)
+			}
+		})
+	}
+}
+
+func TestUnaryShedInterceptorReportsResult(t *testing.T) {
+	limiter := &fakeLimiter{}
+	interceptor := UnaryShedInterceptor(limiter)
+	info := &grpc.UnaryServerInfo{FullMethod: "/demo.v1.UserService/GetUser"}
+
+	failure := errors.NotFound("USER_NOT_FOUND", "user not found")
+	_, _ = interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	_, _ = interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, failure
+	})
+	if len(limiter.done) != 2 || limiter.done[0].Err != nil || limiter.done[1].Err != failure {
+		t.Fatalf("done infos = %+v, want a success and the handler error", limiter.done)
+	}
+}
+
+// TestShedInterceptorFeedsBBR 放行的请求计入 BBR 处理中的请求数，结束后释放
+func TestShedInterceptorFeedsBBR(t *testing.T) {
+	limiter := bbr.NewLimiter()
+	info := &grpc.UnaryServerInfo{FullMethod: "/demo.v1.UserService/GetUser"}
+
+	var inFlight int64
+	_, err := UnaryShedInterceptor(limiter)(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		inFlight = limiter.Stat().InFlight
+		return req, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inFlight != 1 || limiter.Stat().InFlight != 0 {
+		t.Fatalf("in-flight = %d during the call and %d after, want 1 and 0", inFlight, limiter.Stat().InFlight)
+	}
+
+	// 流只在建立时经过限流器，不计入处理中的请求
+	err = StreamShedInterceptor(limiter)(nil, &ctxStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/demo.v1.UserService/Watch"},
+		func(srv interface{}, ss grpc.ServerStream) error {
+			inFlight = limiter.Stat().InFlight
+			return nil
+		})
+	if err != nil || inFlight != 0 {
+		t.Fatalf("stream: err = %v, in-flight = %d, want 0", err, inFlight)
+	}
+}
+
+func TestStreamShedInterceptor(t *testing.T) {
+	interceptor := StreamShedInterceptor(&fakeLimiter{overloaded: true})
+	called := false
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		called = true
+		return nil
+	}
+
+	err := interceptor(nil, &ctxStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/demo.v1.UserService/Watch"}, handler)
+	if errors.Code(err) != codes.ResourceExhausted || called {
+		t.Fatalf("err = %v, handler called = %t, want ResourceExhausted", err, called)
+	}
+	err = interceptor(nil, &ctxStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/grpc.health.v1.Health/Watch"}, handler)
+	if err != nil || !called {
+		t.Fatalf("health watch: err = %v, handler called = %t", err, called)
+	}
+}
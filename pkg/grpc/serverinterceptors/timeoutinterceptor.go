@@ -2,7 +2,6 @@ package serverinterceptors
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"runtime/debug"
 	"strings"
@@ -10,14 +9,26 @@ import (
 	"time"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+
+	"github.com/yanking/app-skeleton/pkg/errors"
 )
 
 // UnaryTimeoutInterceptor returns a func that sets timeout to incoming unary requests.
 func UnaryTimeoutInterceptor(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return UnaryDynamicTimeoutInterceptor(func() time.Duration { return timeout })
+}
+
+// UnaryDynamicTimeoutInterceptor returns a func that sets timeout to incoming unary requests,
+// the timeout is read from timeoutFn on every request so it can be changed at runtime.
+// A non-positive timeout disables the interceptor.
+func UnaryDynamicTimeoutInterceptor(timeoutFn func() time.Duration) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler) (interface{}, error) {
+		timeout := timeoutFn()
+		if timeout <= 0 {
+			return handler(ctx, req)
+		}
+
 		ctx, cancel := context.WithTimeout(ctx, timeout)
 		defer cancel()
 
@@ -49,13 +60,8 @@ func UnaryTimeoutInterceptor(timeout time.Duration) grpc.UnaryServerInterceptor
 			defer lock.Unlock()
 			return resp, err
 		case <-ctx.Done():
-			err = ctx.Err()
-			if errors.Is(err, context.Canceled) {
-				err = status.Error(codes.Canceled, err.Error())
-			} else if errors.Is(err, context.DeadlineExceeded) {
-				err = status.Error(codes.DeadlineExceeded, err.Error())
-			}
-			return nil, err
+			// 转换为 Canceled 或 DeadlineExceeded
+			return nil, errors.FromError(ctx.Err())
 		}
 	}
 }
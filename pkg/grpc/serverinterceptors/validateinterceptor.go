@@ -0,0 +1,66 @@
+package serverinterceptors
+
+import (
+	"context"
+	stderrors "errors"
+
+	"buf.build/go/protovalidate"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/yanking/app-skeleton/pkg/errors"
+)
+
+// validate 按 proto 中声明的 (buf.validate.field) 等规则校验请求:
+//   - 不满足规则时返回 InvalidArgument，每条违规转换为 BadRequest 中的一个字段
+//   - 规则本身无法编译或执行时返回 Internal，原始错误只记录在日志中
+func validate(validator protovalidate.Validator, req interface{}) error {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return nil
+	}
+	err := validator.Validate(msg)
+	if err == nil {
+		return nil
+	}
+	var ve *protovalidate.ValidationError
+	if !stderrors.As(err, &ve) {
+		return errors.Internal("INVALID_VALIDATION_RULES", "failed to validate request").WithCause(err)
+	}
+	e := errors.BadRequest("VALIDATION_FAILED", "request validation failed")
+	for _, v := range ve.Violations {
+		e = e.WithFieldViolation(protovalidate.FieldPathString(v.Proto.GetField()), v.Proto.GetMessage())
+	}
+	return e
+}
+
+// UnaryValidateInterceptor 请求校验拦截器，在处理程序之前校验请求，放在认证和限流拦截器之后
+func UnaryValidateInterceptor(validator protovalidate.Validator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		if err := validate(validator, req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamValidateInterceptor 流式请求的校验拦截器，校验客户端发送的每条消息
+func StreamValidateInterceptor(validator protovalidate.Validator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		return handler(srv, &validateStream{ServerStream: stream, validator: validator})
+	}
+}
+
+type validateStream struct {
+	grpc.ServerStream
+	validator protovalidate.Validator
+}
+
+func (s *validateStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return validate(s.validator, m)
+}
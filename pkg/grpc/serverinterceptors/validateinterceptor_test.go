@@ -0,0 +1,123 @@
+package serverinterceptors
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"buf.build/go/protovalidate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	v1 "github.com/yanking/app-skeleton/api/proto/gen/demo/v1"
+)
+
+// fieldViolations 返回错误中 BadRequest 详情的字段和描述
+func fieldViolations(t *testing.T, err error) map[string]string {
+	t.Helper()
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("code = %s, want InvalidArgument (%v)", st.Code(), err)
+	}
+	violations := make(map[string]string)
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.GetFieldViolations() {
+				violations[v.GetField()] = v.GetDescription()
+			}
+		}
+	}
+	return violations
+}
+
+func TestUnaryValidateInterceptor(t *testing.T) {
+	validator, err := protovalidate.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	interceptor := UnaryValidateInterceptor(validator)
+
+	tests := []struct {
+		name string
+		req  proto.Message
+		// wantFields 为空时请求应当通过校验
+		wantFields []string
+	}{
+		{"valid create", &v1.CreateUserRequest{Name: "alice", Email: "alice@example.com"}, nil},
+		{"missing required fields", &v1.CreateUserRequest{}, []string{"name", "email"}},
+		{"pattern and email", &v1.CreateUserRequest{Name: "alice!", Email: "alice"}, []string{"name", "email"}},
+		{"page size out of range", &v1.ListUsersRequest{PageSize: 101}, []string{"page_size"}},
+		{"page size at the limit", &v1.ListUsersRequest{PageSize: 100}, nil},
+		{"empty id", &v1.GetUserRequest{}, []string{"id"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				return req, nil
+			}
+			_, err := interceptor(context.Background(), tt.req, &grpc.UnaryServerInfo{}, handler)
+			if len(tt.wantFields) == 0 {
+				if err != nil || !called {
+					t.Fatalf("valid request: err = %v, handler called = %t", err, called)
+				}
+				return
+			}
+
+			if called {
+				t.Fatal("handler called for invalid request")
+			}
+			violations := fieldViolations(t, err)
+			if len(violations) != len(tt.wantFields) {
+				t.Fatalf("field violations = %v, want fields %v", violations, tt.wantFields)
+			}
+			for _, field := range tt.wantFields {
+				if violations[field] == "" {
+					t.Errorf("missing violation for %s in %v", field, violations)
+				}
+			}
+		})
+	}
+}
+
+// recvStream 依次返回 msgs 中的消息
+type recvStream struct {
+	grpc.ServerStream
+	msgs []proto.Message
+}
+
+func (s *recvStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *recvStream) RecvMsg(m interface{}) error {
+	proto.Merge(m.(proto.Message), s.msgs[0])
+	s.msgs = s.msgs[1:]
+	return nil
+}
+
+func TestStreamValidateInterceptor(t *testing.T) {
+	validator, err := protovalidate.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := &recvStream{msgs: []proto.Message{
+		&v1.ListUsersRequest{PageSize: 10},
+		&v1.ListUsersRequest{PageSize: -1, PageToken: strings.Repeat("a", 257)},
+	}}
+
+	err = StreamValidateInterceptor(validator)(nil, stream, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+		if err := ss.RecvMsg(&v1.ListUsersRequest{}); err != nil {
+			t.Fatalf("first message: %v", err)
+		}
+		return ss.RecvMsg(&v1.ListUsersRequest{})
+	})
+	got := fieldViolations(t, err)
+	if want := []string{"page_size", "page_token"}; len(got) != len(want) || got[want[0]] == "" || got[want[1]] == "" {
+		t.Fatalf("field violations = %v, want fields %v", got, want)
+	}
+}
@@ -0,0 +1,63 @@
+package grpc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/aegis/ratelimit/bbr"
+)
+
+// SheddingOptions 自适应限流（过载保护）相关配置. 与按配额限流不同，它不需要预先设定阈值：
+// CPU 使用率超过 CPUThreshold 时，根据最近一段时间的通过量和最小耗时估算服务能承受的并发数，超出的请求直接返回 ResourceExhausted.
+type SheddingOptions struct {
+	// Enabled 是否启用自适应限流
+	Enabled bool `json:"enabled" mapstructure:"enabled"`
+	// CPUThreshold 开始丢弃请求的 CPU 使用率，单位为千分之一，默认 800 即 80%
+	CPUThreshold int64 `json:"cpu-threshold" mapstructure:"cpu-threshold"`
+	// Window 统计通过量和耗时的时间窗口，默认 10s
+	Window time.Duration `json:"window" mapstructure:"window"`
+	// Bucket 时间窗口内的桶数，默认 100
+	Bucket int `json:"bucket" mapstructure:"bucket"`
+	// CriticalMethods 过载时也不会被丢弃的方法，以 /* 结尾表示整个服务. 健康检查总是不会被丢弃.
+	CriticalMethods []string `json:"critical-methods" mapstructure:"critical-methods"`
+}
+
+// Validate 校验配置是否合法
+func (o *SheddingOptions) Validate() []error {
+	if o == nil || !o.Enabled {
+		return nil
+	}
+
+	var errs []error
+	if o.CPUThreshold < 0 || o.CPUThreshold > 1000 {
+		errs = append(errs, fmt.Errorf("shedding: cpu-threshold must be between 0 and 1000"))
+	}
+	if o.Window < 0 || o.Bucket < 0 {
+		errs = append(errs, fmt.Errorf("shedding: window and bucket must not be negative"))
+	}
+	return errs
+}
+
+// limiter 按配置创建 BBR 限流器
+func (o *SheddingOptions) limiter() *bbr.BBR {
+	var opts []bbr.Option
+	if o.CPUThreshold > 0 {
+		opts = append(opts, bbr.WithCPUThreshold(o.CPUThreshold))
+	}
+	if o.Window > 0 {
+		opts = append(opts, bbr.WithWindow(o.Window))
+	}
+	if o.Bucket > 0 {
+		opts = append(opts, bbr.WithBucket(o.Bucket))
+	}
+	return bbr.NewLimiter(opts...)
+}
+
+// WithLoadShedding 启用自适应限流，服务过载时丢弃请求并返回 ResourceExhausted
+func WithLoadShedding(opts SheddingOptions) ServerOption {
+	return func(s *Server) {
+		if opts.Enabled {
+			s.shedding = &opts
+		}
+	}
+}
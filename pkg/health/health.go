@@ -0,0 +1,378 @@
+// Package health 提供 Kubernetes 风格的健康检查.
+// 组件和依赖通过 Registry 注册具名的检查项，Registry 按探针类型（liveness、readiness、startup）
+// 聚合检查结果，通过 HTTP 的 /livez、/readyz、/startupz 对外暴露，并通知订阅者（如 gRPC health 服务）状态变化.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yanking/app-skeleton/pkg/log"
+)
+
+// Kind 探针类型
+type Kind string
+
+const (
+	// Liveness 进程是否存活，失败时应重启进程
+	Liveness Kind = "livez"
+	// Readiness 是否可以接收流量，失败时应摘除流量
+	Readiness Kind = "readyz"
+	// Startup 是否已完成启动，成功之前不进行 liveness 和 readiness 探测
+	Startup Kind = "startupz"
+)
+
+// Status 检查结果
+type Status string
+
+const (
+	// StatusPass 检查通过
+	StatusPass Status = "pass"
+	// StatusWarn 非关键检查失败，服务降级但仍可用
+	StatusWarn Status = "warn"
+	// StatusFail 关键检查失败
+	StatusFail Status = "fail"
+)
+
+const (
+	defaultCheckTimeout = 2 * time.Second
+	defaultInterval     = 10 * time.Second
+)
+
+// CheckFunc 执行一次检查，返回错误表示检查失败
+type CheckFunc func(ctx context.Context) error
+
+// Checker 一个具名的检查项
+type Checker struct {
+	// Name 检查项名称，在 Registry 中唯一
+	Name string
+	// Check 检查函数
+	Check CheckFunc
+	// Kinds 检查项参与的探针类型，为空时只参与 readiness
+	Kinds []Kind
+	// Timeout 单次检查的超时时间，默认 2s
+	Timeout time.Duration
+	// CacheTTL 检查结果的缓存时间，避免探针频繁访问下游依赖；为 0 时不缓存
+	CacheTTL time.Duration
+	// Critical 关键检查项失败时探针失败，非关键检查项失败时只标记为降级
+	Critical bool
+}
+
+// Result 单个检查项的结果
+type Result struct {
+	Name      string        `json:"name"`
+	Status    Status        `json:"status"`
+	Critical  bool          `json:"critical"`
+	Error     string        `json:"error,omitempty"`
+	Duration  time.Duration `json:"duration"`
+	CheckedAt time.Time     `json:"checked_at"`
+	Cached    bool          `json:"cached,omitempty"`
+}
+
+// Report 一次探针的聚合结果
+type Report struct {
+	Kind   Kind     `json:"kind"`
+	Status Status   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Healthy 探针是否通过，降级视为通过
+func (r Report) Healthy() bool {
+	return r.Status != StatusFail
+}
+
+// Failed 返回失败的检查项
+func (r Report) Failed() []Result {
+	var failed []Result
+	for _, c := range r.Checks {
+		if c.Status != StatusPass {
+			failed = append(failed, c)
+		}
+	}
+	return failed
+}
+
+type check struct {
+	Checker
+
+	// mu 串行化同一检查项的并发执行，使并发的探针共享缓存结果
+	mu       sync.Mutex
+	last     Result
+	hasCache bool
+}
+
+func (c *check) is(kind Kind) bool {
+	return slices.Contains(c.Kinds, kind)
+}
+
+func (c *check) run(ctx context.Context) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.hasCache && c.CacheTTL > 0 && time.Since(c.last.CheckedAt) < c.CacheTTL {
+		r := c.last
+		r.Cached = true
+		return r
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("panic: %v", p)
+			}
+		}()
+		done <- c.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", c.Timeout)
+	}
+
+	r := Result{Name: c.Name, Status: StatusPass, Critical: c.Critical, Duration: time.Since(start), CheckedAt: start}
+	if err != nil {
+		r.Error = err.Error()
+		r.Status = StatusWarn
+		if c.Critical {
+			r.Status = StatusFail
+		}
+	}
+	c.last = r
+	c.hasCache = true
+	return r
+}
+
+type Option func(r *Registry)
+
+// WithInterval 设置后台检查的周期，默认 10s
+func WithInterval(interval time.Duration) Option {
+	return func(r *Registry) {
+		r.interval = interval
+	}
+}
+
+type subscriber struct {
+	kind Kind
+	fn   func(Report)
+}
+
+// Registry 管理所有检查项，并作为 app.IComponent 在后台周期性地执行检查，在探针或任一检查项的状态变化时通知订阅者
+type Registry struct {
+	mu     sync.RWMutex
+	checks []*check
+
+	interval time.Duration
+
+	subMu sync.Mutex
+	subs  []subscriber
+	last  map[Kind]Status
+	// lastChecks 每个探针上一次各检查项的状态，用于发现探针整体状态不变时单个检查项的变化
+	lastChecks map[Kind]map[string]Status
+
+	started  atomic.Bool
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewRegistry 创建健康检查的注册中心
+func NewRegistry(opts ...Option) *Registry {
+	r := &Registry{
+		interval:   defaultInterval,
+		last:       make(map[Kind]Status),
+		lastChecks: make(map[Kind]map[string]Status),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Register 注册检查项
+func (r *Registry) Register(c Checker) error {
+	if c.Name == "" {
+		return errors.New("health: checker name must not be empty")
+	}
+	if c.Check == nil {
+		return fmt.Errorf("health: checker %q has no check function", c.Name)
+	}
+	if len(c.Kinds) == 0 {
+		c.Kinds = []Kind{Readiness}
+	}
+	for _, k := range c.Kinds {
+		if k != Liveness && k != Readiness && k != Startup {
+			return fmt.Errorf("health: checker %q has unknown kind %q", c.Name, k)
+		}
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultCheckTimeout
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.checks {
+		if existing.Name == c.Name {
+			return fmt.Errorf("health: checker %q already registered", c.Name)
+		}
+	}
+	r.checks = append(r.checks, &check{Checker: c})
+	return nil
+}
+
+// MustRegister 注册检查项，失败时 panic
+func (r *Registry) MustRegister(c Checker) {
+	if err := r.Register(c); err != nil {
+		panic(err)
+	}
+}
+
+// Subscribe 订阅某一探针的状态变化，fn 在探针或其中任一检查项的状态变化时以最新的结果调用
+func (r *Registry) Subscribe(kind Kind, fn func(Report)) {
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+	r.subs = append(r.subs, subscriber{kind: kind, fn: fn})
+}
+
+// Check 并发执行某一探针的所有检查项并聚合结果，exclude 中的检查项会被跳过.
+// 没有检查项时探针通过.
+func (r *Registry) Check(ctx context.Context, kind Kind, exclude ...string) Report {
+	r.mu.RLock()
+	var checks []*check
+	for _, c := range r.checks {
+		if c.is(kind) && !slices.Contains(exclude, c.Name) {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	report := Report{Kind: kind, Status: StatusPass, Checks: make([]Result, len(checks))}
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = c.run(ctx)
+		}()
+	}
+	wg.Wait()
+
+	for _, c := range report.Checks {
+		switch {
+		case c.Status == StatusFail:
+			report.Status = StatusFail
+		case c.Status == StatusWarn && report.Status == StatusPass:
+			report.Status = StatusWarn
+		}
+	}
+
+	// 排除了部分检查项的结果不代表探针的真实状态，不通知订阅者
+	if len(exclude) == 0 {
+		r.notify(report)
+	}
+	return report
+}
+
+// notify 在探针或任一检查项的状态变化时通知订阅者. 探针整体状态不变时单个检查项也可能失败或恢复，
+// 订阅者（如绑定了检查项的 gRPC 服务）需要据此更新状态
+func (r *Registry) notify(report Report) {
+	checks := make(map[string]Status, len(report.Checks))
+	for _, c := range report.Checks {
+		checks[c.Name] = c.Status
+	}
+
+	r.subMu.Lock()
+	prev, seen := r.last[report.Kind]
+	checksChanged := !maps.Equal(r.lastChecks[report.Kind], checks)
+	r.last[report.Kind] = report.Status
+	r.lastChecks[report.Kind] = checks
+	var subs []subscriber
+	if !seen || prev != report.Status || checksChanged {
+		for _, s := range r.subs {
+			if s.kind == report.Kind {
+				subs = append(subs, s)
+			}
+		}
+	}
+	r.subMu.Unlock()
+
+	if seen && prev != report.Status {
+		log.Infof("health: %s changed from %s to %s", report.Kind, prev, report.Status)
+	}
+	for _, s := range subs {
+		s.fn(report)
+	}
+}
+
+// Refresh 立即执行所有被订阅的探针，用于在状态可能发生变化时（如启动完成后）尽快同步给订阅者
+func (r *Registry) Refresh(ctx context.Context) {
+	r.subMu.Lock()
+	var kinds []Kind
+	for _, s := range r.subs {
+		if !slices.Contains(kinds, s.kind) {
+			kinds = append(kinds, s.kind)
+		}
+	}
+	r.subMu.Unlock()
+
+	for _, k := range kinds {
+		r.Check(ctx, k)
+	}
+}
+
+func (r *Registry) Name() string {
+	return "healthRegistry"
+}
+
+// Start 在后台周期性地执行被订阅的探针，立即返回
+func (r *Registry) Start(ctx context.Context) error {
+	if !r.started.CompareAndSwap(false, true) {
+		return nil
+	}
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		r.Refresh(ctx)
+		for {
+			select {
+			case <-ticker.C:
+				r.Refresh(ctx)
+			case <-r.stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop 停止后台检查
+func (r *Registry) Stop(ctx context.Context) error {
+	r.stopOnce.Do(func() { close(r.stop) })
+	if !r.started.Load() {
+		return nil
+	}
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,228 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// toggle 结果可以在运行时切换的检查函数
+type toggle struct {
+	err   atomic.Value
+	calls atomic.Int32
+}
+
+func (c *toggle) set(err error) { c.err.Store(&err) }
+
+func (c *toggle) check(ctx context.Context) error {
+	c.calls.Add(1)
+	if err, ok := c.err.Load().(*error); ok {
+		return *err
+	}
+	return nil
+}
+
+func pass(ctx context.Context) error { return nil }
+
+func fail(ctx context.Context) error { return errors.New("connection refused") }
+
+func TestCheckAggregation(t *testing.T) {
+	tests := []struct {
+		name     string
+		checkers []Checker
+		want     Status
+	}{
+		{"no checks", nil, StatusPass},
+		{"all pass", []Checker{{Name: "db", Check: pass, Critical: true}, {Name: "cache", Check: pass}}, StatusPass},
+		{"non-critical failure degrades", []Checker{{Name: "db", Check: pass, Critical: true}, {Name: "cache", Check: fail}}, StatusWarn},
+		{"critical failure fails", []Checker{{Name: "db", Check: fail, Critical: true}, {Name: "cache", Check: pass}}, StatusFail},
+		{"critical failure wins over warnings", []Checker{{Name: "db", Check: fail, Critical: true}, {Name: "cache", Check: fail}}, StatusFail},
+		{"panic fails", []Checker{{Name: "db", Check: func(ctx context.Context) error { panic("boom") }, Critical: true}}, StatusFail},
+		{"timeout fails", []Checker{{Name: "db", Critical: true, Timeout: 10 * time.Millisecond, Check: func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		}}}, StatusFail},
+		{"other kinds are ignored", []Checker{{Name: "deadlock", Check: fail, Critical: true, Kinds: []Kind{Liveness}}}, StatusPass},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			for _, c := range tt.checkers {
+				r.MustRegister(c)
+			}
+			report := r.Check(context.Background(), Readiness)
+			if report.Status != tt.want || report.Kind != Readiness {
+				t.Fatalf("report = %+v, want %s", report, tt.want)
+			}
+			if report.Healthy() != (tt.want != StatusFail) {
+				t.Fatalf("Healthy() = %t for status %s", report.Healthy(), report.Status)
+			}
+			for _, f := range report.Failed() {
+				if f.Error == "" {
+					t.Fatalf("failed check %s has no error", f.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckExclude(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(Checker{Name: "db", Check: fail, Critical: true})
+	r.MustRegister(Checker{Name: "cache", Check: pass})
+	var notified int
+	r.Subscribe(Readiness, func(Report) { notified++ })
+
+	report := r.Check(context.Background(), Readiness, "db")
+	if report.Status != StatusPass || len(report.Checks) != 1 || report.Checks[0].Name != "cache" {
+		t.Fatalf("report = %+v, want only cache", report)
+	}
+	// 排除了检查项的结果不通知订阅者
+	if notified != 0 {
+		t.Fatalf("subscriber notified %d time(s) for a partial report", notified)
+	}
+}
+
+func TestCheckCache(t *testing.T) {
+	c := &toggle{}
+	r := NewRegistry()
+	r.MustRegister(Checker{Name: "db", Check: c.check, CacheTTL: time.Hour})
+
+	first := r.Check(context.Background(), Readiness)
+	second := r.Check(context.Background(), Readiness)
+	if c.calls.Load() != 1 {
+		t.Fatalf("check called %d times, want 1", c.calls.Load())
+	}
+	if first.Checks[0].Cached || !second.Checks[0].Cached {
+		t.Fatalf("cached = %t, %t, want false, true", first.Checks[0].Cached, second.Checks[0].Cached)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(Checker{Name: "db", Check: pass})
+	tests := []struct {
+		name    string
+		checker Checker
+	}{
+		{"empty name", Checker{Check: pass}},
+		{"no check function", Checker{Name: "cache"}},
+		{"unknown kind", Checker{Name: "cache", Check: pass, Kinds: []Kind{"healthz"}}},
+		{"duplicate name", Checker{Name: "db", Check: pass}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := r.Register(tt.checker); err == nil {
+				t.Fatal("Register() succeeded")
+			}
+		})
+	}
+}
+
+// subscription 记录订阅者收到的通知
+type subscription struct {
+	mu      sync.Mutex
+	reports []Report
+}
+
+func (s *subscription) notify(r Report) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reports = append(s.reports, r)
+}
+
+func (s *subscription) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.reports)
+}
+
+func (s *subscription) last() Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reports[len(s.reports)-1]
+}
+
+func TestSubscribe(t *testing.T) {
+	db, cache := &toggle{}, &toggle{}
+	r := NewRegistry()
+	r.MustRegister(Checker{Name: "db", Check: db.check, Critical: true})
+	r.MustRegister(Checker{Name: "cache", Check: cache.check})
+	r.MustRegister(Checker{Name: "deadlock", Check: pass, Kinds: []Kind{Liveness}})
+	readiness, liveness := &subscription{}, &subscription{}
+	r.Subscribe(Readiness, readiness.notify)
+	r.Subscribe(Liveness, liveness.notify)
+	ctx := context.Background()
+
+	steps := []struct {
+		name  string
+		setup func()
+		// wantNotified 这一次检查是否通知订阅者
+		wantNotified bool
+		wantStatus   Status
+	}{
+		{"first check", func() {}, true, StatusPass},
+		{"unchanged", func() {}, false, StatusPass},
+		{"critical check fails", func() { db.set(errors.New("down")) }, true, StatusFail},
+		{"still failing", func() {}, false, StatusFail},
+		// 探针整体状态不变，但单个检查项发生了变化
+		{"non-critical check fails while failed", func() { cache.set(errors.New("down")) }, true, StatusFail},
+		{"critical check recovers", func() { db.set(nil) }, true, StatusWarn},
+		{"non-critical check recovers", func() { cache.set(nil) }, true, StatusPass},
+	}
+	for _, step := range steps {
+		before := readiness.count()
+		step.setup()
+		r.Check(ctx, Readiness)
+		notified := readiness.count() > before
+		if notified != step.wantNotified {
+			t.Fatalf("%s: notified = %t, want %t", step.name, notified, step.wantNotified)
+		}
+		if notified && readiness.last().Status != step.wantStatus {
+			t.Fatalf("%s: notified status = %s, want %s", step.name, readiness.last().Status, step.wantStatus)
+		}
+	}
+	if liveness.count() != 0 {
+		t.Fatalf("liveness subscriber notified %d time(s) by readiness checks", liveness.count())
+	}
+
+	// Refresh 执行所有被订阅的探针
+	r.Refresh(ctx)
+	if liveness.count() != 1 || liveness.last().Kind != Liveness {
+		t.Fatalf("liveness notifications = %d after Refresh, want 1", liveness.count())
+	}
+}
+
+func TestRegistryStartStop(t *testing.T) {
+	db := &toggle{}
+	r := NewRegistry(WithInterval(10 * time.Millisecond))
+	r.MustRegister(Checker{Name: "db", Check: db.check, Critical: true})
+	sub := &subscription{}
+	r.Subscribe(Readiness, sub.notify)
+
+	if err := r.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	db.set(errors.New("down"))
+	deadline := time.Now().Add(5 * time.Second)
+	for sub.count() == 0 || sub.last().Status != StatusFail {
+		if time.Now().After(deadline) {
+			t.Fatal("background check did not report the failure")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := r.Stop(ctx); err != nil {
+		t.Fatalf("Stop() = %v", err)
+	}
+	calls := db.calls.Load()
+	time.Sleep(50 * time.Millisecond)
+	if db.calls.Load() != calls {
+		t.Fatal("checks still running after Stop")
+	}
+}
@@ -0,0 +1,63 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Handler 返回某一探针的 HTTP 处理器，行为与 Kubernetes 的 /livez、/readyz 一致：
+//   - 探针通过时返回 200，失败时返回 503
+//   - ?verbose 输出每个检查项的结果
+//   - ?exclude=name 跳过指定的检查项，可以重复
+//   - Accept: application/json 时以 JSON 格式输出完整结果
+func (r *Registry) Handler(kind Kind) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		report := r.Check(req.Context(), kind, query["exclude"]...)
+
+		code := http.StatusOK
+		if !report.Healthy() {
+			code = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Cache-Control", "no-store")
+
+		if strings.Contains(req.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(code)
+			_ = json.NewEncoder(w).Encode(report)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(code)
+
+		_, verbose := query["verbose"]
+		if !verbose && report.Healthy() {
+			fmt.Fprint(w, "ok")
+			return
+		}
+
+		for _, c := range report.Checks {
+			switch c.Status {
+			case StatusPass:
+				fmt.Fprintf(w, "[+]%s ok\n", c.Name)
+			case StatusWarn:
+				fmt.Fprintf(w, "[!]%s degraded: %s\n", c.Name, c.Error)
+			default:
+				fmt.Fprintf(w, "[-]%s failed: %s\n", c.Name, c.Error)
+			}
+		}
+		for _, name := range query["exclude"] {
+			fmt.Fprintf(w, "[+]%s excluded: ok\n", name)
+		}
+
+		if report.Healthy() {
+			fmt.Fprintf(w, "%s check passed\n", kind)
+		} else {
+			fmt.Fprintf(w, "%s check failed\n", kind)
+		}
+	})
+}
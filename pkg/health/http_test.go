@@ -0,0 +1,76 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(Checker{Name: "db", Check: fail, Critical: true})
+	r.MustRegister(Checker{Name: "cache", Check: fail})
+	r.MustRegister(Checker{Name: "config", Check: pass})
+	r.MustRegister(Checker{Name: "deadlock", Check: pass, Kinds: []Kind{Liveness}})
+
+	tests := []struct {
+		name     string
+		kind     Kind
+		target   string
+		wantCode int
+		// wantBody 响应体中应包含的内容
+		wantBody []string
+	}{
+		{"liveness passes", Liveness, "/livez", http.StatusOK, []string{"ok"}},
+		{"liveness verbose", Liveness, "/livez?verbose", http.StatusOK, []string{"[+]deadlock ok", "livez check passed"}},
+		{"critical failure", Readiness, "/readyz", http.StatusServiceUnavailable, []string{
+			"[-]db failed: connection refused", "[!]cache degraded: connection refused", "[+]config ok", "readyz check failed",
+		}},
+		{"degraded is still healthy", Readiness, "/readyz?exclude=db", http.StatusOK, []string{"ok"}},
+		{"exclude verbose", Readiness, "/readyz?exclude=db&exclude=cache&verbose", http.StatusOK, []string{
+			"[+]config ok", "[+]db excluded: ok", "[+]cache excluded: ok", "readyz check passed",
+		}},
+		{"startup without checks", Startup, "/startupz", http.StatusOK, []string{"ok"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.Handler(tt.kind).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if rec.Header().Get("Cache-Control") != "no-store" {
+				t.Fatalf("Cache-Control = %q, want no-store", rec.Header().Get("Cache-Control"))
+			}
+			for _, want := range tt.wantBody {
+				if !strings.Contains(rec.Body.String(), want) {
+					t.Fatalf("body = %q, want %q", rec.Body, want)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlerJSON(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(Checker{Name: "db", Check: fail, Critical: true})
+
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	r.Handler(Readiness).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("status = %d, content type = %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var report Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Kind != Readiness || report.Status != StatusFail || len(report.Checks) != 1 ||
+		report.Checks[0].Name != "db" || report.Checks[0].Error != "connection refused" || !report.Checks[0].Critical {
+		t.Fatalf("report = %+v", report)
+	}
+}
@@ -39,6 +39,7 @@ type Logger interface {
 	With(fields ...Field) Logger
 
 	SetLevel(level string)
+	Level() string
 
 	AddCallerSkip(skip int) Logger
 	Sync()
@@ -46,7 +47,9 @@ type Logger interface {
 
 // zapLogger 是 Logger 接口的具体实现. 它底层封装了 zap.Logger.
 type zapLogger struct {
-	z                 *zap.Logger
+	z *zap.Logger
+	// level 为 zap 的动态日志级别，所有由同一个 Logger 派生出来的 Logger 共享
+	level             zap.AtomicLevel
 	opts              *Options
 	contextExtractors map[string]func(context.Context) string // 定义从 context 中提取字段的映射
 }
@@ -116,6 +119,8 @@ func NewLogger(opts *Options, options ...Option) *zapLogger {
 		outputPaths = []string{"stdout"}
 	}
 
+	level := zap.NewAtomicLevelAt(zapLevel)
+
 	// 创建构建 zap.Logger 需要的配置
 	cfg := &zap.Config{
 		// 是否在日志中显示调用日志所在的文件和行号，例如：`"caller":"onex/onex.go:75"`
@@ -123,7 +128,7 @@ func NewLogger(opts *Options, options ...Option) *zapLogger {
 		// 是否禁止在 panic 及以上级别打印堆栈信息
 		DisableStacktrace: opts.DisableStacktrace,
 		// 指定日志级别
-		Level: zap.NewAtomicLevelAt(zapLevel),
+		Level: level,
 		// 指定日志显示格式，可选值：console, json
 		Encoding:      opts.Format,
 		EncoderConfig: encoderConfig,
@@ -139,7 +144,7 @@ func NewLogger(opts *Options, options ...Option) *zapLogger {
 		panic(err)
 	}
 
-	logger := &zapLogger{z: z, opts: opts, contextExtractors: make(map[string]func(context.Context) string)}
+	logger := &zapLogger{z: z, level: level, opts: opts, contextExtractors: make(map[string]func(context.Context) string)}
 	// 应用所有传入的 Option
 	for _, opt := range options {
 		opt(logger)
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 func AddCallerSkip(skip int) Logger {
@@ -52,15 +53,23 @@ func SetLevel(level string) {
 	std.SetLevel(level)
 }
 
+// SetLevel 动态调整日志级别，非法的级别会被忽略
 func (l *zapLogger) SetLevel(level string) {
-	lc := l.clone()
-	lc.opts.Level = level
-	if lc.z != nil {
-		if parsedLevel, err := zap.ParseAtomicLevel(level); err == nil {
-			lc.z = lc.z.WithOptions(zap.IncreaseLevel(parsedLevel))
-		}
+	var zapLevel zapcore.Level
+	if err := zapLevel.UnmarshalText([]byte(level)); err != nil {
+		return
 	}
-	*l = *lc
+	l.level.SetLevel(zapLevel)
+	l.opts.Level = level
+}
+
+// Level 返回当前的日志级别
+func Level() string {
+	return std.Level()
+}
+
+func (l *zapLogger) Level() string {
+	return l.level.Level().String()
 }
 
 // clone 深度拷贝 zapLogger.
@@ -1,6 +1,8 @@
 package log
 
 import (
+	"fmt"
+
 	"github.com/spf13/pflag"
 	"go.uber.org/zap/zapcore"
 )
@@ -34,6 +36,14 @@ func NewOptions() *Options {
 func (o *Options) Validate() []error {
 	var errs []error
 
+	if err := ValidateLevel(o.Level); err != nil {
+		errs = append(errs, err)
+	}
+
+	if o.Format != "console" && o.Format != "json" {
+		errs = append(errs, fmt.Errorf("invalid log format %q, must be console or json", o.Format))
+	}
+
 	return errs
 }
 
@@ -43,7 +53,16 @@ func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	fs.BoolVar(&o.DisableCaller, "log.disable-caller", o.DisableCaller, "Disable output of caller information in the log.")
 	fs.BoolVar(&o.DisableStacktrace, "log.disable-stacktrace", o.DisableStacktrace, ""+
 		"Disable the log to record a stack trace for all messages at or above panic level.")
-	fs.BoolVar(&o.EnableColor, "log.enable-color", o.EnableColor, "Enable output ansi colors in plain format logs.")
-	fs.StringVar(&o.Format, "log.format", o.Format, "Log output `FORMAT`, support plain or json format.")
+	fs.BoolVar(&o.EnableColor, "log.enable-color", o.EnableColor, "Enable output ansi colors in console format logs.")
+	fs.StringVar(&o.Format, "log.format", o.Format, "Log output `FORMAT`, support console or json format.")
 	fs.StringSliceVar(&o.OutputPaths, "log.output-paths", o.OutputPaths, "Output paths of log.")
 }
+
+// ValidateLevel 校验日志级别是否合法
+func ValidateLevel(level string) error {
+	var zapLevel zapcore.Level
+	if err := zapLevel.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+	return nil
+}
@@ -16,6 +16,9 @@ type VectorOpts struct {
 	Labels    []string
 }
 
+// Handler 在 :9091 上启动一个不受 App 管理的 metrics 服务.
+//
+// Deprecated: 使用 pkg/admin 的 admin.Server，它作为组件随 App 启动和优雅关闭，且监听地址可配置.
 func Handler() {
 	metricsMux := http.NewServeMux()
 	metricsMux.Handle("/metrics", promhttp.Handler())
@@ -0,0 +1,159 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/yanking/app-skeleton/pkg/auth"
+	"github.com/yanking/app-skeleton/pkg/log"
+	"github.com/yanking/app-skeleton/pkg/metric"
+)
+
+// 限流判断的结果，作为指标的 decision 标签
+const (
+	decisionAllowed = "allowed"
+	decisionLimited = "limited"
+	decisionError   = "error"
+)
+
+var metricDecisions = metric.NewCounterVec(&metric.CounterVecOpts{
+	Namespace: "rpc_server",
+	Subsystem: "ratelimit",
+	Name:      "decisions_total",
+	Help:      "rpc server rate limit decisions.",
+	Labels:    []string{"method", "decision"},
+})
+
+// rules 某一时刻生效的规则，重载时整体替换
+type rules struct {
+	enabled           bool
+	trustForwardedFor bool
+	fallback          Rule
+	methods           map[string]Rule
+	services          map[string]Rule
+}
+
+func newRules(opts *Options) *rules {
+	r := &rules{
+		enabled:           opts.Enabled,
+		trustForwardedFor: opts.TrustForwardedFor,
+		fallback:          opts.Default,
+		methods:           make(map[string]Rule),
+		services:          make(map[string]Rule),
+	}
+	for _, rule := range opts.Methods {
+		if service, ok := strings.CutSuffix(rule.Method, "*"); ok {
+			r.services[service] = rule
+			continue
+		}
+		r.methods[rule.Method] = rule
+	}
+	return r
+}
+
+// match 返回方法对应的规则以及规则的名称，精确匹配优先于服务匹配，都没有时使用默认规则
+func (r *rules) match(fullMethod string) (Rule, string) {
+	if rule, ok := r.methods[fullMethod]; ok {
+		return rule, fullMethod
+	}
+	if i := strings.LastIndex(fullMethod, "/"); i > 0 {
+		service := fullMethod[:i+1]
+		if rule, ok := r.services[service]; ok {
+			return rule, service + "*"
+		}
+	}
+	return r.fallback, "default"
+}
+
+// Limiter 按规则对 gRPC 请求限流
+type Limiter struct {
+	store Store
+	rules atomic.Pointer[rules]
+	now   func() time.Time
+}
+
+// NewLimiter 创建限流器，opts 需要先通过 Validate 校验
+func NewLimiter(opts *Options, store Store) *Limiter {
+	l := &Limiter{store: store, now: time.Now}
+	l.Update(opts)
+	return l
+}
+
+// Update 替换限流规则，用于配置重载. 存储后端不会改变，已有的配额状态继续生效.
+func (l *Limiter) Update(opts *Options) {
+	l.rules.Store(newRules(opts))
+}
+
+// Allow 为请求消耗一个配额. 存储后端出错时放行请求，避免限流组件故障导致服务不可用.
+func (l *Limiter) Allow(ctx context.Context, fullMethod string) Result {
+	r := l.rules.Load()
+	if !r.enabled {
+		return Result{Allowed: true}
+	}
+
+	rule, name := r.match(fullMethod)
+	if rule.Rate <= 0 {
+		return Result{Allowed: true}
+	}
+
+	key := "rule:" + name + ":" + r.key(ctx, rule)
+	res, err := l.store.Take(ctx, key, rule.Limit(), l.now())
+	if err != nil {
+		log.Warnf("ratelimit: %s: %v, request allowed", fullMethod, err)
+		metricDecisions.Inc(fullMethod, decisionError)
+		return Result{Allowed: true}
+	}
+
+	if res.Allowed {
+		metricDecisions.Inc(fullMethod, decisionAllowed)
+	} else {
+		metricDecisions.Inc(fullMethod, decisionLimited)
+	}
+	return res
+}
+
+// key 返回请求在规则维度下的标识，如 peer:10.0.0.1
+func (r *rules) key(ctx context.Context, rule Rule) string {
+	switch k := rule.key(); {
+	case k == KeyMethod:
+		return k
+	case k == KeySubject:
+		if claims, ok := auth.FromContext(ctx); ok && claims.Subject != "" {
+			return k + ":" + claims.Subject
+		}
+	case strings.HasPrefix(k, KeyMetadataPrefix):
+		md, _ := metadata.FromIncomingContext(ctx)
+		if values := md.Get(strings.TrimPrefix(k, KeyMetadataPrefix)); len(values) > 0 && values[0] != "" {
+			return k + ":" + values[0]
+		}
+	}
+	return KeyPeer + ":" + r.peer(ctx)
+}
+
+// peer 返回调用方 IP
+func (r *rules) peer(ctx context.Context) string {
+	if r.trustForwardedFor {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if values := md.Get("x-forwarded-for"); len(values) > 0 {
+			if ip, _, _ := strings.Cut(values[0], ","); strings.TrimSpace(ip) != "" {
+				return strings.TrimSpace(ip)
+			}
+		}
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "unknown"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
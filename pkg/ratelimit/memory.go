@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval 清理长时间未访问的 key 的间隔
+const sweepInterval = time.Minute
+
+type memoryEntry struct {
+	// 令牌桶的状态
+	tokens float64
+	last   time.Time
+	// 滑动窗口的状态
+	window     int64
+	prev, curr int
+
+	period   time.Duration
+	accessed time.Time
+}
+
+// MemoryStore 进程内的限流状态，只在单实例内生效
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	lastSweep time.Time
+}
+
+// NewMemoryStore 创建进程内的限流状态存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*memoryEntry)}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	e, ok := s.entries[key]
+	if !ok {
+		e = &memoryEntry{tokens: float64(limit.Burst), last: now}
+		s.entries[key] = e
+	}
+	e.period = limit.Period
+	e.accessed = now
+
+	if limit.Algorithm == SlidingWindow {
+		window := now.UnixNano() / int64(limit.Period)
+		switch {
+		case window == e.window+1:
+			e.prev, e.curr = e.curr, 0
+		case window != e.window:
+			e.prev, e.curr = 0, 0
+		}
+		e.window = window
+
+		elapsed := time.Duration(now.UnixNano() - window*int64(limit.Period))
+		r := slidingWindow(e.prev, e.curr, elapsed, limit)
+		if r.Allowed {
+			e.curr++
+		}
+		return r, nil
+	}
+
+	r, tokens := tokenBucket(e.tokens, e.last, now, limit)
+	e.tokens, e.last = tokens, now
+	return r, nil
+}
+
+// sweep 删除超过两个窗口没有访问的 key，避免按调用方区分配额时内存无限增长
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, e := range s.entries {
+		if now.Sub(e.accessed) > 2*max(e.period, sweepInterval) {
+			delete(s.entries, key)
+		}
+	}
+}
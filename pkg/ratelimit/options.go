@@ -0,0 +1,135 @@
+// Package ratelimit 提供令牌桶和滑动窗口两种限流算法，限流状态保存在可替换的 Store 中（内存或 Redis），
+// 规则按方法配置，可以按方法、调用方 IP、认证后的 subject 或某个 metadata 区分配额.
+package ratelimit
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Algorithm 限流算法
+type Algorithm string
+
+const (
+	// TokenBucket 令牌桶，允许 Burst 大小的突发流量
+	TokenBucket Algorithm = "token-bucket"
+	// SlidingWindow 滑动窗口，任意 Period 时间内最多 Rate 个请求
+	SlidingWindow Algorithm = "sliding-window"
+)
+
+// 限流的维度
+const (
+	// KeyMethod 同一方法的所有请求共享配额
+	KeyMethod = "method"
+	// KeyPeer 按调用方 IP 区分配额
+	KeyPeer = "peer"
+	// KeySubject 按 token 中的 subject 区分配额，未认证的请求按调用方 IP 区分
+	KeySubject = "subject"
+	// KeyMetadataPrefix 按请求 metadata 中某个 key 的值区分配额，如 metadata:x-tenant-id，缺少该 key 时按调用方 IP 区分
+	KeyMetadataPrefix = "metadata:"
+)
+
+// 存储后端
+const (
+	StoreMemory = "memory"
+	StoreRedis  = "redis"
+)
+
+// Rule 一条限流规则
+type Rule struct {
+	// Method 方法的完整名称，如 /demo.v1.UserService/CreateUser，以 /* 结尾表示整个服务
+	Method string `json:"method" mapstructure:"method"`
+	// Algorithm 限流算法，可选值：token-bucket, sliding-window，默认 token-bucket
+	Algorithm Algorithm `json:"algorithm" mapstructure:"algorithm"`
+	// Key 限流维度，可选值：method, peer, subject, metadata:<key>，默认 peer
+	Key string `json:"key" mapstructure:"key"`
+	// Rate 每个 Period 允许的请求数，为 0 时不限流
+	Rate int `json:"rate" mapstructure:"rate"`
+	// Period 时间窗口，默认 1s，最小 1ms
+	Period time.Duration `json:"period" mapstructure:"period"`
+	// Burst 令牌桶的容量，默认等于 Rate，只对 token-bucket 生效
+	Burst int `json:"burst" mapstructure:"burst"`
+}
+
+// Limit 返回规则对应的配额
+func (r Rule) Limit() Limit {
+	l := Limit{Algorithm: r.Algorithm, Rate: r.Rate, Period: r.Period, Burst: r.Burst}
+	if l.Algorithm == "" {
+		l.Algorithm = TokenBucket
+	}
+	if l.Period <= 0 {
+		l.Period = time.Second
+	}
+	if l.Burst <= 0 {
+		l.Burst = l.Rate
+	}
+	return l
+}
+
+func (r Rule) key() string {
+	if r.Key == "" {
+		return KeyPeer
+	}
+	return r.Key
+}
+
+func (r Rule) validate(name string) []error {
+	var errs []error
+	switch r.Algorithm {
+	case "", TokenBucket, SlidingWindow:
+	default:
+		errs = append(errs, fmt.Errorf("ratelimit: %s: invalid algorithm %q", name, r.Algorithm))
+	}
+	switch k := r.key(); {
+	case k == KeyMethod, k == KeyPeer, k == KeySubject:
+	case strings.HasPrefix(k, KeyMetadataPrefix) && len(k) > len(KeyMetadataPrefix):
+	default:
+		errs = append(errs, fmt.Errorf("ratelimit: %s: invalid key %q", name, r.Key))
+	}
+	if r.Rate < 0 || r.Burst < 0 || r.Period < 0 {
+		errs = append(errs, fmt.Errorf("ratelimit: %s: rate, burst and period must not be negative", name))
+	}
+	// Redis 存储以毫秒计算速率，小于 1ms 的 Period 会被截断为 0
+	if r.Period > 0 && r.Period < time.Millisecond {
+		errs = append(errs, fmt.Errorf("ratelimit: %s: period %s must be at least 1ms", name, r.Period))
+	}
+	return errs
+}
+
+// Options 限流相关配置
+type Options struct {
+	// Enabled 是否启用限流
+	Enabled bool `json:"enabled" mapstructure:"enabled"`
+	// Store 限流状态的存储后端，可选值：memory, redis. 多实例部署时使用 redis 共享配额
+	Store string `json:"store" mapstructure:"store"`
+	// TrustForwardedFor 是否使用 x-forwarded-for 中的第一个地址作为调用方 IP，只应在调用方都经过可信代理（如 gRPC-Gateway 之前的负载均衡）时开启.
+	// 进程内 gRPC-Gateway 的请求不需要开启，peer 已经是 HTTP 客户端的地址
+	TrustForwardedFor bool `json:"trust-forwarded-for" mapstructure:"trust-forwarded-for"`
+	// Default 没有匹配到方法规则时使用的默认规则
+	Default Rule `json:"default" mapstructure:"default"`
+	// Methods 按方法覆盖的规则，精确匹配优先于服务匹配
+	Methods []Rule `json:"methods" mapstructure:"methods"`
+}
+
+// Validate 校验配置是否合法
+func (o *Options) Validate() []error {
+	if o == nil || !o.Enabled {
+		return nil
+	}
+
+	var errs []error
+	switch o.Store {
+	case "", StoreMemory, StoreRedis:
+	default:
+		errs = append(errs, fmt.Errorf("ratelimit: invalid store %q", o.Store))
+	}
+	errs = append(errs, o.Default.validate("default")...)
+	for _, r := range o.Methods {
+		if !strings.HasPrefix(r.Method, "/") {
+			errs = append(errs, fmt.Errorf("ratelimit: method %q must be a full method name", r.Method))
+		}
+		errs = append(errs, r.validate(r.Method)...)
+	}
+	return errs
+}
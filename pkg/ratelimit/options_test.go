@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		opts *Options
+		// wantErr 错误中应包含的内容，为空表示合法
+		wantErr string
+	}{
+		{"nil", nil, ""},
+		{"disabled", &Options{Store: "etcd"}, ""},
+		{"defaults", &Options{Enabled: true}, ""},
+		{"full", &Options{Enabled: true, Store: StoreRedis,
+			Default: Rule{Rate: 100, Period: time.Second},
+			Methods: []Rule{
+				{Method: "/demo.v1.UserService/CreateUser", Algorithm: SlidingWindow, Key: KeySubject, Rate: 10, Period: time.Minute},
+				{Method: "/demo.v1.UserService/*", Key: "metadata:x-tenant-id", Rate: 5, Period: time.Millisecond, Burst: 10},
+			},
+		}, ""},
+		{"invalid store", &Options{Enabled: true, Store: "etcd"}, `invalid store "etcd"`},
+		{"invalid algorithm", &Options{Enabled: true, Default: Rule{Algorithm: "leaky-bucket"}}, `default: invalid algorithm "leaky-bucket"`},
+		{"invalid key", &Options{Enabled: true, Default: Rule{Key: "metadata:"}}, `default: invalid key "metadata:"`},
+		{"negative rate", &Options{Enabled: true, Default: Rule{Rate: -1}}, "must not be negative"},
+		{"negative period", &Options{Enabled: true, Default: Rule{Period: -time.Second}}, "must not be negative"},
+		{"period below 1ms", &Options{Enabled: true, Default: Rule{Rate: 1, Period: 500 * time.Microsecond}}, "default: period 500µs must be at least 1ms"},
+		{"method period below 1ms", &Options{Enabled: true, Methods: []Rule{{Method: "/demo.v1.UserService/*", Rate: 1, Period: time.Nanosecond}}},
+			"/demo.v1.UserService/*: period 1ns must be at least 1ms"},
+		{"not a full method name", &Options{Enabled: true, Methods: []Rule{{Method: "CreateUser"}}}, `method "CreateUser" must be a full method name`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.opts.Validate()
+			if tt.wantErr == "" {
+				if len(errs) != 0 {
+					t.Fatalf("Validate() = %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.wantErr) {
+				t.Fatalf("Validate() = %v, want an error containing %q", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRuleLimit(t *testing.T) {
+	got := Rule{Rate: 10}.Limit()
+	want := Limit{Algorithm: TokenBucket, Rate: 10, Period: time.Second, Burst: 10}
+	if got != want {
+		t.Fatalf("Limit() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript 原子地计算并更新令牌桶. 时间由调用方传入，避免依赖 Redis 服务器时间.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local ttl = tonumber(ARGV[4])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+  tokens = burst
+  ts = now
+end
+
+if now > ts then
+  tokens = math.min(burst, tokens + (now - ts) * rate)
+  ts = now
+end
+
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  wait = math.ceil((1 - tokens) / rate)
+end
+
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(ts))
+redis.call('PEXPIRE', KEYS[1], ttl)
+return {allowed, math.floor(tokens), wait}
+`)
+
+// slidingWindowScript 原子地读取前后两个窗口的计数，未超出配额时增加当前窗口的计数
+var slidingWindowScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local weight = tonumber(ARGV[2])
+local ttl = tonumber(ARGV[3])
+
+local curr = tonumber(redis.call('GET', KEYS[1]) or '0')
+local prev = tonumber(redis.call('GET', KEYS[2]) or '0')
+
+if prev * weight + curr + 1 <= rate then
+  redis.call('INCR', KEYS[1])
+  redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return {prev, curr}
+`)
+
+// RedisStore 将限流状态保存在 Redis 中，多个实例共享配额
+type RedisStore struct {
+	client redis.Scripter
+	prefix string
+}
+
+// NewRedisStore 创建基于 Redis 的限流状态存储，client 可以是 *redis.Client 或 *redis.ClusterClient
+func NewRedisStore(client redis.Scripter, prefix string) *RedisStore {
+	if prefix == "" {
+		prefix = "ratelimit"
+	}
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	key = s.prefix + ":" + key
+
+	if limit.Algorithm == SlidingWindow {
+		period := int64(limit.Period)
+		window := now.UnixNano() / period
+		elapsed := time.Duration(now.UnixNano() - window*period)
+		weight := 1 - float64(elapsed)/float64(limit.Period)
+
+		// 使用 hash tag 保证 Redis Cluster 中前后两个窗口在同一个 slot
+		keys := []string{
+			fmt.Sprintf("{%s}:%d", key, window),
+			fmt.Sprintf("{%s}:%d", key, window-1),
+		}
+		ttl := 2 * limit.Period.Milliseconds()
+		v, err := slidingWindowScript.Run(ctx, s.client, keys, limit.Rate, strconv.FormatFloat(weight, 'f', -1, 64), ttl).Int64Slice()
+		if err != nil {
+			return Result{}, fmt.Errorf("ratelimit: redis: %w", err)
+		}
+		return slidingWindow(int(v[0]), int(v[1]), elapsed, limit), nil
+	}
+
+	perMs := float64(limit.Rate) / float64(limit.Period.Milliseconds())
+	// 令牌桶从空到满所需的时间之后状态不再有意义
+	ttl := int64(float64(limit.Burst)/perMs) + limit.Period.Milliseconds()
+	v, err := tokenBucketScript.Run(ctx, s.client, []string{key},
+		strconv.FormatFloat(perMs, 'f', -1, 64), limit.Burst, now.UnixMilli(), ttl).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: redis: %w", err)
+	}
+	return Result{
+		Allowed:    v[0] == 1,
+		Remaining:  int(v[1]),
+		RetryAfter: time.Duration(v[2]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit 一个 key 的配额
+type Limit struct {
+	Algorithm Algorithm
+	// Rate 每个 Period 允许的请求数
+	Rate   int
+	Period time.Duration
+	// Burst 令牌桶的容量
+	Burst int
+}
+
+// Result 一次限流判断的结果
+type Result struct {
+	Allowed bool
+	// Remaining 剩余的配额
+	Remaining int
+	// RetryAfter 被拒绝时建议的重试等待时间
+	RetryAfter time.Duration
+}
+
+// Store 保存限流状态. 实现需要保证同一 key 的判断是原子的.
+type Store interface {
+	// Take 为 key 消耗一个配额
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// tokenBucket 计算令牌桶的状态，返回判断结果和新的令牌数
+func tokenBucket(tokens float64, last, now time.Time, limit Limit) (Result, float64) {
+	perSecond := float64(limit.Rate) / limit.Period.Seconds()
+	if elapsed := now.Sub(last).Seconds(); elapsed > 0 {
+		tokens = math.Min(float64(limit.Burst), tokens+elapsed*perSecond)
+	}
+	if tokens >= 1 {
+		tokens--
+		return Result{Allowed: true, Remaining: int(tokens)}, tokens
+	}
+	wait := time.Duration((1 - tokens) / perSecond * float64(time.Second))
+	return Result{RetryAfter: wait}, tokens
+}
+
+// slidingWindow 以前后两个固定窗口的加权计数近似滑动窗口
+func slidingWindow(prev, curr int, elapsed time.Duration, limit Limit) Result {
+	weight := 1 - float64(elapsed)/float64(limit.Period)
+	count := float64(prev)*weight + float64(curr)
+	if count+1 <= float64(limit.Rate) {
+		return Result{Allowed: true, Remaining: int(float64(limit.Rate) - count - 1)}
+	}
+
+	// 当前窗口已满时需要等到下一个窗口，否则等待前一个窗口的权重下降到足以容纳一个请求
+	wait := limit.Period - elapsed
+	if curr+1 <= limit.Rate && prev > 0 {
+		need := 1 - float64(limit.Rate-curr-1)/float64(prev)
+		wait = time.Duration(need*float64(limit.Period)) - elapsed
+	}
+	return Result{RetryAfter: max(wait, time.Millisecond)}
+}
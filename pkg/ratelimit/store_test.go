@@ -0,0 +1,180 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// base 对齐到秒，使滑动窗口从窗口开始处计算
+var base = time.Unix(1_700_000_000, 0)
+
+// stores 返回所有 Store 的实现，Redis 使用进程内的 miniredis
+func stores() map[string]func(t *testing.T) Store {
+	return map[string]func(t *testing.T) Store{
+		"memory": func(t *testing.T) Store {
+			return NewMemoryStore()
+		},
+		"redis": func(t *testing.T) Store {
+			mr := miniredis.RunT(t)
+			client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+			t.Cleanup(func() { _ = client.Close() })
+			return NewRedisStore(client, "test")
+		},
+	}
+}
+
+type step struct {
+	// at 相对 base 的时间
+	at            time.Duration
+	key           string
+	wantAllowed   bool
+	wantRemaining int
+	// wantRetryAfter 被拒绝时建议的等待时间
+	wantRetryAfter time.Duration
+}
+
+func TestStoreTake(t *testing.T) {
+	tokenBucket := Limit{Algorithm: TokenBucket, Rate: 10, Period: time.Second, Burst: 3}
+	slidingWindow := Limit{Algorithm: SlidingWindow, Rate: 3, Period: time.Second}
+
+	tests := []struct {
+		name  string
+		limit Limit
+		steps []step
+	}{
+		{
+			name:  "token bucket allows burst then refills",
+			limit: tokenBucket,
+			steps: []step{
+				{at: 0, wantAllowed: true, wantRemaining: 2},
+				{at: 0, wantAllowed: true, wantRemaining: 1},
+				{at: 0, wantAllowed: true, wantRemaining: 0},
+				{at: 0, wantAllowed: false, wantRetryAfter: 100 * time.Millisecond},
+				// 每 100ms 补充一个令牌
+				{at: 100 * time.Millisecond, wantAllowed: true, wantRemaining: 0},
+				{at: 100 * time.Millisecond, wantAllowed: false, wantRetryAfter: 100 * time.Millisecond},
+				// 令牌数不超过 Burst
+				{at: 10 * time.Second, wantAllowed: true, wantRemaining: 2},
+			},
+		},
+		{
+			name:  "token bucket keys are independent",
+			limit: tokenBucket,
+			steps: []step{
+				{at: 0, key: "a", wantAllowed: true, wantRemaining: 2},
+				{at: 0, key: "a", wantAllowed: true, wantRemaining: 1},
+				{at: 0, key: "a", wantAllowed: true, wantRemaining: 0},
+				{at: 0, key: "a", wantAllowed: false, wantRetryAfter: 100 * time.Millisecond},
+				{at: 0, key: "b", wantAllowed: true, wantRemaining: 2},
+			},
+		},
+		{
+			name:  "sliding window rejects until next window when current window is full",
+			limit: slidingWindow,
+			steps: []step{
+				{at: 0, wantAllowed: true, wantRemaining: 2},
+				{at: 100 * time.Millisecond, wantAllowed: true, wantRemaining: 1},
+				{at: 200 * time.Millisecond, wantAllowed: true, wantRemaining: 0},
+				{at: 400 * time.Millisecond, wantAllowed: false, wantRetryAfter: 600 * time.Millisecond},
+			},
+		},
+		{
+			name:  "sliding window weights the previous window",
+			limit: slidingWindow,
+			steps: []step{
+				{at: 0, wantAllowed: true, wantRemaining: 2},
+				{at: 0, wantAllowed: true, wantRemaining: 1},
+				{at: 0, wantAllowed: true, wantRemaining: 0},
+				// 下一个窗口过半时前一个窗口的权重为 0.5，计数为 1.5
+				{at: 1500 * time.Millisecond, wantAllowed: true, wantRemaining: 0},
+				// 计数为 2.5，需要等前一个窗口的权重下降到 1/3
+				{at: 1500 * time.Millisecond, wantAllowed: false, wantRetryAfter: 2*time.Second/3 - 500*time.Millisecond},
+			},
+		},
+		{
+			name:  "sliding window forgets windows older than one period",
+			limit: slidingWindow,
+			steps: []step{
+				{at: 0, wantAllowed: true, wantRemaining: 2},
+				{at: 0, wantAllowed: true, wantRemaining: 1},
+				{at: 0, wantAllowed: true, wantRemaining: 0},
+				{at: 2 * time.Second, wantAllowed: true, wantRemaining: 2},
+			},
+		},
+	}
+
+	for storeName, newStore := range stores() {
+		for _, tt := range tests {
+			t.Run(storeName+"/"+tt.name, func(t *testing.T) {
+				store := newStore(t)
+				for i, s := range tt.steps {
+					key := s.key
+					if key == "" {
+						key = "key"
+					}
+					got, err := store.Take(context.Background(), key, tt.limit, base.Add(s.at))
+					if err != nil {
+						t.Fatalf("step %d: Take() error = %v", i, err)
+					}
+					if got.Allowed != s.wantAllowed {
+						t.Fatalf("step %d: Allowed = %t, want %t", i, got.Allowed, s.wantAllowed)
+					}
+					if s.wantAllowed && got.Remaining != s.wantRemaining {
+						t.Errorf("step %d: Remaining = %d, want %d", i, got.Remaining, s.wantRemaining)
+					}
+					if !s.wantAllowed && !approx(got.RetryAfter, s.wantRetryAfter) {
+						t.Errorf("step %d: RetryAfter = %s, want %s", i, got.RetryAfter, s.wantRetryAfter)
+					}
+				}
+			})
+		}
+	}
+}
+
+// approx Redis 中以毫秒计算，允许 1ms 的误差
+func approx(got, want time.Duration) bool {
+	d := got - want
+	return d > -time.Millisecond && d <= time.Millisecond
+}
+
+// TestStoreTakeConcurrent 同一 key 的并发请求不会超出配额
+func TestStoreTakeConcurrent(t *testing.T) {
+	limits := []Limit{
+		{Algorithm: TokenBucket, Rate: 1, Period: time.Hour, Burst: 20},
+		{Algorithm: SlidingWindow, Rate: 20, Period: time.Hour},
+	}
+	for storeName, newStore := range stores() {
+		for _, limit := range limits {
+			t.Run(fmt.Sprintf("%s/%s", storeName, limit.Algorithm), func(t *testing.T) {
+				store := newStore(t)
+				var allowed atomic.Int64
+				var wg sync.WaitGroup
+				for range 100 {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						r, err := store.Take(context.Background(), "key", limit, base)
+						if err != nil {
+							t.Error(err)
+							return
+						}
+						if r.Allowed {
+							allowed.Add(1)
+						}
+					}()
+				}
+				wg.Wait()
+				if got := allowed.Load(); got != 20 {
+					t.Fatalf("allowed %d requests, want 20", got)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+)
+
+const (
+	// defaultPollInterval 检查注册文件是否变化的默认间隔
+	defaultPollInterval = time.Second
+	// staleLockTimeout 超过该时间的锁文件视为持有者已经退出
+	staleLockTimeout = 10 * time.Second
+)
+
+// File 基于本地 JSON 文件的注册中心，同时实现了 Registrar 和 Discovery，用于在一台机器上运行多个进程的本地开发.
+// 文件内容为服务名称到实例列表的映射，多个进程通过锁文件互斥地修改. 进程异常退出时不会注销实例.
+type File struct {
+	path     string
+	interval time.Duration
+}
+
+// FileOption File 的配置项
+type FileOption func(f *File)
+
+// WithPollInterval 设置 Watcher 检查文件变化的间隔，默认 1s
+func WithPollInterval(interval time.Duration) FileOption {
+	return func(f *File) {
+		if interval > 0 {
+			f.interval = interval
+		}
+	}
+}
+
+// NewFile 创建基于文件的注册中心，文件不存在时在第一次注册时创建
+func NewFile(path string, opts ...FileOption) *File {
+	f := &File{path: path, interval: defaultPollInterval}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+func (f *File) Register(ctx context.Context, instance *ServiceInstance) error {
+	return f.update(ctx, func(services map[string][]*ServiceInstance) {
+		instances := slices.DeleteFunc(services[instance.Name], func(s *ServiceInstance) bool { return s.ID == instance.ID })
+		services[instance.Name] = append(instances, instance)
+	})
+}
+
+func (f *File) Deregister(ctx context.Context, instance *ServiceInstance) error {
+	return f.update(ctx, func(services map[string][]*ServiceInstance) {
+		instances := slices.DeleteFunc(services[instance.Name], func(s *ServiceInstance) bool { return s.ID == instance.ID })
+		if len(instances) == 0 {
+			delete(services, instance.Name)
+			return
+		}
+		services[instance.Name] = instances
+	})
+}
+
+func (f *File) GetService(ctx context.Context, name string) ([]*ServiceInstance, error) {
+	services, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+	instances := services[name]
+	sort.Slice(instances, func(i, j int) bool { return instances[i].ID < instances[j].ID })
+	return instances, nil
+}
+
+func (f *File) Watch(ctx context.Context, name string) (Watcher, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &fileWatcher{f: f, name: name, ctx: ctx, cancel: cancel}, nil
+}
+
+// read 读取注册文件，文件不存在时返回空的映射
+func (f *File) read() (map[string][]*ServiceInstance, error) {
+	services := make(map[string][]*ServiceInstance)
+	b, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && len(b) == 0) {
+		return services, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("registry: %w", err)
+	}
+	if err := json.Unmarshal(b, &services); err != nil {
+		return nil, fmt.Errorf("registry: parse %s: %w", f.path, err)
+	}
+	return services, nil
+}
+
+// update 在持有锁文件时读取、修改并原子地写回注册文件
+func (f *File) update(ctx context.Context, fn func(services map[string][]*ServiceInstance)) error {
+	unlock, err := f.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	services, err := f.read()
+	if err != nil {
+		return err
+	}
+	fn(services)
+
+	b, err := json.MarshalIndent(services, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return fmt.Errorf("registry: %w", err)
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return fmt.Errorf("registry: %w", err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		return fmt.Errorf("registry: %w", err)
+	}
+	return nil
+}
+
+// lock 创建锁文件，已存在时等待. 超过 staleLockTimeout 的锁文件会被删除.
+func (f *File) lock(ctx context.Context) (func(), error) {
+	lockPath := f.path + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0o755); err != nil {
+		return nil, fmt.Errorf("registry: %w", err)
+	}
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		lf, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_ = lf.Close()
+			return func() { _ = os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("registry: %w", err)
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleLockTimeout {
+			_ = os.Remove(lockPath)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("registry: waiting for %s: %w", lockPath, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// fileWatcher 定期读取注册文件，实例发生变化时返回
+type fileWatcher struct {
+	f      *File
+	name   string
+	ctx    context.Context
+	cancel context.CancelFunc
+	last   []*ServiceInstance
+	init   bool
+}
+
+func (w *fileWatcher) Next() ([]*ServiceInstance, error) {
+	if !w.init {
+		instances, err := w.f.GetService(w.ctx, w.name)
+		if err != nil {
+			return nil, err
+		}
+		w.init = true
+		w.last = instances
+		return instances, nil
+	}
+
+	ticker := time.NewTicker(w.f.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.ctx.Done():
+			return nil, ErrWatcherStopped
+		case <-ticker.C:
+		}
+
+		instances, err := w.f.GetService(w.ctx, w.name)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.EqualFunc(instances, w.last, (*ServiceInstance).Equal) {
+			w.last = instances
+			return instances, nil
+		}
+	}
+}
+
+func (w *fileWatcher) Stop() error {
+	w.cancel()
+	return nil
+}
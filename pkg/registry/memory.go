@@ -0,0 +1,117 @@
+package registry
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Memory 进程内的注册中心，同时实现了 Registrar 和 Discovery
+type Memory struct {
+	mu       sync.Mutex
+	services map[string]map[string]*ServiceInstance
+	watchers map[string]map[*memoryWatcher]struct{}
+}
+
+// NewMemory 创建进程内的注册中心
+func NewMemory() *Memory {
+	return &Memory{
+		services: make(map[string]map[string]*ServiceInstance),
+		watchers: make(map[string]map[*memoryWatcher]struct{}),
+	}
+}
+
+func (m *Memory) Register(ctx context.Context, instance *ServiceInstance) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	instances, ok := m.services[instance.Name]
+	if !ok {
+		instances = make(map[string]*ServiceInstance)
+		m.services[instance.Name] = instances
+	}
+	instances[instance.ID] = instance
+	m.notify(instance.Name)
+	return nil
+}
+
+func (m *Memory) Deregister(ctx context.Context, instance *ServiceInstance) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if instances, ok := m.services[instance.Name]; ok {
+		delete(instances, instance.ID)
+	}
+	m.notify(instance.Name)
+	return nil
+}
+
+func (m *Memory) GetService(ctx context.Context, name string) ([]*ServiceInstance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.list(name), nil
+}
+
+func (m *Memory) Watch(ctx context.Context, name string) (Watcher, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	w := &memoryWatcher{m: m, name: name, ctx: ctx, cancel: cancel, first: true, notify: make(chan struct{}, 1)}
+	if m.watchers[name] == nil {
+		m.watchers[name] = make(map[*memoryWatcher]struct{})
+	}
+	m.watchers[name][w] = struct{}{}
+	return w, nil
+}
+
+// list 返回按 ID 排序的实例，调用方需要持有锁
+func (m *Memory) list(name string) []*ServiceInstance {
+	instances := make([]*ServiceInstance, 0, len(m.services[name]))
+	for _, instance := range m.services[name] {
+		instances = append(instances, instance)
+	}
+	sort.Slice(instances, func(i, j int) bool { return instances[i].ID < instances[j].ID })
+	return instances
+}
+
+// notify 通知服务的所有 Watcher，调用方需要持有锁
+func (m *Memory) notify(name string) {
+	for w := range m.watchers[name] {
+		select {
+		case w.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+type memoryWatcher struct {
+	m      *Memory
+	name   string
+	ctx    context.Context
+	cancel context.CancelFunc
+	first  bool
+	notify chan struct{}
+}
+
+func (w *memoryWatcher) Next() ([]*ServiceInstance, error) {
+	if w.first {
+		w.first = false
+	} else {
+		select {
+		case <-w.ctx.Done():
+			return nil, ErrWatcherStopped
+		case <-w.notify:
+		}
+	}
+	if w.ctx.Err() != nil {
+		return nil, ErrWatcherStopped
+	}
+	return w.m.GetService(w.ctx, w.name)
+}
+
+func (w *memoryWatcher) Stop() error {
+	w.cancel()
+	w.m.mu.Lock()
+	defer w.m.mu.Unlock()
+	delete(w.m.watchers[w.name], w)
+	return nil
+}
@@ -0,0 +1,76 @@
+// Package registry 定义了服务注册与发现的接口，并提供内存和文件两种实现.
+// 内存实现用于同一进程内的测试，文件实现用于本地多进程开发；etcd、Consul 等实现只需满足相同的接口.
+package registry
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+)
+
+// ErrWatcherStopped Watcher 已经停止
+var ErrWatcherStopped = errors.New("registry: watcher stopped")
+
+// 常用的实例元数据
+const (
+	// MetadataWeight 实例的权重，供加权负载均衡使用，默认 1
+	MetadataWeight = "weight"
+)
+
+// ServiceInstance 服务的一个实例
+type ServiceInstance struct {
+	// ID 实例的唯一标识
+	ID string `json:"id"`
+	// Name 服务名称
+	Name string `json:"name"`
+	// Version 服务版本
+	Version string `json:"version"`
+	// Metadata 实例的元数据，如权重、区域
+	Metadata map[string]string `json:"metadata"`
+	// Endpoints 实例的访问地址，如 grpc://10.0.0.1:6666、http://10.0.0.1:5555
+	Endpoints []string `json:"endpoints"`
+}
+
+// Equal 判断两个实例是否相同
+func (s *ServiceInstance) Equal(o *ServiceInstance) bool {
+	if s == nil || o == nil {
+		return s == o
+	}
+	if s.ID != o.ID || s.Name != o.Name || s.Version != o.Version || len(s.Metadata) != len(o.Metadata) {
+		return false
+	}
+	for k, v := range s.Metadata {
+		if ov, ok := o.Metadata[k]; !ok || ov != v {
+			return false
+		}
+	}
+	a, b := slices.Clone(s.Endpoints), slices.Clone(o.Endpoints)
+	sort.Strings(a)
+	sort.Strings(b)
+	return slices.Equal(a, b)
+}
+
+// Registrar 服务注册
+type Registrar interface {
+	// Register 注册实例，同一 ID 重复注册时覆盖
+	Register(ctx context.Context, instance *ServiceInstance) error
+	// Deregister 注销实例
+	Deregister(ctx context.Context, instance *ServiceInstance) error
+}
+
+// Discovery 服务发现
+type Discovery interface {
+	// GetService 返回服务当前的所有实例
+	GetService(ctx context.Context, name string) ([]*ServiceInstance, error)
+	// Watch 监听服务实例的变化
+	Watch(ctx context.Context, name string) (Watcher, error)
+}
+
+// Watcher 监听一个服务的实例变化
+type Watcher interface {
+	// Next 第一次调用时立即返回当前的实例，之后阻塞直到实例发生变化、ctx 结束或 Watcher 被停止
+	Next() ([]*ServiceInstance, error)
+	// Stop 停止监听
+	Stop() error
+}
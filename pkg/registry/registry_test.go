@@ -0,0 +1,193 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// registrar 同时实现了 Registrar 和 Discovery 的注册中心
+type registrar interface {
+	Registrar
+	Discovery
+}
+
+// registries 返回所有注册中心的实现，文件注册中心使用 t.TempDir() 中的文件
+func registries() map[string]func(t *testing.T) registrar {
+	return map[string]func(t *testing.T) registrar{
+		"memory": func(t *testing.T) registrar {
+			return NewMemory()
+		},
+		"file": func(t *testing.T) registrar {
+			return NewFile(filepath.Join(t.TempDir(), "registry.json"), WithPollInterval(10*time.Millisecond))
+		},
+	}
+}
+
+func instance(id string, endpoints ...string) *ServiceInstance {
+	return &ServiceInstance{ID: id, Name: "demo", Version: "v1", Endpoints: endpoints}
+}
+
+func ids(instances []*ServiceInstance) []string {
+	out := make([]string, 0, len(instances))
+	for _, s := range instances {
+		out = append(out, s.ID)
+	}
+	return out
+}
+
+// next 在超时时间内等待 Watcher 返回
+func next(t *testing.T, w Watcher) []string {
+	t.Helper()
+	type result struct {
+		instances []*ServiceInstance
+		err       error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		instances, err := w.Next()
+		ch <- result{instances, err}
+	}()
+	select {
+	case r := <-ch:
+		if r.err != nil {
+			t.Fatalf("Next() error = %v", r.err)
+		}
+		return ids(r.instances)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Next() did not return")
+		return nil
+	}
+}
+
+func TestRegisterDeregister(t *testing.T) {
+	ctx := context.Background()
+	for name, newRegistry := range registries() {
+		t.Run(name, func(t *testing.T) {
+			r := newRegistry(t)
+			steps := []struct {
+				register   *ServiceInstance
+				deregister *ServiceInstance
+				want       []string
+			}{
+				{register: instance("b", "grpc://127.0.0.1:2"), want: []string{"b"}},
+				{register: instance("a", "grpc://127.0.0.1:1"), want: []string{"a", "b"}},
+				{deregister: instance("b"), want: []string{"a"}},
+				// 注销不存在的实例不报错
+				{deregister: instance("c"), want: []string{"a"}},
+				{deregister: instance("a"), want: []string{}},
+			}
+			for i, s := range steps {
+				var err error
+				if s.register != nil {
+					err = r.Register(ctx, s.register)
+				} else {
+					err = r.Deregister(ctx, s.deregister)
+				}
+				if err != nil {
+					t.Fatalf("step %d: %v", i, err)
+				}
+				instances, err := r.GetService(ctx, "demo")
+				if err != nil {
+					t.Fatalf("step %d: GetService() error = %v", i, err)
+				}
+				if got := ids(instances); !slices.Equal(got, s.want) {
+					t.Fatalf("step %d: instances = %v, want %v", i, got, s.want)
+				}
+			}
+
+			// 同一 ID 重复注册时覆盖
+			if err := r.Register(ctx, instance("a", "grpc://127.0.0.1:1")); err != nil {
+				t.Fatal(err)
+			}
+			if err := r.Register(ctx, instance("a", "grpc://127.0.0.1:3")); err != nil {
+				t.Fatal(err)
+			}
+			instances, err := r.GetService(ctx, "demo")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := instances[0].Endpoints; len(instances) != 1 || !slices.Equal(got, []string{"grpc://127.0.0.1:3"}) {
+				t.Fatalf("instances = %v, endpoints = %v, want the last registered", ids(instances), got)
+			}
+			if others, _ := r.GetService(ctx, "other"); len(others) != 0 {
+				t.Fatalf("other service instances = %v, want none", ids(others))
+			}
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ctx := context.Background()
+	for name, newRegistry := range registries() {
+		t.Run(name, func(t *testing.T) {
+			r := newRegistry(t)
+			if err := r.Register(ctx, instance("a", "grpc://127.0.0.1:1")); err != nil {
+				t.Fatal(err)
+			}
+			w, err := r.Watch(ctx, "demo")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 第一次调用立即返回当前的实例
+			if got := next(t, w); !slices.Equal(got, []string{"a"}) {
+				t.Fatalf("initial instances = %v, want [a]", got)
+			}
+			if err := r.Register(ctx, instance("b", "grpc://127.0.0.1:2")); err != nil {
+				t.Fatal(err)
+			}
+			if got := next(t, w); !slices.Equal(got, []string{"a", "b"}) {
+				t.Fatalf("instances after register = %v, want [a b]", got)
+			}
+			if err := r.Deregister(ctx, instance("a")); err != nil {
+				t.Fatal(err)
+			}
+			if got := next(t, w); !slices.Equal(got, []string{"b"}) {
+				t.Fatalf("instances after deregister = %v, want [b]", got)
+			}
+
+			// 停止后 Next 返回 ErrWatcherStopped
+			done := make(chan error, 1)
+			go func() {
+				_, err := w.Next()
+				done <- err
+			}()
+			if err := w.Stop(); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case err := <-done:
+				if !errors.Is(err, ErrWatcherStopped) {
+					t.Fatalf("Next() after Stop error = %v, want ErrWatcherStopped", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Next() did not return after Stop")
+			}
+		})
+	}
+}
+
+func TestServiceInstanceEqual(t *testing.T) {
+	a := &ServiceInstance{ID: "a", Name: "demo", Metadata: map[string]string{"weight": "2"}, Endpoints: []string{"grpc://1", "http://1"}}
+	tests := []struct {
+		name string
+		o    *ServiceInstance
+		want bool
+	}{
+		{"endpoints in different order", &ServiceInstance{ID: "a", Name: "demo", Metadata: map[string]string{"weight": "2"}, Endpoints: []string{"http://1", "grpc://1"}}, true},
+		{"different metadata", &ServiceInstance{ID: "a", Name: "demo", Metadata: map[string]string{"weight": "3"}, Endpoints: []string{"grpc://1", "http://1"}}, false},
+		{"different endpoints", &ServiceInstance{ID: "a", Name: "demo", Metadata: map[string]string{"weight": "2"}, Endpoints: []string{"grpc://1"}}, false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := a.Equal(tt.o); got != tt.want {
+				t.Fatalf("Equal() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
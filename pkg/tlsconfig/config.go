@@ -0,0 +1,225 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/yanking/app-skeleton/pkg/log"
+)
+
+// NewServerConfig 创建服务端的 *tls.Config.
+// 证书和 CA 文件在握手时按 ReloadInterval 检查修改时间，变化后自动重新加载，无需重启；
+// 重新加载失败时继续使用旧的证书.
+func NewServerConfig(o *Options) (*tls.Config, error) {
+	if errs := o.Validate(true); len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	r, err := newReloader(o)
+	if err != nil {
+		return nil, err
+	}
+	suites, _ := cipherSuites(o.CipherSuites)
+
+	cfg := &tls.Config{
+		MinVersion:   tlsVersions[o.MinVersion],
+		CipherSuites: suites,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return r.certificate(), nil
+		},
+	}
+
+	// 客户端证书由 verifyPeer 以最新的 CA 校验，以支持 CA 热加载
+	switch auth := clientAuthTypes[o.ClientAuth]; auth {
+	case tls.VerifyClientCertIfGiven:
+		cfg.ClientAuth = tls.RequestClientCert
+		cfg.VerifyPeerCertificate = r.verifyPeer(x509.ExtKeyUsageClientAuth, "")
+	case tls.RequireAndVerifyClientCert:
+		cfg.ClientAuth = tls.RequireAnyClientCert
+		cfg.VerifyPeerCertificate = r.verifyPeer(x509.ExtKeyUsageClientAuth, "")
+	default:
+		cfg.ClientAuth = auth
+	}
+	return cfg, nil
+}
+
+// NewClientConfig 创建客户端的 *tls.Config. 配置了证书时用于双向认证，证书和 CA 同样支持热加载.
+func NewClientConfig(o *Options) (*tls.Config, error) {
+	if errs := o.Validate(false); len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	r, err := newReloader(o)
+	if err != nil {
+		return nil, err
+	}
+	suites, _ := cipherSuites(o.CipherSuites)
+
+	cfg := &tls.Config{
+		MinVersion:         tlsVersions[o.MinVersion],
+		CipherSuites:       suites,
+		ServerName:         o.ServerName,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+	if o.CertFile != "" {
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return r.certificate(), nil
+		}
+	}
+	if o.CAFile != "" && !o.InsecureSkipVerify {
+		// 跳过内置校验，由 VerifyConnection 以最新的 CA 校验服务端证书
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			return r.verifyChain(cs.PeerCertificates, x509.ExtKeyUsageServerAuth, cs.ServerName)
+		}
+	}
+	return cfg, nil
+}
+
+// reloader 按修改时间重新加载证书和 CA
+type reloader struct {
+	opts *Options
+
+	mu        sync.Mutex
+	lastCheck time.Time
+	modTimes  map[string]time.Time
+	cert      *tls.Certificate
+	pool      *x509.CertPool
+}
+
+func newReloader(o *Options) (*reloader, error) {
+	r := &reloader{opts: o, modTimes: make(map[string]time.Time)}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	r.lastCheck = time.Now()
+	return r, nil
+}
+
+func (r *reloader) files() []string {
+	var files []string
+	for _, f := range []string{r.opts.CertFile, r.opts.KeyFile, r.opts.CAFile} {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// load 加载证书和 CA，调用方需持有锁或处于初始化阶段
+func (r *reloader) load() error {
+	modTimes := make(map[string]time.Time)
+	for _, f := range r.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			return fmt.Errorf("tls: %w", err)
+		}
+		modTimes[f] = info.ModTime()
+	}
+
+	var cert *tls.Certificate
+	if r.opts.CertFile != "" {
+		c, err := tls.LoadX509KeyPair(r.opts.CertFile, r.opts.KeyFile)
+		if err != nil {
+			return fmt.Errorf("tls: load key pair: %w", err)
+		}
+		cert = &c
+	}
+
+	var pool *x509.CertPool
+	if r.opts.CAFile != "" {
+		pem, err := os.ReadFile(r.opts.CAFile)
+		if err != nil {
+			return fmt.Errorf("tls: read ca file: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("tls: no certificates found in ca file %s", r.opts.CAFile)
+		}
+	}
+
+	r.cert, r.pool, r.modTimes = cert, pool, modTimes
+	return nil
+}
+
+// maybeReload 距离上次检查超过 ReloadInterval 且文件有变化时重新加载
+func (r *reloader) maybeReload() {
+	if time.Since(r.lastCheck) < r.opts.reloadInterval() {
+		return
+	}
+	r.lastCheck = time.Now()
+
+	changed := false
+	for _, f := range r.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			log.Warnf("tls: failed to stat %s, keeping the current certificates: %v", f, err)
+			return
+		}
+		if !info.ModTime().Equal(r.modTimes[f]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+
+	if err := r.load(); err != nil {
+		log.Errorf("tls: failed to reload certificates, keeping the current ones: %v", err)
+		return
+	}
+	log.Infof("tls: certificates reloaded from %s", r.opts.CertFile)
+}
+
+func (r *reloader) certificate() *tls.Certificate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maybeReload()
+	return r.cert
+}
+
+func (r *reloader) caPool() *x509.CertPool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maybeReload()
+	return r.pool
+}
+
+func (r *reloader) verifyPeer(usage x509.ExtKeyUsage, serverName string) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		// 客户端没有提供证书，是否允许由 ClientAuth 决定
+		if len(rawCerts) == 0 {
+			return nil
+		}
+		certs := make([]*x509.Certificate, 0, len(rawCerts))
+		for _, raw := range rawCerts {
+			c, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return fmt.Errorf("tls: parse peer certificate: %w", err)
+			}
+			certs = append(certs, c)
+		}
+		return r.verifyChain(certs, usage, serverName)
+	}
+}
+
+// verifyChain 以当前的 CA 校验对端证书链
+func (r *reloader) verifyChain(certs []*x509.Certificate, usage x509.ExtKeyUsage, serverName string) error {
+	if len(certs) == 0 {
+		return errors.New("tls: no peer certificate")
+	}
+	opts := x509.VerifyOptions{
+		Roots:         r.caPool(),
+		DNSName:       serverName,
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{usage},
+	}
+	for _, c := range certs[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+	_, err := certs[0].Verify(opts)
+	return err
+}
@@ -0,0 +1,304 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA 测试用的 CA，签发的证书写入 t.TempDir()
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string
+}
+
+var serial int64
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func writePEM(t *testing.T, path, typ string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newCA(t *testing.T, dir, name string) *testCA {
+	t.Helper()
+	serial++
+	key := newKey(t)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, name+".pem")
+	writePEM(t, file, "CERTIFICATE", der)
+	return &testCA{cert: cert, key: key, file: file}
+}
+
+// issue 签发叶子证书，写入 certFile 和 keyFile
+func (ca *testCA) issue(t *testing.T, cn string, usage x509.ExtKeyUsage, certFile, keyFile string) {
+	t.Helper()
+	serial++
+	key := newKey(t)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+}
+
+// handshake 通过本地连接完成一次握手，返回客户端看到的服务端证书和服务端的错误
+func handshake(t *testing.T, server, client *tls.Config) (*x509.Certificate, error) {
+	t.Helper()
+	// 使用带缓冲的 TCP 连接，一方发送 alert 时不会因为对方没有读取而阻塞
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	cc, err := net.Dial("tcp", lis.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	sc, err := lis.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+	_ = sc.SetDeadline(time.Now().Add(5 * time.Second))
+	_ = cc.SetDeadline(time.Now().Add(5 * time.Second))
+
+	srv := tls.Server(sc, server)
+	cli := tls.Client(cc, client)
+	done := make(chan error, 1)
+	go func() {
+		err := srv.Handshake()
+		// 服务端拒绝时关闭连接，使客户端的握手结束
+		if err != nil {
+			_ = srv.Close()
+		}
+		done <- err
+	}()
+
+	var peer *x509.Certificate
+	if err := cli.Handshake(); err == nil {
+		peer = cli.ConnectionState().PeerCertificates[0]
+	} else {
+		_ = cli.Close()
+	}
+	return peer, <-done
+}
+
+func TestClientAuth(t *testing.T) {
+	dir := t.TempDir()
+	ca := newCA(t, dir, "ca")
+	other := newCA(t, dir, "other-ca")
+	serverCert, serverKey := filepath.Join(dir, "server.pem"), filepath.Join(dir, "server-key.pem")
+	ca.issue(t, "server", x509.ExtKeyUsageServerAuth, serverCert, serverKey)
+	clientCert, clientKey := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")
+	ca.issue(t, "client", x509.ExtKeyUsageClientAuth, clientCert, clientKey)
+	otherCert, otherKey := filepath.Join(dir, "other.pem"), filepath.Join(dir, "other-key.pem")
+	other.issue(t, "other", x509.ExtKeyUsageClientAuth, otherCert, otherKey)
+
+	clients := map[string]*Options{
+		"none":    {Enabled: true, CAFile: ca.file, ServerName: "localhost"},
+		"valid":   {Enabled: true, CAFile: ca.file, ServerName: "localhost", CertFile: clientCert, KeyFile: clientKey},
+		"invalid": {Enabled: true, CAFile: ca.file, ServerName: "localhost", CertFile: otherCert, KeyFile: otherKey},
+		// 服务端证书不用于客户端认证
+		"server-cert": {Enabled: true, CAFile: ca.file, ServerName: "localhost", CertFile: serverCert, KeyFile: serverKey},
+	}
+
+	tests := []struct {
+		clientAuth string
+		client     string
+		wantErr    bool
+	}{
+		{ClientAuthNone, "none", false},
+		{ClientAuthNone, "invalid", false},
+		{ClientAuthRequest, "invalid", false},
+		{ClientAuthRequireAny, "none", true},
+		{ClientAuthRequireAny, "invalid", false},
+		{ClientAuthVerifyIfGiven, "none", false},
+		{ClientAuthVerifyIfGiven, "valid", false},
+		{ClientAuthVerifyIfGiven, "invalid", true},
+		{ClientAuthRequireAndVerify, "none", true},
+		{ClientAuthRequireAndVerify, "valid", false},
+		{ClientAuthRequireAndVerify, "invalid", true},
+		{ClientAuthRequireAndVerify, "server-cert", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.clientAuth+"/"+tt.client, func(t *testing.T) {
+			server, err := NewServerConfig(&Options{
+				Enabled: true, CertFile: serverCert, KeyFile: serverKey, CAFile: ca.file, ClientAuth: tt.clientAuth,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			client, err := NewClientConfig(clients[tt.client])
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = handshake(t, server, client)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("handshake error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClientVerifiesServer(t *testing.T) {
+	dir := t.TempDir()
+	ca := newCA(t, dir, "ca")
+	other := newCA(t, dir, "other-ca")
+	serverCert, serverKey := filepath.Join(dir, "server.pem"), filepath.Join(dir, "server-key.pem")
+	ca.issue(t, "server", x509.ExtKeyUsageServerAuth, serverCert, serverKey)
+
+	server, err := NewServerConfig(&Options{Enabled: true, CertFile: serverCert, KeyFile: serverKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		opts    *Options
+		wantErr bool
+	}{
+		{"trusted", &Options{Enabled: true, CAFile: ca.file, ServerName: "localhost"}, false},
+		{"untrusted ca", &Options{Enabled: true, CAFile: other.file, ServerName: "localhost"}, true},
+		{"wrong server name", &Options{Enabled: true, CAFile: ca.file, ServerName: "example.com"}, true},
+		{"insecure skip verify", &Options{Enabled: true, CAFile: other.file, InsecureSkipVerify: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClientConfig(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			peer, _ := handshake(t, server, client)
+			if (peer == nil) != tt.wantErr {
+				t.Fatalf("client accepted server = %t, wantErr %v", peer != nil, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReloadRotatedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	ca := newCA(t, dir, "ca")
+	certFile, keyFile := filepath.Join(dir, "server.pem"), filepath.Join(dir, "server-key.pem")
+	ca.issue(t, "server-v1", x509.ExtKeyUsageServerAuth, certFile, keyFile)
+
+	server, err := NewServerConfig(&Options{
+		Enabled: true, CertFile: certFile, KeyFile: keyFile, ReloadInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClientConfig(&Options{Enabled: true, CAFile: ca.file, ServerName: "localhost"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if peer, _ := handshake(t, server, client); peer == nil || peer.Subject.CommonName != "server-v1" {
+		t.Fatalf("initial certificate = %v, want server-v1", peer)
+	}
+
+	// 轮换证书，修改时间设置为未来以免文件系统的时间精度导致修改时间不变
+	ca.issue(t, "server-v2", x509.ExtKeyUsageServerAuth, certFile, keyFile)
+	future := time.Now().Add(time.Minute)
+	for _, f := range []string{certFile, keyFile} {
+		if err := os.Chtimes(f, future, future); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	if peer, _ := handshake(t, server, client); peer == nil || peer.Subject.CommonName != "server-v2" {
+		t.Fatalf("rotated certificate = %v, want server-v2", peer)
+	}
+
+	// 新证书不合法时继续使用旧的证书
+	if err := os.WriteFile(certFile, []byte("broken"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	future = future.Add(time.Minute)
+	if err := os.Chtimes(certFile, future, future); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if peer, _ := handshake(t, server, client); peer == nil || peer.Subject.CommonName != "server-v2" {
+		t.Fatalf("certificate after failed reload = %v, want server-v2", peer)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		server  bool
+		wantErr bool
+	}{
+		{"disabled", Options{}, true, false},
+		{"server without cert", Options{Enabled: true}, true, true},
+		{"client without cert", Options{Enabled: true}, false, false},
+		{"cert without key", Options{Enabled: true, CertFile: "cert.pem"}, false, true},
+		{"verify without ca", Options{Enabled: true, CertFile: "c", KeyFile: "k", ClientAuth: ClientAuthRequireAndVerify}, true, true},
+		{"unknown client auth", Options{Enabled: true, CertFile: "c", KeyFile: "k", ClientAuth: "always"}, true, true},
+		{"unknown min version", Options{Enabled: true, MinVersion: "1.1"}, false, true},
+		{"unknown cipher suite", Options{Enabled: true, CipherSuites: []string{"TLS_FOO"}}, false, true},
+		{"negative reload interval", Options{Enabled: true, ReloadInterval: -time.Second}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if errs := tt.opts.Validate(tt.server); (len(errs) > 0) != tt.wantErr {
+				t.Fatalf("Validate() = %v, wantErr %v", errs, tt.wantErr)
+			}
+		})
+	}
+}
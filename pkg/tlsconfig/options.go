@@ -0,0 +1,122 @@
+// Package tlsconfig 根据配置创建服务端和客户端的 *tls.Config，支持双向认证和证书热加载.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"fmt"
+	"time"
+)
+
+const defaultReloadInterval = 10 * time.Second
+
+// 客户端认证模式
+const (
+	ClientAuthNone             = "none"
+	ClientAuthRequest          = "request"
+	ClientAuthRequireAny       = "require-any"
+	ClientAuthVerifyIfGiven    = "verify-if-given"
+	ClientAuthRequireAndVerify = "require-and-verify"
+)
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"":                         tls.NoClientCert,
+	ClientAuthNone:             tls.NoClientCert,
+	ClientAuthRequest:          tls.RequestClientCert,
+	ClientAuthRequireAny:       tls.RequireAnyClientCert,
+	ClientAuthVerifyIfGiven:    tls.VerifyClientCertIfGiven,
+	ClientAuthRequireAndVerify: tls.RequireAndVerifyClientCert,
+}
+
+var tlsVersions = map[string]uint16{
+	"":    tls.VersionTLS12,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Options TLS 相关配置，服务端和客户端共用
+type Options struct {
+	// Enabled 是否启用 TLS
+	Enabled bool `json:"enabled" mapstructure:"enabled"`
+	// CertFile 证书文件，服务端必填；客户端填写时用于双向认证
+	CertFile string `json:"cert-file" mapstructure:"cert-file"`
+	// KeyFile 私钥文件
+	KeyFile string `json:"key-file" mapstructure:"key-file"`
+	// CAFile CA 证书文件. 服务端用于校验客户端证书，客户端用于校验服务端证书，为空时客户端使用系统根证书
+	CAFile string `json:"ca-file" mapstructure:"ca-file"`
+	// ClientAuth 服务端对客户端证书的要求，可选值：none, request, require-any, verify-if-given, require-and-verify
+	ClientAuth string `json:"client-auth" mapstructure:"client-auth"`
+	// MinVersion 最低 TLS 版本，可选值：1.2, 1.3，默认 1.2
+	MinVersion string `json:"min-version" mapstructure:"min-version"`
+	// CipherSuites 允许的密码套件名称（如 TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256），为空时使用 Go 的默认值，对 TLS 1.3 不生效
+	CipherSuites []string `json:"cipher-suites" mapstructure:"cipher-suites"`
+	// ServerName 客户端校验服务端证书时使用的主机名，为空时使用连接地址中的主机名
+	ServerName string `json:"server-name" mapstructure:"server-name"`
+	// InsecureSkipVerify 客户端不校验服务端证书，仅用于测试
+	InsecureSkipVerify bool `json:"insecure-skip-verify" mapstructure:"insecure-skip-verify"`
+	// ReloadInterval 检查证书文件是否变化的最小间隔，默认 10s
+	ReloadInterval time.Duration `json:"reload-interval" mapstructure:"reload-interval"`
+}
+
+// Validate 校验配置是否合法. server 为 true 时按服务端的要求校验.
+func (o *Options) Validate(server bool) []error {
+	if o == nil || !o.Enabled {
+		return nil
+	}
+
+	var errs []error
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		errs = append(errs, fmt.Errorf("tls: cert-file and key-file must be set together"))
+	}
+	if server && o.CertFile == "" {
+		errs = append(errs, fmt.Errorf("tls: cert-file and key-file are required for a server"))
+	}
+	auth, ok := clientAuthTypes[o.ClientAuth]
+	if !ok {
+		errs = append(errs, fmt.Errorf("tls: invalid client-auth %q", o.ClientAuth))
+	}
+	if server && (auth == tls.VerifyClientCertIfGiven || auth == tls.RequireAndVerifyClientCert) && o.CAFile == "" {
+		errs = append(errs, fmt.Errorf("tls: client-auth %q requires ca-file", o.ClientAuth))
+	}
+	if _, ok := tlsVersions[o.MinVersion]; !ok {
+		errs = append(errs, fmt.Errorf("tls: invalid min-version %q, must be one of 1.2, 1.3", o.MinVersion))
+	}
+	if _, err := cipherSuites(o.CipherSuites); err != nil {
+		errs = append(errs, err)
+	}
+	if o.ReloadInterval < 0 {
+		errs = append(errs, fmt.Errorf("tls: reload-interval must not be negative"))
+	}
+	return errs
+}
+
+func (o *Options) reloadInterval() time.Duration {
+	if o.ReloadInterval > 0 {
+		return o.ReloadInterval
+	}
+	return defaultReloadInterval
+}
+
+// cipherSuites 将密码套件名称转换为 ID
+func cipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	known := make(map[string]uint16)
+	for _, s := range tls.CipherSuites() {
+		known[s.Name] = s.ID
+	}
+	for _, s := range tls.InsecureCipherSuites() {
+		known[s.Name] = s.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("tls: unknown cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
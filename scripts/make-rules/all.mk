@@ -3,4 +3,5 @@ include scripts/make-rules/common.mk
 include scripts/make-rules/golang.mk
 include scripts/make-rules/tools.mk
 include scripts/make-rules/swagger.mk
-include scripts/make-rules/generate.mk
\ No newline at end of file
+include scripts/make-rules/generate.mk
+include scripts/make-rules/cert.mk
\ No newline at end of file
@@ -0,0 +1,25 @@
+## ==============================================================================
+## Makefile helper functions for certificates
+## ==============================================================================
+
+# 本地测试证书存放目录
+CERT_DIR := $(OUTPUT_DIR)/cert
+
+cert.gen: ## 生成本地测试用的 CA、服务端和客户端证书
+	@echo "===========> Generating certificates to $(CERT_DIR)"
+	@mkdir -p $(CERT_DIR)
+	@openssl req -x509 -newkey rsa:2048 -nodes -days 365 -subj "/CN=app-skeleton-ca" \
+		-keyout $(CERT_DIR)/ca.key -out $(CERT_DIR)/ca.crt 2>/dev/null
+	@for name in server client; do \
+		if [ $$name = server ]; then usage=serverAuth; else usage=clientAuth; fi; \
+		openssl req -newkey rsa:2048 -nodes -subj "/CN=$$name" \
+			-keyout $(CERT_DIR)/$$name.key -out $(CERT_DIR)/$$name.csr 2>/dev/null; \
+		printf "subjectAltName=DNS:localhost,IP:127.0.0.1\nextendedKeyUsage=$$usage\n" > $(CERT_DIR)/$$name.ext; \
+		openssl x509 -req -in $(CERT_DIR)/$$name.csr -CA $(CERT_DIR)/ca.crt -CAkey $(CERT_DIR)/ca.key \
+			-CAcreateserial -days 365 -extfile $(CERT_DIR)/$$name.ext -out $(CERT_DIR)/$$name.crt 2>/dev/null; \
+		rm -f $(CERT_DIR)/$$name.csr $(CERT_DIR)/$$name.ext; \
+	done
+	@echo "Generated at: $(CERT_DIR)"
+
+# 伪目标（防止文件与目标名称冲突）
+.PHONY: cert.gen
@@ -36,6 +36,8 @@ install.protoc-plugins: ## Install protoc-plugins
 	@$(GO) install github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2@latest
 	@$(GO) install google.golang.org/protobuf/cmd/protoc-gen-go@latest
 	@$(GO) install google.golang.org/grpc/cmd/protoc-gen-go-grpc@latest
+	@$(GO) install ./cmd/protoc-gen-go-errors
+	@$(GO) install ./cmd/protoc-gen-openapiv2-validate
 
 .PHONY: install.swagger
 install.swagger: ## Install swagger